@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func agingCommand(fr *flags.Set, conf *string) {
+	var typ, at, output string
+	fr.Add("aging").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.StringVar(&typ, "type", "receivable", "receivable or payable")
+		set.StringVar(&at, "at", "", "age open amounts at this date (default: today)")
+		set.StringVar(&output, "o", "table", "output format: table or csv")
+		return func(h *flags.Help) {
+			h.Add("accounts receivable / payable aging report.")
+			h.Add("open amounts per customer or vendor, bucketed by days overdue.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		now := time.Now()
+		if at != "" {
+			var err error
+			if now, err = time.Parse(dFormat, at); err != nil {
+				return err
+			}
+		}
+
+		var accType gnucash.AccountType
+		switch typ {
+		case "receivable":
+			accType = gnucash.AccountTypeReceivable
+		case "payable":
+			accType = gnucash.AccountTypePayable
+		default:
+			return fmt.Errorf("unknown aging type '%s'", typ)
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		lines, err := book.Aging(accType, now)
+		if err != nil {
+			return err
+		}
+
+		header := []string{"owner", "account"}
+		header = append(header, gnucash.AgingBucketNames[:]...)
+		header = append(header, "total")
+		row := func(l gnucash.AgingLine) []string {
+			owner, account := l.Owner, ""
+			if owner == "" {
+				owner = "(no owner)"
+			}
+			if l.Account != nil {
+				account = l.Account.FQN
+			}
+			r := []string{owner, account}
+			for _, v := range l.Buckets {
+				r = append(r, fmt.Sprintf("%.2f", v.Float64()))
+			}
+			return append(r, fmt.Sprintf("%.2f", l.Total().Float64()))
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, l := range lines {
+				fmt.Fprintln(w, strings.Join(row(l), "\t")+"\t")
+			}
+			fmt.Fprintln(w, strings.Join(row(lines.Total()), "\t")+"\t")
+			return w.Flush()
+
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			for _, l := range lines {
+				if err := w.Write(row(l)); err != nil {
+					return err
+				}
+			}
+			if err := w.Write(row(lines.Total())); err != nil {
+				return err
+			}
+			w.Flush()
+			return w.Error()
+		}
+
+		return fmt.Errorf("unknown output format '%s'", output)
+	})
+}
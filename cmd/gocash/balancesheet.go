@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func balanceSheetCommand(fr *flags.Set, conf *string) {
+	var at, compare, output, tab string
+	var depth int
+	fr.Add("balance-sheet").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.StringVar(&at, "at", "", "date of the balance sheet (default: today)")
+		set.StringVar(&compare, "compare", "", "comma separated prior dates to add as columns")
+		set.IntVar(&depth, "depth", 0, "maximum account depth, deeper accounts are summed into their parent")
+		set.StringVar(&output, "o", "table", "output format: table, csv, json or sheet")
+		set.StringVar(&tab, "tab", "Balance Sheet", "sheet tab to write to with -o sheet")
+		return func(h *flags.Help) {
+			h.Add("assets, liabilities and equity at a date.")
+			h.Add("amounts are converted to the report currency or the book's currency.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		date := time.Now()
+		var err error
+		if at != "" {
+			if date, err = time.Parse(dFormat, at); err != nil {
+				return err
+			}
+		}
+		dates := []time.Time{endOfDay(date)}
+		for _, c := range strings.Split(compare, ",") {
+			if c = strings.TrimSpace(c); c == "" {
+				continue
+			}
+			d, err := time.Parse(dFormat, c)
+			if err != nil {
+				return err
+			}
+			dates = append(dates, endOfDay(d))
+		}
+
+		currency, err := reportCurrency(*conf)
+		if err != nil {
+			return err
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		st, err := book.BalanceSheet(dates, currency, depth)
+		if err != nil {
+			return err
+		}
+
+		liabilities, _ := st.Section(gnucash.SectionLiabilities)
+		equity, _ := st.Section(gnucash.SectionEquity)
+		total := make([]gnucash.Value, len(dates))
+		for i := range total {
+			total[i] = liabilities.Total[i].Add(equity.Total[i])
+		}
+
+		return writeStatement(
+			st,
+			[]statementTotal{{"Total Liabilities and Equity", total}},
+			output,
+			*conf,
+			tab,
+		)
+	})
+}
+
+// endOfDay returns the last second of the day t falls in.
+func endOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location()).Add(-time.Second)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func budgetCommand(fr *flags.Set, conf *string) {
+	var name, at, ignore, output string
+	var all, total bool
+	fr.Add("budget").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.StringVar(&name, "name", "", "name of the budget (default: the first one)")
+		set.StringVar(&at, "at", "", "compare the period containing this date (default: today)")
+		set.BoolVar(&all, "all", false, "compare all periods")
+		set.BoolVar(&total, "total", false, "sum the compared periods per account")
+		set.StringVar(&ignore, "ignore", "", "regex of accounts to leave out")
+		set.StringVar(&output, "o", "table", "output format: table or csv")
+		return func(h *flags.Help) {
+			h.Add("compare a budget to the actual amounts in the book.")
+			h.Add("accounts are filtered by the regexes passed as arguments,")
+			h.Add(fmt.Sprintf("those matching -ignore or %s[] are left out.", KReportIgnore))
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		if len(book.Budgets) == 0 {
+			return errors.New("book has no budgets")
+		}
+
+		budget := book.Budgets[0]
+		if name != "" {
+			var ok bool
+			if budget, ok = book.Budgets.ByName(name); !ok {
+				return fmt.Errorf("no such budget '%s'", name)
+			}
+		}
+
+		include, err := anyRegexp(args)
+		if err != nil {
+			return err
+		}
+
+		ignores, err := confPrefixArray(*conf, KReportIgnore)
+		if err != nil {
+			return err
+		}
+		if ignore != "" {
+			ignores = append(ignores, ignore)
+		}
+		exclude, err := anyRegexp(ignores)
+		if err != nil {
+			return err
+		}
+
+		period := -1
+		if !all {
+			t := time.Now()
+			if at != "" {
+				if t, err = time.Parse(dFormat, at); err != nil {
+					return err
+				}
+			}
+			var ok bool
+			if period, ok = budget.PeriodAt(t); !ok {
+				return fmt.Errorf(
+					"%s is not within budget '%s', use -all or -at",
+					t.Format(dFormat),
+					budget.Name,
+				)
+			}
+		}
+
+		lines := budget.Compare(book.Transactions, period, include, exclude)
+		if total {
+			lines = lines.Total()
+		}
+
+		header := []string{"from", "to", "account", "budgeted", "actual", "variance", "used"}
+		row := func(l gnucash.BudgetLine) []string {
+			used := ""
+			if u, ok := l.Used(); ok {
+				used = fmt.Sprintf("%.1f%%", u)
+			}
+			return []string{
+				l.Start.Format(dFormat),
+				l.End.AddDate(0, 0, -1).Format(dFormat),
+				l.Account.FQN,
+				fmt.Sprintf("%.2f", l.Budgeted.Float64()),
+				fmt.Sprintf("%.2f", l.Actual.Float64()),
+				fmt.Sprintf("%.2f", l.Variance().Float64()),
+				used,
+			}
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, l := range lines {
+				fmt.Fprintln(w, strings.Join(row(l), "\t")+"\t")
+			}
+			return w.Flush()
+
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			for _, l := range lines {
+				if err := w.Write(row(l)); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+
+		return fmt.Errorf("unknown output format '%s'", output)
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+type jsonProblem struct {
+	Severity gnucash.Severity `json:"severity"`
+	Check    string           `json:"check"`
+	Object   string           `json:"object"`
+	ID       gnucash.GUID     `json:"id"`
+	Message  string           `json:"message"`
+}
+
+func checkCommand(fr *flags.Set, conf *string) {
+	var output string
+	var strict bool
+	fr.Add("check").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.StringVar(&output, "o", "table", "output format: table, csv or json")
+		set.BoolVar(&strict, "strict", false, "exit non-zero on warnings too")
+		return func(h *flags.Help) {
+			h.Add("check the integrity of the book.")
+			h.Add("errors:")
+			h.Add("  " + gnucash.CheckUnbalanced + ": transactions whose values do not add up to zero")
+			h.Add("  " + gnucash.CheckUnknownAccount + ": splits and invoices referring to accounts not in the book")
+			h.Add("  " + gnucash.CheckUnknownLot + ": splits and invoices referring to lots not in the book")
+			h.Add("  " + gnucash.CheckUnknownTransaction + ": invoices referring to transactions not in the book")
+			h.Add("  " + gnucash.CheckCommodity + ": split quantities that do not match their account")
+			h.Add("  " + gnucash.CheckDanglingParent + ": accounts whose parent is not in the book")
+			h.Add("  " + gnucash.CheckCycle + ": accounts that are their own ancestor")
+			h.Add("  " + gnucash.CheckUnknownCommodity + ": accounts and prices in unknown commodities")
+			h.Add("warnings:")
+			h.Add("  " + gnucash.CheckImbalanceAccount + ", " + gnucash.CheckOrphanAccount + ": splits in Imbalance-* / Orphan-* accounts")
+			h.Add("  " + gnucash.CheckPlaceholder + ": splits in placeholder accounts")
+			h.Add("  " + gnucash.CheckCommodity + ": opposite signs, quantities finer than the commodity")
+			h.Add("exits non-zero when errors are found.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		ps := book.Check()
+		row := func(p gnucash.Problem) []string {
+			return []string{string(p.Severity), p.Check, p.Object, string(p.ID), p.Message}
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, p := range ps {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", p.Severity, p.Check, p.Object, p.ID, p.Message)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write([]string{"severity", "check", "object", "id", "message"}); err != nil {
+				return err
+			}
+			for _, p := range ps {
+				if err := w.Write(row(p)); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+		case "json":
+			l := make([]jsonProblem, len(ps))
+			for i, p := range ps {
+				l[i] = jsonProblem(p)
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(l); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown output format '%s'", output)
+		}
+
+		errs, warnings := ps.Count(gnucash.SeverityError), ps.Count(gnucash.SeverityWarning)
+		if errs != 0 || (strict && warnings != 0) {
+			return fmt.Errorf("%d errors, %d warnings", errs, warnings)
+		}
+		fmt.Fprintf(os.Stderr, "%d errors, %d warnings\n", errs, warnings)
+
+		return nil
+	})
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func duplicatesCommand(fr *flags.Set, conf *string) {
+	var days int
+	var min float64
+	var output, drop, reason string
+	var void, remove, interactive bool
+	fr.Add("duplicates").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.IntVar(&days, "days", 3, "maximum number of days between duplicates")
+		set.Float64Var(&min, "min", 0.6, "minimum score (0-1)")
+		set.StringVar(&output, "o", "table", "output format: table or csv")
+		set.BoolVar(&void, "void", false, "void the chosen copies and write the book")
+		set.BoolVar(&remove, "delete", false, "delete the chosen copies and write the book")
+		set.StringVar(&drop, "drop", "", "comma separated ids of the copies to void or delete")
+		set.BoolVar(&interactive, "i", false, "choose the copy to void or delete for each pair")
+		set.StringVar(&reason, "reason", "duplicate", "void reason")
+		return func(h *flags.Help) {
+			h.Add("find likely duplicate transactions.")
+			h.Add("pairs of transactions moving the same amount in the same account")
+			h.Add("within -days of each other, scored on description similarity,")
+			h.Add("date distance and the accounts they share.")
+			h.Add("with -void or -delete the copies listed in -drop, or chosen")
+			h.Add("per pair with -i, are removed.")
+			h.Add("transactions with reconciled or frozen splits are never removed.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		if void && remove {
+			return errors.New("-void and -delete are mutually exclusive")
+		}
+		if drop != "" && interactive {
+			return errors.New("-drop and -i are mutually exclusive")
+		}
+		if (void || remove) != (drop != "" || interactive) {
+			return errors.New("-void and -delete require the copies to drop from -drop or -i")
+		}
+
+		data, err := readdata(*conf)
+		if err != nil {
+			return err
+		}
+		if void || remove {
+			if err := writable(data); err != nil {
+				return err
+			}
+		}
+		book := data.Books[0]
+
+		dupes := book.Duplicates(days, min)
+
+		header := []string{
+			"score",
+			"account",
+			"amount",
+			"first date",
+			"first description",
+			"last date",
+			"last description",
+			"first id",
+			"last id",
+		}
+		row := func(d gnucash.Duplicate) []string {
+			return []string{
+				fmt.Sprintf("%.2f", d.Score),
+				d.Account.FQN,
+				fmt.Sprintf("%.2f", d.Amount.Float64()),
+				d.A.DatePosted.Get().Format(dFormat),
+				d.A.Description,
+				d.B.DatePosted.Get().Format(dFormat),
+				d.B.Description,
+				string(d.A.ID),
+				string(d.B.ID),
+			}
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, d := range dupes {
+				fmt.Fprintln(w, strings.Join(row(d), "\t")+"\t")
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			for _, d := range dupes {
+				if err := w.Write(row(d)); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown output format '%s'", output)
+		}
+
+		if !void && !remove {
+			return nil
+		}
+
+		var dropped []*gnucash.Transaction
+		if interactive {
+			dropped, err = chooseDuplicates(dupes)
+		} else {
+			dropped, err = listedDuplicates(dupes, strings.Split(drop, ","))
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, t := range dropped {
+			if remove {
+				err = book.RemoveTransaction(t)
+			} else {
+				err = t.Void(reason, now)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		action := "voided"
+		if remove {
+			action = "deleted"
+		}
+		fmt.Fprintf(os.Stderr, "%s %d transactions\n", action, len(dropped))
+		if len(dropped) == 0 {
+			return nil
+		}
+
+		return writedata(*conf, data)
+	})
+}
+
+// listedDuplicates returns the transactions with the given ids, each must be
+// a copy in one of the duplicates and must not be reconciled.
+func listedDuplicates(dupes []gnucash.Duplicate, ids []string) ([]*gnucash.Transaction, error) {
+	copies := make(map[gnucash.GUID]*gnucash.Transaction)
+	for _, d := range dupes {
+		copies[d.A.ID] = d.A
+		copies[d.B.ID] = d.B
+	}
+
+	seen := make(map[gnucash.GUID]struct{})
+	l := make([]*gnucash.Transaction, 0, len(ids))
+	for _, id := range ids {
+		id := gnucash.GUID(strings.TrimSpace(id))
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		t, ok := copies[id]
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a duplicate", id)
+		}
+		if t.Reconciled() {
+			return nil, fmt.Errorf("'%s' (%s): %w", id, t.Description, gnucash.ErrReconciled)
+		}
+		l = append(l, t)
+	}
+
+	return l, nil
+}
+
+// chooseDuplicates asks which copy of each pair to drop, best scoring pairs
+// first. Pairs with a copy that was already dropped are skipped and a copy
+// that was kept is not offered again.
+func chooseDuplicates(dupes []gnucash.Duplicate) ([]*gnucash.Transaction, error) {
+	dropped := make(map[gnucash.GUID]struct{})
+	kept := make(map[gnucash.GUID]struct{})
+	l := make([]*gnucash.Transaction, 0)
+
+	s := bufio.NewScanner(os.Stdin)
+	for _, d := range dupes {
+		_, a := dropped[d.A.ID]
+		_, b := dropped[d.B.ID]
+		if a || b {
+			continue
+		}
+
+		pair := [2]*gnucash.Transaction{d.A, d.B}
+		choosable := func(t *gnucash.Transaction) bool {
+			_, ok := kept[t.ID]
+			return !ok && !t.Reconciled()
+		}
+		if !choosable(d.A) && !choosable(d.B) {
+			continue
+		}
+
+		fmt.Printf("\n%.2f  %s  %.2f\n", d.Score, d.Account.FQN, d.Amount.Float64())
+		for i, t := range pair {
+			note := ""
+			if t.Reconciled() {
+				note = "  (reconciled)"
+			} else if _, ok := kept[t.ID]; ok {
+				note = "  (kept)"
+			}
+			fmt.Printf("%d) %s  %s%s\n", i+1, t.DatePosted.Get().Format(dFormat), t.Description, note)
+		}
+
+	ask:
+		for {
+			fmt.Print("drop (1) (2), (s)kip, (d)one, (q)uit: ")
+			if !s.Scan() {
+				if err := s.Err(); err != nil {
+					return nil, err
+				}
+				return nil, errors.New("aborted")
+			}
+
+			switch in := strings.TrimSpace(s.Text()); in {
+			case "q":
+				return nil, errors.New("aborted")
+			case "d":
+				return l, nil
+			case "s":
+				break ask
+			case "1", "2":
+				i := int(in[0] - '1')
+				t, o := pair[i], pair[1-i]
+				if !choosable(t) {
+					fmt.Fprintf(os.Stderr, "\033[1;31m%s can not be dropped\033[0m\n", in)
+					continue
+				}
+				dropped[t.ID] = struct{}{}
+				kept[o.ID] = struct{}{}
+				l = append(l, t)
+				break ask
+			}
+		}
+	}
+
+	return l, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+// forecastAccounts selects the accounts matching any of the given regexes,
+// or all bank and cash accounts if there are none.
+func forecastAccounts(book *gnucash.Book, patterns []string) (gnucash.Accounts, error) {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		var err error
+		if res[i], err = regexp.Compile(p); err != nil {
+			return nil, err
+		}
+	}
+
+	l := make(gnucash.Accounts, 0)
+	for _, a := range book.Accounts {
+		if len(res) == 0 {
+			if a.Type == gnucash.AccountTypeBank || a.Type == gnucash.AccountTypeCash {
+				l = append(l, a)
+			}
+			continue
+		}
+
+		for _, re := range res {
+			if re.MatchString(a.FQN) {
+				l = append(l, a)
+				break
+			}
+		}
+	}
+
+	if len(l) == 0 {
+		return nil, errors.New("no accounts to forecast")
+	}
+
+	return l, nil
+}
+
+func forecastCommand(fr *flags.Set, conf *string) {
+	var days int
+	var from, output string
+	var changes bool
+	fr.Add("forecast").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.IntVar(&days, "days", 90, "number of days to project")
+		set.StringVar(&from, "from", "", "first day of the projection (default: today)")
+		set.StringVar(&output, "o", "table", "output format: table, csv or sheet")
+		set.BoolVar(&changes, "changes", false, "only show days on which a balance changes")
+		return func(h *flags.Help) {
+			h.Add("project account balances using the book's scheduled transactions.")
+			h.Add("accounts are selected by the regexes passed as arguments or the")
+			h.Add(fmt.Sprintf("%s[] config entries and default to all bank and cash accounts.", KForecastAccount))
+			h.Add("days on which an asset account would be negative are flagged with '!'")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		if days < 0 {
+			return errors.New("-days can not be negative")
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		patterns := args
+		if len(patterns) == 0 {
+			if patterns, err = confPrefixArray(*conf, KForecastAccount); err != nil {
+				return err
+			}
+		}
+
+		accounts, err := forecastAccounts(book, patterns)
+		if err != nil {
+			return err
+		}
+
+		first := time.Now()
+		if from != "" {
+			if first, err = time.Parse(dFormat, from); err != nil {
+				return err
+			}
+		}
+
+		forecast, err := book.Forecast(accounts, first, first.AddDate(0, 0, days))
+		if err != nil {
+			return err
+		}
+		for _, err := range forecast.Skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s\n", err)
+		}
+
+		negative := make(map[time.Time]map[*gnucash.Account]struct{})
+		alerted := make(map[*gnucash.Account]struct{})
+		for _, a := range forecast.Alerts() {
+			if negative[a.Date] == nil {
+				negative[a.Date] = make(map[*gnucash.Account]struct{})
+			}
+			negative[a.Date][a.Account] = struct{}{}
+			if _, ok := alerted[a.Account]; !ok {
+				alerted[a.Account] = struct{}{}
+				fmt.Fprintf(os.Stderr, "\033[1;31m%s\033[0m\n", a)
+			}
+		}
+
+		list := forecast.Days
+		if changes {
+			list = forecast.Changes()
+		}
+
+		header := make([]string, 1, len(accounts)+1)
+		header[0] = "date"
+		for _, a := range accounts {
+			header = append(header, a.FQN)
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, d := range list {
+				row := make([]string, 1, len(accounts)+1)
+				row[0] = d.Date.Format(dFormat)
+				for i, a := range accounts {
+					flag := ""
+					if _, ok := negative[d.Date][a]; ok {
+						flag = " !"
+					}
+					row = append(row, fmt.Sprintf("%.2f%s", d.Balances[i].Float64(), flag))
+				}
+				fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+			}
+			return w.Flush()
+
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			for _, d := range list {
+				row := make([]string, 1, len(accounts)+1)
+				row[0] = d.Date.Format(dFormat)
+				for i := range accounts {
+					row = append(row, fmt.Sprintf("%.2f", d.Balances[i].Float64()))
+				}
+				if err := w.Write(row); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+
+		case "sheet":
+			srv, sid, err := sheetService(*conf)
+			if err != nil {
+				return err
+			}
+
+			end := start("Updating forecast sheet")
+			defer end()
+			vals := make([][]interface{}, 1, len(list)+1)
+			vals[0] = make([]interface{}, len(header))
+			for i, h := range header {
+				vals[0][i] = h
+			}
+			for _, d := range list {
+				row := make([]interface{}, 1, len(accounts)+1)
+				row[0] = d.Date.Format(dFormat)
+				for i := range accounts {
+					row = append(row, d.Balances[i].Float64())
+				}
+				vals = append(vals, row)
+			}
+
+			return sheetUpdate(srv, sid, "Forecast!A1", sheetPad(vals, len(header)))
+		}
+
+		return fmt.Errorf("unknown output format '%s'", output)
+	})
+}
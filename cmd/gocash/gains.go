@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func gainsCommand(fr *flags.Set, conf *string) {
+	var policy, output string
+	var year int
+	var unrealized bool
+	fr.Add("gains").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.IntVar(&year, "year", time.Now().Year(), "tax year")
+		set.StringVar(&policy, "policy", "fifo", "how to match sales without lots: fifo, lifo or average")
+		set.BoolVar(&unrealized, "unrealized", false, "list unrealized gains at the end of the year instead")
+		set.StringVar(&output, "o", "table", "output format: table or csv")
+		return func(h *flags.Help) {
+			h.Add("realized and unrealized capital gains of stock and mutual fund accounts.")
+			h.Add("accounts are filtered by the regexes passed as arguments.")
+			h.Add(fmt.Sprintf("amounts are converted to %s if set.", KReportCurrency))
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		p, err := gnucash.ParseGainsPolicy(policy)
+		if err != nil {
+			return err
+		}
+
+		include, err := anyRegexp(args)
+		if err != nil {
+			return err
+		}
+
+		currency, err := reportCurrency(*conf)
+		if err != nil {
+			return err
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		accounts := make(gnucash.Accounts, 0)
+		for _, a := range book.Accounts {
+			if a.Type != gnucash.AccountTypeStock && a.Type != gnucash.AccountTypeMutual {
+				continue
+			}
+			if include == nil || include.MatchString(a.FQN) {
+				accounts = append(accounts, a)
+			}
+		}
+
+		from := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+		to := from.AddDate(1, 0, 0)
+		at := to.Add(-time.Second)
+		if now := time.Now(); now.Before(at) {
+			at = now
+		}
+
+		gains, err := book.Gains(accounts, p, currency, at)
+		if err != nil {
+			return err
+		}
+
+		f := func(v gnucash.Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+		d := func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(dFormat)
+		}
+
+		var header []string
+		var rows [][]string
+		if unrealized {
+			header = []string{"account", "acquired", "quantity", "cost", "market", "gain", "currency"}
+			for _, g := range gains.Unrealized {
+				rows = append(rows, []string{
+					g.Account.FQN,
+					d(g.Acquired),
+					strconv.FormatFloat(g.Quantity.Float64(), 'f', -1, 64),
+					f(g.Cost),
+					f(g.Market),
+					f(g.Gain()),
+					string(g.Currency),
+				})
+			}
+			cost, market := gains.Unrealized.Total()
+			rows = append(rows, []string{"total", "", "", f(cost), f(market), f(market.Sub(cost)), ""})
+		} else {
+			header = []string{"account", "acquired", "sold", "quantity", "cost", "proceeds", "gain", "currency"}
+			realized := gains.Realized.Between(from, to)
+			for _, g := range realized {
+				rows = append(rows, []string{
+					g.Account.FQN,
+					d(g.Acquired),
+					d(g.Sold),
+					strconv.FormatFloat(g.Quantity.Float64(), 'f', -1, 64),
+					f(g.Cost),
+					f(g.Proceeds),
+					f(g.Gain()),
+					string(g.Currency),
+				})
+			}
+			cost, proceeds := realized.Total()
+			rows = append(rows, []string{"total", "", "", "", f(cost), f(proceeds), f(proceeds.Sub(cost)), ""})
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, r := range rows {
+				fmt.Fprintln(w, strings.Join(r, "\t")+"\t")
+			}
+			return w.Flush()
+
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			if err := w.WriteAll(rows); err != nil {
+				return err
+			}
+			return w.Error()
+		}
+
+		return fmt.Errorf("unknown output format '%s'", output)
+	})
+}
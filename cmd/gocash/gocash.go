@@ -28,9 +28,12 @@ type group struct {
 	num     string
 	date    string
 	account string
-	amount  float64
+	amount  gnucash.Value
 	descr   string
 	memo    string
+	// onlineID is the bank's id of the transaction, stored in the split
+	// when inserting.
+	onlineID string
 }
 
 func (g *group) Add(s *group) {
@@ -38,7 +41,7 @@ func (g *group) Add(s *group) {
 		panic("HEY da mag niii")
 	}
 
-	g.amount += s.amount
+	g.amount = g.amount.Add(s.amount)
 }
 
 func (g *group) Fields() []string {
@@ -46,7 +49,7 @@ func (g *group) Fields() []string {
 		g.num,
 		g.date,
 		g.account,
-		fmt.Sprintf("%.2f", g.amount),
+		fmt.Sprintf("%.2f", g.amount.Float64()),
 		"1",
 		g.descr,
 		g.memo,
@@ -77,9 +80,18 @@ type transaction struct {
 	date   string
 	from   string
 	to     string
-	amount float64
+	amount gnucash.Value
 	descr  string
 	memo   string
+	// splits divide the amount of the to side, or the from side if
+	// splitFrom, between accounts.
+	splits    []txSplit
+	splitFrom bool
+}
+
+type txSplit struct {
+	account string
+	percent gnucash.Value
 }
 
 func (tx *transaction) GenID() error {
@@ -107,7 +119,7 @@ func (tx *transaction) Groups(n int) (from, to *group) {
 	from.num = num
 	from.date = tx.date
 	from.account = tx.from
-	from.amount = -tx.amount
+	from.amount = tx.amount.Neg()
 	from.descr = ""
 	from.memo = ""
 
@@ -121,6 +133,54 @@ func (tx *transaction) Groups(n int) (from, to *group) {
 	return
 }
 
+// SplitGroups returns the groups of the transaction like Groups, with the
+// split side divided between the accounts of tx.splits. Each part is
+// rounded to the smallest unit of its account (see accountSCU) so the book
+// does not round it again, rounding differences go to the last split.
+func (tx *transaction) SplitGroups(n int, scu func(account string) int64) (from, to []*group) {
+	f, t := tx.Groups(n)
+	from, to = []*group{f}, []*group{t}
+	if len(tx.splits) == 0 {
+		return
+	}
+
+	side := t
+	if tx.splitFrom {
+		side = f
+	}
+
+	parts := make([]*group, len(tx.splits))
+	rest := side.amount
+	for i, s := range tx.splits {
+		g := *side
+		g.account = s.account
+		g.amount = side.amount.Mul(s.percent).Div(gnucash.NewValue(100, 1)).Convert(scu(s.account), gnucash.RoundBankers)
+		if i == len(tx.splits)-1 {
+			g.amount = rest
+		}
+		rest = rest.Sub(g.amount)
+		parts[i] = &g
+	}
+
+	if tx.splitFrom {
+		from = parts
+		return
+	}
+	to = parts
+	return
+}
+
+// accountSCU returns the smallest unit of the account with the given fqn,
+// unknown accounts default to 100 like gnucash.Transaction.AddSplit.
+func accountSCU(lookup *gnucash.AccountsLookup) func(account string) int64 {
+	return func(account string) int64 {
+		if a, ok := lookup.ByFQN(account); ok && a.SCU != 0 {
+			return int64(a.SCU)
+		}
+		return 100
+	}
+}
+
 type transactions []*transaction
 
 // func (txs transactions) Len() int      { return len(txs) }
@@ -154,6 +214,28 @@ func start(msg string) func() {
 
 const dFormat = "2006-01-02"
 
+// dateFormats are the formats of the dates in the sheet.
+var dateFormats = []string{
+	"2006-01-02",
+	"02-01-2006",
+}
+
+var dateRepl = regexp.MustCompile(`[\-/ \.:]+`)
+
+// parseDate parses a date in any of the formats, regardless of the
+// separators used.
+func parseDate(date string, formats []string) (time.Time, error) {
+	date = dateRepl.ReplaceAllString(strings.TrimSpace(date), "-")
+	for _, f := range formats {
+		t, err := time.Parse(dateRepl.ReplaceAllString(f, "-"), date)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("failed to parse date: %s", date)
+}
+
 type Conf struct {
 	o  []ConfKey
 	kv map[ConfKey]string
@@ -171,6 +253,13 @@ const (
 	KIgnore                        = "account.ignore"
 	KReport                        = "report.profit.account"
 	KReportIgnore                  = "report.profit.ignore"
+	KReportCurrency                = "report.currency"
+	KForecastAccount               = "forecast.account"
+	KImportAccount                 = "import.account."
+	KImportCounter                 = "import.counter"
+	KImportCSV                     = "import.csv."
+	KImportConfidence              = "import.min-confidence"
+	KRule                          = "rule."
 )
 
 var eg = map[ConfKey]string{
@@ -314,43 +403,118 @@ func accountsWithAliases(accounts gnucash.Accounts, conf string) (
 	return
 }
 
-func readbook(conf string) (*gnucash.Book, error) {
+func readdata(conf string) (*gnucash.XML, error) {
 	c, err := readconf(conf, []ConfKey{KDataFile})
 	if err != nil {
 		return nil, err
 	}
 
-	f, err := os.Open(c.Get(KDataFile))
+	data, err := gnucash.Open(c.Get(KDataFile))
 	if err != nil {
 		return nil, fmt.Errorf("could not read datafile '%s': %w", c.Get(KDataFile), err)
 	}
-
-	data, err := gnucash.Read(f)
-	if err != nil {
-		return nil, err
-	}
 	if len(data.Books) == 0 {
 		// not a gnucash datafile xml
 		return nil, fmt.Errorf("no book found in '%s'", c.Get(KDataFile))
 	}
 
+	return data, nil
+}
+
+func readbook(conf string) (*gnucash.Book, error) {
+	data, err := readdata(conf)
+	if err != nil {
+		return nil, err
+	}
+
 	return data.Books[0], nil
 }
 
-func accounts(conf string) (gnucash.Accounts, error) {
+// writable returns an error if data can not be written back to its datafile.
+// Commands that write call it before doing any (interactive) work.
+func writable(data *gnucash.XML) error {
+	if data.Backend != gnucash.BackendXML {
+		return fmt.Errorf("%w, save the book as xml in GnuCash first", gnucash.ErrReadOnlyBackend)
+	}
+
+	return nil
+}
+
+func writedata(conf string, data *gnucash.XML) error {
+	if err := writable(data); err != nil {
+		return err
+	}
+
 	c, err := readconf(conf, []ConfKey{KDataFile})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	f, err := os.Open(c.Get(KDataFile))
+
+	path := c.Get(KDataFile)
+	if _, err := os.Stat(path + ".LCK"); err == nil {
+		return fmt.Errorf("'%s' is locked, close it in GnuCash first", path)
+	}
+
+	backup, err := gnucash.Backup(path)
 	if err != nil {
-		return nil, fmt.Errorf("could not read datafile '%s': %w", c.Get(KDataFile), err)
+		return fmt.Errorf("could not backup datafile '%s': %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "Backed up '%s' to '%s'\n", path, backup)
+
+	return gnucash.WriteFile(path, data)
+}
+
+func insertGroups(book *gnucash.Book, groups []*group) (int, error) {
+	txs := make(map[string]*gnucash.Transaction)
+	order := make([]*gnucash.Transaction, 0)
+	for _, g := range groups {
+		acc, ok := book.AccountsLookup.ByFQN(g.account)
+		if !ok {
+			return 0, fmt.Errorf("no such account: '%s'", g.account)
+		}
+
+		tx, ok := txs[g.num]
+		if !ok {
+			date, err := time.Parse(dFormat, g.date)
+			if err != nil {
+				return 0, err
+			}
+			tx = gnucash.NewTransaction(acc.Commodity, date, g.num, g.descr)
+			txs[g.num] = tx
+			order = append(order, tx)
+		}
+
+		if tx.Description == "" {
+			tx.Description = g.descr
+		}
+
+		s, err := tx.AddSplit(acc, g.amount, g.memo)
+		if err != nil {
+			return 0, err
+		}
+		if g.onlineID != "" {
+			s.SetOnlineID(g.onlineID)
+		}
 	}
 
-	data, err := gnucash.ReadAccounts(f)
+	for _, tx := range order {
+		if err := book.AddTransaction(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(order), nil
+}
+
+func accounts(conf string) (gnucash.Accounts, error) {
+	c, err := readconf(conf, []ConfKey{KDataFile})
 	if err != nil {
 		return nil, err
 	}
+	data, err := gnucash.OpenAccounts(c.Get(KDataFile))
+	if err != nil {
+		return nil, fmt.Errorf("could not read datafile '%s': %w", c.Get(KDataFile), err)
+	}
 
 	return data.Accounts, nil
 }
@@ -362,6 +526,44 @@ func accountsFromAny(conf string) (gnucash.Accounts, error) {
 	return accounts(conf)
 }
 
+func reportCurrency(conf string) (gnucash.CommodityFQN, error) {
+	c, err := readconf(conf, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return currencyFQN(c.Get(KReportCurrency)), nil
+}
+
+func currencyFQN(currency string) gnucash.CommodityFQN {
+	if currency == "" || strings.Contains(currency, ".") {
+		return gnucash.CommodityFQN(currency)
+	}
+
+	return gnucash.CommodityRef{
+		ID: gnucash.CommodityID(currency),
+		NS: gnucash.CommodityCurrency,
+	}.FQN()
+}
+
+// anyRegexp compiles patterns into a single regex matching any of them, or
+// nil if there are none.
+func anyRegexp(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	l := make([]string, len(patterns))
+	for i, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, err
+		}
+		l[i] = "(?:" + p + ")"
+	}
+
+	return regexp.Compile(strings.Join(l, "|"))
+}
+
 func accountFuzzy(accounts gnucash.Accounts) ([]string, *fuzzy.Index) {
 	accountNames := make([]string, len(accounts))
 	for i, a := range accounts {
@@ -373,6 +575,65 @@ func accountFuzzy(accounts gnucash.Accounts) ([]string, *fuzzy.Index) {
 	return accountNames, fuzz
 }
 
+// findAccount returns the account with the given fqn or, if there is none,
+// the single best fuzzy match.
+func findAccount(book *gnucash.Book, query string) (*gnucash.Account, error) {
+	if a, ok := book.AccountsLookup.ByFQN(query); ok {
+		return a, nil
+	}
+	for _, a := range book.Accounts {
+		if strings.EqualFold(a.FQN, query) {
+			return a, nil
+		}
+	}
+
+	accountNames, fuzz := accountFuzzy(book.Accounts)
+	res := make([]string, 0)
+	fuzz.Search(query, func(i int, score, low, high uint8) {
+		if score == high {
+			res = append(res, accountNames[i])
+		}
+	})
+
+	switch len(res) {
+	case 0:
+		return nil, fmt.Errorf("no account matching '%s'", query)
+	case 1:
+		a, _ := book.AccountsLookup.ByFQN(res[0])
+		return a, nil
+	}
+
+	return nil, fmt.Errorf("'%s' matches multiple accounts:\n%s", query, strings.Join(res, "\n"))
+}
+
+func sheetService(conf string) (*sheets.Service, string, error) {
+	c, err := readconf(conf, []ConfKey{KSheetID, KServiceAccountCredentialsFile})
+	if err != nil {
+		return nil, "", err
+	}
+
+	srv, err := sheets.NewService(
+		context.Background(),
+		option.WithCredentialsFile(c.Get(KServiceAccountCredentialsFile)),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create sheets service: %w", err)
+	}
+
+	return srv, c.Get(KSheetID), nil
+}
+
+func sheetUpdate(srv *sheets.Service, sid, rng string, vals [][]interface{}) error {
+	values := &sheets.ValueRange{
+		MajorDimension: "ROWS",
+		Values:         vals,
+	}
+	ur := srv.Spreadsheets.Values.Update(sid, rng, values)
+	ur.ValueInputOption("RAW")
+	_, err := ur.Do()
+	return err
+}
+
 func sheetPad(vals [][]interface{}, innerSize int) [][]interface{} {
 	n := len(vals)
 	if n < cap(vals) {
@@ -409,6 +670,23 @@ func main() {
 			h.Add("  - tx:      interactively create an importable transaction")
 			h.Add("  - sheet:   parse a google sheet and export as csv")
 			h.Add("             (will alter your google sheet!)")
+			h.Add("  - forecast: project account balances using scheduled transactions")
+			h.Add("  - budget:  compare a budget to the actual amounts")
+			h.Add("  - invoices: list open invoices and bills")
+			h.Add("  - aging:   accounts receivable / payable aging report")
+			h.Add("  - gains:   realized and unrealized capital gains")
+			h.Add("  - portfolio: holdings, cost basis and returns")
+			h.Add("  - balance-sheet: assets, liabilities and equity at a date")
+			h.Add("  - income-statement: profit & loss per period")
+			h.Add("  - register: transactions of an account with a running balance")
+			h.Add("  - reconcile: reconcile an account against a bank statement")
+			h.Add("  - import-ofx: import OFX / QFX bank statements")
+			h.Add("  - import-camt: import CAMT.053 bank statements")
+			h.Add("  - import-mt940: import MT940 bank statements")
+			h.Add("  - import-csv: import csv bank exports using a config profile")
+			h.Add("  - duplicates: find, void or delete likely duplicate transactions")
+			h.Add("  - check:   check the integrity of the book")
+			h.Add("  tx, sheet and import-* accept -insert to write straight to the book")
 		}
 	}).Handler(func(set *flags.Set, args []string) error {
 		set.Usage(1)
@@ -445,12 +723,34 @@ func main() {
 		return nil
 	})
 
+	var txInsert bool
 	fr.Add("tx").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.BoolVar(&txInsert, "insert", false, "append the transaction to the book instead of printing csv")
 		return func(h *flags.Help) {
 			h.Add("interactively create an importable transaction")
+			h.Add("the counter account is suggested from the book's history,")
+			h.Add("press enter to accept the first or pick one by number")
 		}
 	}).Handler(func(set *flags.Set, args []string) error {
-		accounts, err := accountsFromAny(conf)
+		var data *gnucash.XML
+		var book *gnucash.Book
+		var accounts gnucash.Accounts
+		var err error
+		if txInsert {
+			data, err = readdata(conf)
+			if err == nil {
+				err = writable(data)
+			}
+			if err == nil {
+				book = data.Books[0]
+				accounts = book.Accounts
+			}
+		} else if book, err = readbook(conf); err == nil {
+			accounts = book.Accounts
+		} else {
+			book = nil
+			accounts, err = accountsFromAny(conf)
+		}
 		if err != nil {
 			return err
 		}
@@ -474,8 +774,8 @@ func main() {
 			}
 		}
 
-		float := func(str string) (string, error) {
-			_, err := strconv.ParseFloat(str, 32)
+		decimal := func(str string) (string, error) {
+			_, err := gnucash.ParseDecimal(str)
 			return str, err
 		}
 
@@ -495,15 +795,14 @@ func main() {
 		// todo validation / completion
 		tx := &transaction{}
 		tx.date = time.Now().Format(dFormat)
-		amount, err := ask("Amount", float)
+		amount, err := ask("Amount", decimal)
 		if err != nil {
 			return err
 		}
-		amountf, err := strconv.ParseFloat(amount, 64)
+		tx.amount, err = gnucash.ParseDecimal(amount)
 		if err != nil {
 			return err
 		}
-		tx.amount = amountf
 
 		tx.descr, err = ask("Description", noop)
 		if err != nil {
@@ -515,7 +814,33 @@ func main() {
 			return err
 		}
 
-		tx.to, err = ask("To", account)
+		var suggestions []gnucash.Suggestion
+		if book != nil {
+			source, _ := book.AccountsLookup.ByFQN(tx.from)
+			suggestions = book.Classifier().Predict(gnucash.ClassifierInput{
+				Source:      source,
+				Amount:      tx.amount.Neg(),
+				Description: tx.descr,
+			}, 3)
+		}
+
+		lbl := "To"
+		if len(suggestions) != 0 {
+			for i, sug := range suggestions {
+				fmt.Printf("  %d) %s\n", i+1, sug)
+			}
+			lbl = fmt.Sprintf("To [%s]", suggestions[0].Account.FQN)
+		}
+
+		tx.to, err = ask(lbl, func(str string) (string, error) {
+			if str == "" && len(suggestions) != 0 {
+				return suggestions[0].Account.FQN, nil
+			}
+			if n, err := strconv.Atoi(str); err == nil && n > 0 && n <= len(suggestions) {
+				return suggestions[n-1].Account.FQN, nil
+			}
+			return account(str)
+		})
 		if err != nil {
 			return err
 		}
@@ -524,9 +849,16 @@ func main() {
 			return err
 		}
 
-		w := csv.NewWriter(os.Stdout)
 		from, to := tx.Groups(0)
 		groups := []*group{to, from}
+		if txInsert {
+			if _, err := insertGroups(data.Books[0], groups); err != nil {
+				return err
+			}
+			return writedata(conf, data)
+		}
+
+		w := csv.NewWriter(os.Stdout)
 		for _, group := range groups {
 			if err := w.Write(group.Fields()); err != nil {
 				return err
@@ -564,16 +896,48 @@ func main() {
 		fmt.Printf("%s[] = ^assets\\.current\\.wallets\\.me$\n", KReport)
 		fmt.Printf("%s[] = ^assets\\.current\\..*bank\n", KReport)
 		fmt.Printf("%s[]  = ^equity\\.opening balances$\n", KReportIgnore)
+		fmt.Println()
+		fmt.Printf("%s = EUR\n", KReportCurrency)
+		fmt.Println()
+		fmt.Printf("%s[] = ^assets\\.current\\..*bank\n", KForecastAccount)
+		fmt.Println()
+		fmt.Printf("%sNL91ABNA0417164300 = me.bank\n", KImportAccount)
+		fmt.Printf("%s = Imbalance-EUR\n", KImportCounter)
+		fmt.Printf("%s = 0.5\n", KImportConfidence)
+		fmt.Println()
+		fmt.Printf("%ssomebank.account             = NL91ABNA0417164300\n", KImportCSV)
+		fmt.Printf("%ssomebank.delimiter           = ;\n", KImportCSV)
+		fmt.Printf("%ssomebank.encoding            = windows-1252\n", KImportCSV)
+		fmt.Printf("%ssomebank.header              = 1\n", KImportCSV)
+		fmt.Printf("%ssomebank.date-format[]       = 02/01/2006\n", KImportCSV)
+		fmt.Printf("%ssomebank.decimal-comma       = true\n", KImportCSV)
+		fmt.Printf("%ssomebank.column.date         = Datum\n", KImportCSV)
+		fmt.Printf("%ssomebank.column.amount       = Bedrag\n", KImportCSV)
+		fmt.Printf("%ssomebank.column.counterparty = Naam tegenpartij\n", KImportCSV)
+		fmt.Printf("%ssomebank.column.description  = 9\n", KImportCSV)
+		fmt.Println()
+		fmt.Printf("%sgroceries.counterparty  = (?i)supermarket|bakery\n", KRule)
+		fmt.Printf("%sgroceries.max           = 0\n", KRule)
+		fmt.Printf("%sgroceries.split[]       = food 80\n", KRule)
+		fmt.Printf("%sgroceries.split[]       = household 20\n", KRule)
+		fmt.Printf("%sdining.description      = (?i)restaurant (.*)\n", KRule)
+		fmt.Printf("%sdining.weekday          = fri,sat,sun\n", KRule)
+		fmt.Printf("%sdining.source           = me.bank\n", KRule)
+		fmt.Printf("%sdining.account          = dining\n", KRule)
+		fmt.Printf("%sdining.memo             = dinner at $1\n", KRule)
 		return nil
 	})
 
+	var sheetInsert bool
 	fr.Add("sheet").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.BoolVar(&sheetInsert, "insert", false, "append the transactions to the book instead of printing csv")
 		return func(h *flags.Help) {
 			h.Add("parse a google sheet and export as csv.")
 			h.Add("you will need to create a google project and link a service account")
 			h.Add("(will alter your google sheet!)")
 		}
 	}).Handler(func(set *flags.Set, args []string) error {
+		var data *gnucash.XML
 		var book *gnucash.Book
 		var sid string
 		var srv *sheets.Service
@@ -581,11 +945,14 @@ func main() {
 		var aliasesOrder []string
 		var placeholder map[string]struct{}
 		var accountsLookup *gnucash.AccountsLookup
+		var currency gnucash.CommodityFQN
+		var conv *gnucash.Converter
+		var ruleset *rules
 
 		err := func() error {
 			end := start("Parsing config and books")
 			defer end()
-			c, err := readconf(
+			_, err := readconf(
 				conf,
 				[]ConfKey{
 					KDataFile,
@@ -597,14 +964,16 @@ func main() {
 				return err
 			}
 
-			bookf := c.Get(KDataFile)
-			sid = c.Get(KSheetID)
-			credsf := c.Get(KServiceAccountCredentialsFile)
-
-			book, err = readbook(bookf)
+			data, err = readdata(conf)
 			if err != nil {
 				return err
 			}
+			if sheetInsert {
+				if err := writable(data); err != nil {
+					return err
+				}
+			}
+			book = data.Books[0]
 			_accounts := book.Accounts
 			accounts := make(gnucash.Accounts, 0, len(_accounts))
 			_ignore, err := confPrefixArray(conf, KIgnore)
@@ -629,25 +998,31 @@ func main() {
 				return err
 			}
 
+			ruleset, err = readRules(conf, aliases)
+			if err != nil {
+				return err
+			}
+
 			accountsLookup = book.AccountsLookup
 
-			ctx := context.Background()
-			srv, err = sheets.NewService(
-				ctx,
-				option.WithCredentialsFile(credsf),
-			)
+			currency, err = reportCurrency(conf)
 			if err != nil {
-				return fmt.Errorf("unable to create sheets service: %w", err)
+				return err
 			}
-			return nil
+			conv = book.Converter()
+
+			srv, sid, err = sheetService(conf)
+			return err
 		}()
 		if err != nil {
 			return err
 		}
 
+		var skipped []error
 		err = func() error {
 			end := start("Updating accounts sheets")
 			defer end()
+			now := time.Now()
 			vals := make([][]interface{}, 0, len(aliasesOrder)*5)
 			for _, v := range aliasesOrder {
 				item := make([]interface{}, 4)
@@ -664,11 +1039,21 @@ func main() {
 
 				acc, ok := book.AccountsLookup.ByFQN(fqn)
 				if ok {
-					accval := book.Transactions.ValueForAccount(acc.ID, false)
-					accvalgross := book.Transactions.ValueForAccount(acc.ID, true)
-					item[2] = accvalgross
-					item[3] = accval
-					if ph || accvalgross == accval {
+					accval, err := book.Transactions.ValuationForAccount(acc.ID, false, conv, currency, now)
+					if err != nil {
+						skipped = append(skipped, fmt.Errorf("%s: %w", fqn, err))
+						vals = append(vals, item)
+						continue
+					}
+					accvalgross, err := book.Transactions.ValuationForAccount(acc.ID, true, conv, currency, now)
+					if err != nil {
+						skipped = append(skipped, fmt.Errorf("%s: %w", fqn, err))
+						vals = append(vals, item)
+						continue
+					}
+					item[2] = accvalgross.Float64()
+					item[3] = accval.Float64()
+					if ph || accvalgross.Equal(accval) {
 						item[3] = ""
 					}
 				}
@@ -678,18 +1063,14 @@ func main() {
 
 			vals = sheetPad(vals, 3)
 
-			values := &sheets.ValueRange{
-				MajorDimension: "ROWS",
-				Values:         vals,
-			}
-			ur := srv.Spreadsheets.Values.Update(sid, "Accounts!A1", values)
-			ur.ValueInputOption("RAW")
-			_, err := ur.Do()
-			return err
+			return sheetUpdate(srv, sid, "Accounts!A1", vals)
 		}()
 		if err != nil {
 			return err
 		}
+		for _, err := range skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s\n", err)
+		}
 
 		txs := make(transactions, 0)
 		txsByUIDs := make(map[string][]*transaction)
@@ -726,13 +1107,15 @@ func main() {
 				return "", fmt.Errorf("field %d is not a string: %T", ix, val)
 			}
 
-			formats := []string{
-				"2006-01-02",
-				"02-01-2006",
+			// rows already in the book keep the accounts they were
+			// imported with, only the others are categorised.
+			booked := make(map[string]struct{})
+			for _, t := range book.Transactions {
+				if uid, err := num2uid(t.Num); err == nil && uid != "" {
+					booked[uid] = struct{}{}
+				}
 			}
 
-			dateRepl := regexp.MustCompile(`[\-/ \.:]+`)
-
 			bad, all, old := 0, 0, 0
 			for y, row := range resp.Values {
 				tx := &transaction{}
@@ -750,50 +1133,43 @@ func main() {
 						return err
 					}
 
-					var dt time.Time
 					tx.date, err = strval(row, 2, true)
 					if err != nil {
 						return err
 					}
-					tx.date = dateRepl.ReplaceAllString(tx.date, "-")
-					for _, f := range formats {
-						t, err := time.Parse(f, tx.date)
-						if err == nil {
-							dt = t
-							break
-						}
+					dt, err := parseDate(tx.date, dateFormats)
+					if err != nil {
+						return err
 					}
+					tx.date = dt.Format(dFormat)
 
-					if dt == (time.Time{}) {
-						return fmt.Errorf("failed to parse date: %s", tx.date)
+					account := func(ix int) (string, error) {
+						alias, err := strval(row, ix, true)
+						if err != nil || alias == "" {
+							return "", err
+						}
+						fqn := aliases[alias]
+						if _, ok := accountsLookup.ByFQN(fqn); !ok {
+							return "", fmt.Errorf("no such account: '%s'", alias)
+						}
+						return fqn, nil
 					}
-					tx.date = dt.Format(dFormat)
 
-					tx.from, err = strval(row, 3, true)
+					tx.from, err = account(3)
 					if err != nil {
 						return err
 					}
-					rfrom := aliases[tx.from]
-					if _, ok := accountsLookup.ByFQN(rfrom); !ok {
-						return fmt.Errorf("no such account: '%s'", tx.from)
-					}
-					tx.from = rfrom
 
-					tx.to, err = strval(row, 4, true)
+					tx.to, err = account(4)
 					if err != nil {
 						return err
 					}
-					rto := aliases[tx.to]
-					if _, ok := accountsLookup.ByFQN(rto); !ok {
-						return fmt.Errorf("no such account: '%s'", tx.to)
-					}
-					tx.to = rto
 
 					amount, err := strval(row, 5, true)
 					if err != nil {
 						return err
 					}
-					tx.amount, err = strconv.ParseFloat(amount, 64)
+					tx.amount, err = gnucash.ParseDecimal(amount)
 					if err != nil {
 						return err
 					}
@@ -808,6 +1184,18 @@ func main() {
 						return err
 					}
 
+					if tx.from == "" && tx.to == "" {
+						return errors.New("no accounts")
+					}
+
+					_, imported := booked[tx.uid]
+					if !imported && (tx.from == "" || tx.to == "") {
+						if ruleset.categorise(tx, "", "") == "" {
+							ruleset.record("", tx)
+							return errors.New("uncategorised, no rule matches")
+						}
+					}
+
 					err = tx.GenID()
 					if err != nil {
 						return err
@@ -845,6 +1233,9 @@ func main() {
 				txs = append(txs, tx)
 			}
 
+			if err := ruleset.report(resultsBuf); err != nil {
+				return err
+			}
 			fmt.Fprintf(resultsBuf, "  %d rows\n", all)
 			fmt.Fprintf(resultsBuf, "  %d old\n", old)
 			fmt.Fprintf(resultsBuf, "  %d new\n", all-old-bad)
@@ -936,10 +1327,20 @@ func main() {
 					continue
 				}
 
-				from, to := tx.Groups(n)
-				groups = append(groups, to)
 				groupFrom := make(map[string]*group, len(txs))
-				groupFrom[fromkey(from)] = from
+				add := func(tx *transaction) {
+					froms, tos := tx.SplitGroups(n, accountSCU(accountsLookup))
+					groups = append(groups, tos...)
+					for _, from := range froms {
+						k := fromkey(from)
+						if f, ok := groupFrom[k]; ok {
+							f.Add(from)
+							continue
+						}
+						groupFrom[k] = from
+					}
+				}
+				add(tx)
 
 				for j := i + 1; j < len(txs); j++ {
 					if !qualifies(txs[j]) {
@@ -950,14 +1351,7 @@ func main() {
 					}
 
 					i = j
-					fromj, toj := txs[j].Groups(n)
-					groups = append(groups, toj)
-					k := fromkey(fromj)
-					if f, ok := groupFrom[k]; ok {
-						f.Add(fromj)
-						continue
-					}
-					groupFrom[fromkey(fromj)] = fromj
+					add(txs[j])
 				}
 
 				for _, g := range groupFrom {
@@ -971,9 +1365,29 @@ func main() {
 			return err
 		}
 
+		if sheetInsert {
+			err = func() error {
+				end := start("Inserting transactions in book")
+				defer end()
+				n, err := insertGroups(book, groups)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(resultsBuf, "  inserted %d transactions\n", n)
+				return writedata(conf, data)
+			}()
+			resultsBuf.WriteTo(os.Stderr)
+			if err != nil {
+				return err
+			}
+		}
+
 		csvbuf := bytes.NewBuffer(nil)
 		toImport := 0
 		err = func() error {
+			if sheetInsert {
+				return nil
+			}
 			end := start("Generating CSV")
 			defer end()
 			w := csv.NewWriter(csvbuf)
@@ -1000,8 +1414,10 @@ func main() {
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(os.Stderr, "Generated %d rows\n", toImport)
-		fmt.Print(csvbuf.String())
+		if !sheetInsert {
+			fmt.Fprintf(os.Stderr, "Generated %d rows\n", toImport)
+			fmt.Print(csvbuf.String())
+		}
 
 		err = func() error {
 			end := start("Updating report")
@@ -1044,15 +1460,22 @@ func main() {
 
 			for ; cur.Before(now); cur = cur.AddDate(0, 1, 0) {
 				monthly := all.Between(cur, cur.AddDate(0, 1, 0))
+				simplified := monthly.Simplified()
+				if currency != "" {
+					simplified, err = monthly.SimplifiedIn(conv, currency)
+					if err != nil {
+						return err
+					}
+				}
 
 				entry := make([]interface{}, 1+len(regexes))
 				entry[0] = cur.Format(dFormat)
 				for i, re := range regexes {
-					l := monthly.Simplified().RelativeFrom(re).Filter(nil, nil, nil, re)
+					l := simplified.RelativeFrom(re).Filter(nil, nil, nil, re)
 					for _, ignore := range ignores {
 						l = l.Filter(nil, nil, nil, ignore)
 					}
-					entry[i+1] = -l.Sum()
+					entry[i+1] = l.Sum().Neg().Float64()
 				}
 				vals = append(vals, entry)
 			}
@@ -1075,6 +1498,23 @@ func main() {
 		return nil
 	})
 
+	forecastCommand(fr, &conf)
+	budgetCommand(fr, &conf)
+	invoicesCommand(fr, &conf)
+	agingCommand(fr, &conf)
+	gainsCommand(fr, &conf)
+	portfolioCommand(fr, &conf)
+	balanceSheetCommand(fr, &conf)
+	incomeStatementCommand(fr, &conf)
+	registerCommand(fr, &conf)
+	reconcileCommand(fr, &conf)
+	importCommand(fr, &conf, "import-ofx", "OFX 1.x (SGML) / 2.x (XML) and QFX", gnucash.ParseOFX)
+	importCommand(fr, &conf, "import-camt", "ISO 20022 CAMT.053", gnucash.ParseCAMT053)
+	importCommand(fr, &conf, "import-mt940", "SWIFT MT940", gnucash.ParseMT940)
+	importCSVCommand(fr, &conf)
+	duplicatesCommand(fr, &conf)
+	checkCommand(fr, &conf)
+
 	set, _ := fr.ParseCommandline()
 	if err := set.Do(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testConf writes conf to a temporary config file and resets the cached
+// config so readconf reads it.
+func testConf(t *testing.T, conf string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_c = Conf{}
+	t.Cleanup(func() { _c = Conf{} })
+
+	return path
+}
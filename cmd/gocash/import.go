@@ -0,0 +1,380 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+// GenImportID generates the uid of a transaction imported from a bank
+// statement line. Lines with a bank id are identified by it, others by
+// their contents and n, the number of identical lines preceding it on the
+// statement.
+func (tx *transaction) GenImportID(bankAccount string, l gnucash.BankLine, n int) {
+	w := sha256.New()
+	fmt.Fprintf(w, "hG4mY0lzGQyqU7Vn1pPz2Xc9q8KJtdRkS7wW3iEo:%s:", bankAccount)
+	if l.ID != "" {
+		fmt.Fprintf(w, "id:%s", l.ID)
+	} else {
+		fmt.Fprintf(
+			w,
+			"%s:%s:%s:%s:%d",
+			l.Date.Format(dFormat),
+			l.Amount.Reduce().String(),
+			l.Description,
+			l.Memo,
+			n,
+		)
+	}
+
+	tx.uid = hex.EncodeToString(w.Sum(nil))
+}
+
+// importer turns bank statement lines into transactions between the book
+// account of the statement and a counter account chosen by the rules or
+// the classifier, skipping the lines that were imported before.
+type importer struct {
+	conf   string
+	insert bool
+	data   *gnucash.XML
+	book   *gnucash.Book
+
+	// mapping maps bank accounts to account fqns.
+	mapping map[string]string
+	counter string
+
+	// uids are the uids of the transactions in the book (see num2uid) and
+	// onlineIDs the bank ids stored in the splits of each account.
+	uids      map[string]struct{}
+	onlineIDs map[gnucash.GUID]map[string]struct{}
+	// classifier proposes counter accounts learned from the book when no
+	// rule matches, if it is at least confidence sure.
+	classifier *gnucash.Classifier
+	confidence float64
+	rules      *rules
+
+	groups []*group
+	n      int
+
+	lines, dupes, unknown int
+}
+
+func newImporter(conf string, insert bool) (*importer, error) {
+	data, err := readdata(conf)
+	if err != nil {
+		return nil, err
+	}
+	if insert {
+		if err := writable(data); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := readconf(conf, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, mapping, err := confPrefix(conf, KImportAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	_, aliases, _, err := accountsWithAliases(data.Books[0].Accounts, conf)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := readRules(conf, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	confidence := 0.5
+	if v := c.Get(KImportConfidence); v != "" {
+		if confidence, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("%s: %w", KImportConfidence, err)
+		}
+	}
+
+	im := &importer{
+		conf:      conf,
+		insert:    insert,
+		data:      data,
+		book:      data.Books[0],
+		mapping:   mapping,
+		counter:   c.Get(KImportCounter),
+		uids:      make(map[string]struct{}),
+		onlineIDs: make(map[gnucash.GUID]map[string]struct{}),
+		rules:     rules,
+		groups:    make([]*group, 0),
+	}
+
+	for _, tx := range im.book.Transactions {
+		if uid, err := num2uid(tx.Num); err == nil && uid != "" {
+			im.uids[uid] = struct{}{}
+		}
+
+		for _, s := range tx.Splits {
+			if id := s.OnlineID(); id != "" {
+				im.onlineID(s.AccountID)[id] = struct{}{}
+			}
+		}
+	}
+	im.classifier = im.book.Classifier()
+	im.confidence = confidence
+
+	return im, nil
+}
+
+func (im *importer) onlineID(account gnucash.GUID) map[string]struct{} {
+	if im.onlineIDs[account] == nil {
+		im.onlineIDs[account] = make(map[string]struct{})
+	}
+
+	return im.onlineIDs[account]
+}
+
+// account returns the book account of the statement: query if not empty,
+// otherwise the one mapped to the bank account in the config.
+func (im *importer) account(st gnucash.BankStatement, query string) (*gnucash.Account, error) {
+	if query == "" {
+		query = im.mapping[st.Account]
+	}
+	if query == "" {
+		return nil, fmt.Errorf(
+			"no account for bank account '%s', add '%s%s = <account>' to your config",
+			st.Account,
+			KImportAccount,
+			st.Account,
+		)
+	}
+
+	acc, err := findAccount(im.book, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if st.Currency != "" && string(acc.Commodity.ID) != st.Currency {
+		return nil, fmt.Errorf(
+			"bank account '%s' is in %s but '%s' is denominated in %s",
+			st.Account,
+			st.Currency,
+			acc.FQN,
+			acc.Commodity.ID,
+		)
+	}
+
+	return acc, nil
+}
+
+// propose returns the counter account for a line: the classifier's best
+// suggestion or, if it is not confident enough, the configured fallback in
+// which case known is false.
+func (im *importer) propose(acc *gnucash.Account, l gnucash.BankLine) (fqn string, known bool) {
+	s := im.classifier.Predict(gnucash.ClassifierInput{
+		Source:      acc,
+		Amount:      l.Amount,
+		Description: l.Description + " " + l.Memo,
+		Payee:       l.Description,
+	}, 1)
+	if len(s) != 0 && s[0].Confidence >= im.confidence {
+		return s[0].Account.FQN, true
+	}
+
+	im.unknown++
+	if im.counter != "" {
+		return im.counter, false
+	}
+
+	return "Imbalance-" + string(acc.Commodity.ID), false
+}
+
+// add imports the lines of a statement into acc.
+func (im *importer) add(st gnucash.BankStatement, acc *gnucash.Account) {
+	seen := make(map[string]int)
+	for _, l := range st.Lines {
+		im.lines++
+		tx := &transaction{
+			state: "c",
+			date:  l.Date.Format(dFormat),
+			descr: l.Description,
+			memo:  l.Memo,
+		}
+
+		key := fmt.Sprintf("%s:%s:%s:%s", tx.date, l.Amount.Reduce().String(), l.Description, l.Memo)
+		tx.GenImportID(st.Account, l, seen[key])
+		seen[key]++
+
+		_, dupe := im.uids[tx.uid]
+		if l.ID != "" {
+			if _, ok := im.onlineID(acc.ID)[l.ID]; ok {
+				dupe = true
+			}
+		}
+		if dupe {
+			im.dupes++
+			continue
+		}
+		im.uids[tx.uid] = struct{}{}
+		if l.ID != "" {
+			im.onlineID(acc.ID)[l.ID] = struct{}{}
+		}
+
+		tx.to, tx.amount = acc.FQN, l.Amount
+		if l.Amount.Sign() < 0 {
+			tx.from, tx.to, tx.amount = acc.FQN, "", l.Amount.Neg()
+		}
+
+		if im.rules.categorise(tx, l.Description, l.IBAN) == "" {
+			counter, known := im.propose(acc, l)
+			if tx.from == "" {
+				tx.from = counter
+			} else {
+				tx.to = counter
+			}
+			rule := ""
+			if known {
+				rule = ruleLearned
+			}
+			im.rules.record(rule, tx)
+		}
+
+		im.n++
+		from, to := tx.SplitGroups(im.n, accountSCU(im.book.AccountsLookup))
+		if tx.to == acc.FQN {
+			to[0].onlineID = l.ID
+		} else {
+			from[0].onlineID = l.ID
+		}
+		im.groups = append(im.groups, to...)
+		im.groups = append(im.groups, from...)
+	}
+}
+
+// finish inserts the transactions in the book or prints them as csv.
+func (im *importer) finish() error {
+	if err := im.rules.report(os.Stderr); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "  %d lines\n", im.lines)
+	fmt.Fprintf(os.Stderr, "  %d imported before\n", im.dupes)
+	fmt.Fprintf(os.Stderr, "  %d new\n", im.lines-im.dupes)
+	fmt.Fprintf(os.Stderr, "  %d without a known counter account\n", im.unknown)
+
+	if im.insert {
+		if len(im.groups) == 0 {
+			return nil
+		}
+		n, err := insertGroups(im.book, im.groups)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "  inserted %d transactions\n", n)
+		return writedata(im.conf, im.data)
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	row := []string{
+		"num",
+		"date",
+		"account",
+		"amount",
+		"price",
+		"description",
+	}
+	if err := w.Write(row); err != nil {
+		return err
+	}
+	for _, g := range im.groups {
+		if err := w.Write(g.Fields()); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// importCommand adds a command importing the bank statement files given as
+// arguments, parsed by parse.
+func importCommand(
+	fr *flags.Set,
+	conf *string,
+	name string,
+	format string,
+	parse func(io.Reader) ([]gnucash.BankStatement, error),
+) {
+	var insert bool
+	var account string
+	fr.Add(name).Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.BoolVar(&insert, "insert", false, "append the transactions to the book instead of printing csv")
+		set.StringVar(&account, "account", "", "book account (fqn or fuzzy query) instead of the configured "+KImportAccount+"<bank account>")
+		return func(h *flags.Help) {
+			h.Add("import " + format + " bank statements.")
+			h.Add("the files are the arguments. transactions whose bank id or uid")
+			h.Add("is already in the book are skipped. counter accounts are set")
+			h.Add("by the " + KRule + "* rules, learned from the book's transactions")
+			h.Add("or default to " + KImportCounter + ".")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		if len(args) == 0 {
+			return errors.New("no files given")
+		}
+
+		return importFiles(*conf, insert, account, args, parse)
+	})
+}
+
+// importFiles imports the statements in the files at paths into the
+// account given by query or, if empty, the configured one.
+func importFiles(
+	conf string,
+	insert bool,
+	query string,
+	paths []string,
+	parse func(io.Reader) ([]gnucash.BankStatement, error),
+) error {
+	im, err := newImporter(conf, insert)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		stmts, err := readStatements(path, parse)
+		if err != nil {
+			return err
+		}
+		for _, st := range stmts {
+			acc, err := im.account(st, query)
+			if err != nil {
+				return err
+			}
+			im.add(st, acc)
+		}
+	}
+
+	return im.finish()
+}
+
+func readStatements(path string, parse func(io.Reader) ([]gnucash.BankStatement, error)) ([]gnucash.BankStatement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stmts, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return stmts, nil
+}
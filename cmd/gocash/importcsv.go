@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+// csvColumns are the fields a csv profile can map to a column.
+var csvColumns = []string{
+	"date",
+	"amount",
+	"debit",
+	"credit",
+	"description",
+	"counterparty",
+	"iban",
+	"id",
+}
+
+// csvProfile describes the csv export of a bank, it is read from the
+// import.csv.<name>.* config entries.
+type csvProfile struct {
+	name string
+	// account identifies the bank account for import.account mapping and
+	// uid generation, defaults to the profile's name.
+	account      string
+	delimiter    rune
+	encoding     string
+	header       int
+	formats      []string
+	decimalComma bool
+	// columns maps fields to a column, either a 1-based index or the name
+	// of the column in the last header row.
+	columns map[string]string
+}
+
+func csvProfiles(conf string) ([]string, error) {
+	o, _, err := confPrefix(conf, KImportCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	names := make([]string, 0)
+	for _, k := range o {
+		name := strings.SplitN(k, ".", 2)[0]
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func readCSVProfile(conf, name string) (*csvProfile, error) {
+	names, err := csvProfiles(conf)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" && len(names) == 1 {
+		name = names[0]
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no profile given, available: %s", strings.Join(names, ", "))
+	}
+
+	prefix := KImportCSV + name + "."
+	_, kv, err := confPrefix(conf, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(kv) == 0 {
+		return nil, fmt.Errorf("no such csv profile '%s', available: %s", name, strings.Join(names, ", "))
+	}
+
+	p := &csvProfile{
+		name:      name,
+		account:   kv["account"],
+		delimiter: ',',
+		encoding:  strings.ToLower(kv["encoding"]),
+		columns:   make(map[string]string),
+	}
+	if p.account == "" {
+		p.account = name
+	}
+
+	switch d := kv["delimiter"]; d {
+	case "":
+	case "tab", "\\t":
+		p.delimiter = '\t'
+	default:
+		r, n := utf8.DecodeRuneInString(d)
+		if n != len(d) {
+			return nil, fmt.Errorf("%sdelimiter must be a single character", prefix)
+		}
+		p.delimiter = r
+	}
+
+	if h := kv["header"]; h != "" {
+		if p.header, err = strconv.Atoi(h); err != nil {
+			return nil, fmt.Errorf("%sheader: %w", prefix, err)
+		}
+	}
+
+	if d := kv["decimal-comma"]; d != "" {
+		if p.decimalComma, err = strconv.ParseBool(d); err != nil {
+			return nil, fmt.Errorf("%sdecimal-comma: %w", prefix, err)
+		}
+	}
+
+	if p.formats, err = confPrefixArray(conf, prefix+"date-format"); err != nil {
+		return nil, err
+	}
+	if len(p.formats) == 0 {
+		p.formats = dateFormats
+	}
+
+	for _, c := range csvColumns {
+		if v := kv["column."+c]; v != "" {
+			p.columns[c] = v
+		}
+	}
+	if p.columns["date"] == "" {
+		return nil, fmt.Errorf("%scolumn.date is required", prefix)
+	}
+	if p.columns["amount"] == "" && p.columns["debit"] == "" && p.columns["credit"] == "" {
+		return nil, fmt.Errorf("%scolumn.amount or column.debit / column.credit is required", prefix)
+	}
+
+	return p, nil
+}
+
+// cp1252 maps the bytes 0x80-0x9f of windows-1252 to unicode, the other
+// bytes equal latin-1.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+func (p *csvProfile) decode(b []byte) (string, error) {
+	switch p.encoding {
+	case "", "utf-8", "utf8":
+		b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+		if !utf8.Valid(b) {
+			return "", fmt.Errorf("not utf-8, set %s%s.encoding", KImportCSV, p.name)
+		}
+		return string(b), nil
+	case "latin1", "latin-1", "iso-8859-1":
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		return string(r), nil
+	case "windows-1252", "cp1252":
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+			if c >= 0x80 && c < 0xa0 {
+				r[i] = cp1252[c-0x80]
+			}
+		}
+		return string(r), nil
+	}
+
+	return "", fmt.Errorf("unknown encoding '%s'", p.encoding)
+}
+
+// amount parses a bank's amount, accounting style negatives like (12.50) and
+// 12.50- included. Currency symbols and spaces are ignored.
+func (p *csvProfile) amount(str string) (gnucash.Value, error) {
+	orig := str
+	str = strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || strings.ContainsRune("-+.,()", r) {
+			return r
+		}
+		return -1
+	}, str)
+
+	neg := false
+	if strings.HasPrefix(str, "(") && strings.HasSuffix(str, ")") {
+		neg, str = true, str[1:len(str)-1]
+	}
+	if strings.HasSuffix(str, "-") && !strings.HasPrefix(str, "-") {
+		neg, str = true, str[:len(str)-1]
+	}
+
+	if p.decimalComma {
+		str = strings.ReplaceAll(str, ".", "")
+		str = strings.Replace(str, ",", ".", 1)
+	} else {
+		str = strings.ReplaceAll(str, ",", "")
+	}
+
+	v, err := gnucash.ParseDecimal(str)
+	if err != nil {
+		return v, fmt.Errorf("invalid amount '%s'", orig)
+	}
+	if neg && v.Sign() > 0 {
+		v = v.Neg()
+	}
+
+	return v, nil
+}
+
+// parse reads a bank export as a statement of the profile's account.
+func (p *csvProfile) parse(r io.Reader) ([]gnucash.BankStatement, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := p.decode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(strings.NewReader(text))
+	cr.Comma = p.delimiter
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var header []string
+	if p.header > 0 && len(rows) >= p.header {
+		header = rows[p.header-1]
+	}
+
+	cols := make(map[string]int, len(p.columns))
+	for field, col := range p.columns {
+		if n, err := strconv.Atoi(col); err == nil {
+			cols[field] = n - 1
+			continue
+		}
+		found := false
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), col) {
+				cols[field], found = i, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no column '%s' in the header", col)
+		}
+	}
+
+	get := func(row []string, field string) string {
+		ix, ok := cols[field]
+		if !ok || ix < 0 || ix >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[ix])
+	}
+
+	st := gnucash.BankStatement{
+		Account: p.account,
+		Lines:   make([]gnucash.BankLine, 0, len(rows)),
+	}
+	for i := p.header; i < len(rows); i++ {
+		row := rows[i]
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		l := gnucash.BankLine{
+			Description: get(row, "counterparty"),
+			Memo:        get(row, "description"),
+			IBAN:        get(row, "iban"),
+			ID:          get(row, "id"),
+		}
+		if l.Description == "" {
+			l.Description, l.Memo = l.Memo, ""
+		}
+
+		if l.Date, err = parseDate(get(row, "date"), p.formats); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+
+		if a := get(row, "amount"); a != "" {
+			if l.Amount, err = p.amount(a); err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+1, err)
+			}
+		}
+		for _, f := range []string{"credit", "debit"} {
+			a := get(row, f)
+			if a == "" {
+				continue
+			}
+			v, err := p.amount(a)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+1, err)
+			}
+			if v.Sign() < 0 {
+				v = v.Neg()
+			}
+			if f == "debit" {
+				v = v.Neg()
+			}
+			l.Amount = l.Amount.Add(v)
+		}
+
+		st.Lines = append(st.Lines, l)
+	}
+
+	return []gnucash.BankStatement{st}, nil
+}
+
+func importCSVCommand(fr *flags.Set, conf *string) {
+	var insert bool
+	var account, profile string
+	fr.Add("import-csv").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.BoolVar(&insert, "insert", false, "append the transactions to the book instead of printing csv")
+		set.StringVar(&account, "account", "", "book account (fqn or fuzzy query) instead of the configured "+KImportAccount+"<bank account>")
+		set.StringVar(&profile, "profile", "", "csv profile, "+KImportCSV+"<profile>.* in the config (default: the only one)")
+		return func(h *flags.Help) {
+			h.Add("import csv bank exports described by a profile.")
+			h.Add("the files are the arguments, profile entries:")
+			h.Add("  account        bank account for " + KImportAccount + " (default: profile name)")
+			h.Add("  delimiter      field delimiter, 'tab' for tabs (default: ,)")
+			h.Add("  encoding       utf-8, latin1 or windows-1252 (default: utf-8)")
+			h.Add("  header         number of header rows (default: 0)")
+			h.Add("  date-format[]  go time layouts (default: 2006-01-02, 02-01-2006)")
+			h.Add("  decimal-comma  amounts are written as 1.234,56")
+			h.Add("  column.<field> 1-based index or header name, fields:")
+			h.Add("                 " + strings.Join(csvColumns, ", "))
+			h.Add("debit and credit columns are used when a bank has no signed amount.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		if len(args) == 0 {
+			return errors.New("no files given")
+		}
+
+		p, err := readCSVProfile(*conf, profile)
+		if err != nil {
+			return err
+		}
+
+		return importFiles(*conf, insert, account, args, p.parse)
+	})
+}
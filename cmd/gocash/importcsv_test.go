@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCSVProfileAmount(t *testing.T) {
+	tests := []struct {
+		str          string
+		decimalComma bool
+		exp          string
+	}{
+		{"12.50", false, "12.50"},
+		{"-1,234.50", false, "-1234.50"},
+		{"+3", false, "3.00"},
+		{"€ 12,50", true, "12.50"},
+		{"-1.234,50", true, "-1234.50"},
+		{"(12,50)", true, "-12.50"},
+		{"( 1,234.50 )", false, "-1234.50"},
+		{"12,50-", true, "-12.50"},
+		{"12.50- EUR", false, "-12.50"},
+		{"(-12.50)", false, "-12.50"},
+	}
+
+	for _, test := range tests {
+		p := &csvProfile{decimalComma: test.decimalComma}
+		v, err := p.amount(test.str)
+		if err != nil {
+			t.Errorf("%s: %s", test.str, err)
+			continue
+		}
+		if got := fmt.Sprintf("%.2f", v.Float64()); got != test.exp {
+			t.Errorf("%s: expected %s got %s", test.str, test.exp, got)
+		}
+	}
+
+	for _, str := range []string{"", "abc", "12-50", "--12", "(12"} {
+		p := &csvProfile{}
+		if _, err := p.amount(str); err == nil || !strings.Contains(err.Error(), "invalid amount") {
+			t.Errorf("%s: expected an invalid amount error got %v", str, err)
+		}
+	}
+}
+
+func TestCSVProfile(t *testing.T) {
+	conf := testConf(t, `
+import.csv.bank.account = NL91ABNA0417164300
+import.csv.bank.delimiter = ;
+import.csv.bank.encoding = windows-1252
+import.csv.bank.header = 2
+import.csv.bank.decimal-comma = true
+import.csv.bank.date-format[] = 02/01/2006
+import.csv.bank.column.date = Date
+import.csv.bank.column.debit = 3
+import.csv.bank.column.credit = 4
+import.csv.bank.column.counterparty = Name
+import.csv.bank.column.description = Memo
+`)
+
+	p, err := readCSVProfile(conf, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.account != "NL91ABNA0417164300" || p.delimiter != ';' || p.header != 2 || !p.decimalComma {
+		t.Errorf("unexpected profile %+v", p)
+	}
+
+	doc := "Export\n" +
+		"Date;Name;Out;In;Memo\n" +
+		"05/01/2024;Caf\xe9;3,50;;coffee\n" +
+		"\n" +
+		"25/01/2024;;;(1.000,00);refund \x80\n" +
+		"26/01/2024;Shop;12,50-;;\n"
+	stmts, err := p.parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 || stmts[0].Account != p.account {
+		t.Fatalf("expected 1 statement of %s got %+v", p.account, stmts)
+	}
+
+	exp := []string{
+		"2024-01-05 -3.50 Café coffee",
+		// the credit column is taken as a credit whatever its sign.
+		"2024-01-25 1000.00 refund € ",
+		"2024-01-26 -12.50 Shop ",
+	}
+	lines := stmts[0].Lines
+	if len(lines) != len(exp) {
+		t.Fatalf("expected %d lines got %d", len(exp), len(lines))
+	}
+	for i, l := range lines {
+		str := fmt.Sprintf("%s %.2f %s %s", l.Date.Format("2006-01-02"), l.Amount.Float64(), l.Description, l.Memo)
+		if str != exp[i] {
+			t.Errorf("expected '%s' got '%s'", exp[i], str)
+		}
+	}
+
+	if _, err := readCSVProfile(conf, "other"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func incomeStatementCommand(fr *flags.Set, conf *string) {
+	var from, to, period, fiscal, output, tab string
+	var depth int
+	var change bool
+	fr.Add("income-statement").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.StringVar(&from, "from", "", "start date (default: start of the current fiscal year)")
+		set.StringVar(&to, "to", "", "end date, inclusive (default: today)")
+		set.StringVar(&period, "period", "month", "period per column: none, week, month, quarter or year")
+		set.StringVar(&fiscal, "fiscal", "01-01", "start of the fiscal year as MM-DD")
+		set.IntVar(&depth, "depth", 0, "maximum account depth, deeper accounts are summed into their parent")
+		set.BoolVar(&change, "change", false, "add period over period change columns")
+		set.StringVar(&output, "o", "table", "output format: table, csv, json or sheet")
+		set.StringVar(&tab, "tab", "Income Statement", "sheet tab to write to with -o sheet")
+		return func(h *flags.Help) {
+			h.Add("income, expenses and net income (profit & loss) per period.")
+			h.Add("amounts are converted to the report currency or the book's currency.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		fy, err := time.Parse("01-02", fiscal)
+		if err != nil {
+			return fmt.Errorf("invalid fiscal year start '%s': %w", fiscal, err)
+		}
+
+		g, err := gnucash.ParseGranularity(period)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		end := now
+		if to != "" {
+			if end, err = time.Parse(dFormat, to); err != nil {
+				return err
+			}
+		}
+		start := gnucash.FiscalYear(end, fy.Month(), fy.Day())
+		if from != "" {
+			if start, err = time.Parse(dFormat, from); err != nil {
+				return err
+			}
+		}
+		y, m, d := end.Date()
+		end = time.Date(y, m, d+1, 0, 0, 0, 0, end.Location())
+		if !start.Before(end) {
+			return fmt.Errorf("%s is not before %s", start.Format(dFormat), end.Format(dFormat))
+		}
+
+		currency, err := reportCurrency(*conf)
+		if err != nil {
+			return err
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		periods := gnucash.Periods(start, end, g, fy.Month(), fy.Day())
+		st, err := book.IncomeStatement(periods, currency, depth)
+		if err != nil {
+			return err
+		}
+		if change {
+			st = st.Changes()
+		}
+
+		return writeStatement(
+			st,
+			[]statementTotal{{"Net Income", st.NetIncome()}},
+			output,
+			*conf,
+			tab,
+		)
+	})
+}
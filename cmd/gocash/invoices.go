@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func invoicesCommand(fr *flags.Set, conf *string) {
+	var typ, at, output string
+	var all bool
+	fr.Add("invoices").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.BoolVar(&all, "all", false, "include paid and unposted invoices")
+		set.StringVar(&typ, "type", "", "only list this type: invoice, bill or voucher")
+		set.StringVar(&at, "at", "", "calculate days overdue at this date (default: today)")
+		set.StringVar(&output, "o", "table", "output format: table or csv")
+		return func(h *flags.Help) {
+			h.Add("list open invoices and bills, oldest due date first.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		now := time.Now()
+		if at != "" {
+			var err error
+			if now, err = time.Parse(dFormat, at); err != nil {
+				return err
+			}
+		}
+
+		switch typ {
+		case "", "invoice", "bill", "voucher":
+		default:
+			return fmt.Errorf("unknown invoice type '%s'", typ)
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		invoices := book.Invoices
+		if !all {
+			invoices = invoices.Open()
+		}
+
+		list := make(gnucash.Invoices, 0, len(invoices))
+		for _, inv := range invoices {
+			if typ == "" || inv.Type() == typ {
+				list = append(list, inv)
+			}
+		}
+		list.SortDueDate()
+
+		header := []string{"type", "id", "owner", "posted", "due", "amount", "owed", "overdue"}
+		row := func(inv *gnucash.Invoice) []string {
+			if !inv.IsPosted() {
+				return []string{inv.Type(), inv.ID, inv.OwnerName(), "", "", "", "", ""}
+			}
+			overdue := ""
+			if !inv.Owed().IsZero() {
+				overdue = fmt.Sprintf("%d", inv.DaysOverdue(now))
+			}
+			return []string{
+				inv.Type(),
+				inv.ID,
+				inv.OwnerName(),
+				inv.Posted.Get().Format(dFormat),
+				inv.DueDate().Format(dFormat),
+				fmt.Sprintf("%.2f", inv.Amount().Float64()),
+				fmt.Sprintf("%.2f", inv.Owed().Float64()),
+				overdue,
+			}
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, inv := range list {
+				fmt.Fprintln(w, strings.Join(row(inv), "\t")+"\t")
+			}
+			return w.Flush()
+
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			for _, inv := range list {
+				if err := w.Write(row(inv)); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+
+		return fmt.Errorf("unknown output format '%s'", output)
+	})
+}
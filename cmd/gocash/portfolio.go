@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func portfolioCommand(fr *flags.Set, conf *string) {
+	var from, to, policy, output string
+	fr.Add("portfolio").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.StringVar(&from, "from", "", "start of the period (default: start of this year)")
+		set.StringVar(&to, "to", "", "end of the period (default: today)")
+		set.StringVar(&policy, "policy", "fifo", "cost basis for sales without lots: fifo, lifo or average")
+		set.StringVar(&output, "o", "table", "output format: table or csv")
+		return func(h *flags.Help) {
+			h.Add("shares, cost basis, market value, unrealized gain and")
+			h.Add("time-weighted (twr) and money-weighted (xirr) returns")
+			h.Add("of every account holding a commodity other than a currency.")
+			h.Add("accounts are filtered by the regexes passed as arguments.")
+			h.Add(fmt.Sprintf("amounts are converted to %s if set.", KReportCurrency))
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		now := time.Now()
+		start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+		end := now
+		var err error
+		if from != "" {
+			if start, err = time.Parse(dFormat, from); err != nil {
+				return err
+			}
+		}
+		if to != "" {
+			if end, err = time.Parse(dFormat, to); err != nil {
+				return err
+			}
+			end = end.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		if !start.Before(end) {
+			return fmt.Errorf("%s is not before %s", start.Format(dFormat), end.Format(dFormat))
+		}
+
+		p, err := gnucash.ParseGainsPolicy(policy)
+		if err != nil {
+			return err
+		}
+
+		include, err := anyRegexp(args)
+		if err != nil {
+			return err
+		}
+
+		currency, err := reportCurrency(*conf)
+		if err != nil {
+			return err
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		accounts := make(gnucash.Accounts, 0)
+		for _, a := range book.Accounts {
+			if include == nil || include.MatchString(a.FQN) {
+				accounts = append(accounts, a)
+			}
+		}
+
+		portfolio, err := book.Portfolio(accounts, p, currency, start, end)
+		if err != nil {
+			return err
+		}
+		for _, err := range portfolio.Skipped {
+			fmt.Fprintf(os.Stderr, "skipped %s\n", err)
+		}
+
+		f := func(v gnucash.Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+		pct := func(r float64) string {
+			if math.IsNaN(r) {
+				return ""
+			}
+			return fmt.Sprintf("%.2f%%", r*100)
+		}
+
+		header := []string{"account", "commodity", "shares", "price", "cost", "market", "gain", "twr", "xirr"}
+		rows := make([][]string, 0, len(portfolio.Holdings)+1)
+		for _, h := range portfolio.Holdings {
+			rows = append(rows, []string{
+				h.Account.FQN,
+				string(h.Account.Commodity.ID),
+				strconv.FormatFloat(h.Shares.Float64(), 'f', -1, 64),
+				strconv.FormatFloat(h.Price.Float64(), 'f', -1, 64),
+				f(h.Cost),
+				f(h.Market),
+				f(h.Gain()),
+				pct(h.TWR),
+				pct(h.XIRR),
+			})
+		}
+		cost, market := portfolio.Total()
+		xirr, _ := portfolio.XIRR()
+		rows = append(rows, []string{"total", "", "", "", f(cost), f(market), f(market.Sub(cost)), "", pct(xirr)})
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, r := range rows {
+				fmt.Fprintln(w, strings.Join(r, "\t")+"\t")
+			}
+			return w.Flush()
+
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			if err := w.WriteAll(rows); err != nil {
+				return err
+			}
+			return w.Error()
+		}
+
+		return fmt.Errorf("unknown output format '%s'", output)
+	})
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+// readStatementCSV reads bank statement lines formatted as
+// date,amount[,description]. A header line is skipped.
+func readStatementCSV(path string) ([]gnucash.BankLine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	lines := make([]gnucash.BankLine, 0)
+	for n := 1; ; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected date,amount[,description]", path, n)
+		}
+
+		date, err := time.Parse(dFormat, strings.TrimSpace(rec[0]))
+		if err != nil {
+			if n == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		amount, err := gnucash.ParseDecimal(strings.TrimSpace(rec[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		l := gnucash.BankLine{Date: date, Amount: amount}
+		if len(rec) > 2 {
+			l.Description = strings.TrimSpace(rec[2])
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, nil
+}
+
+func reconcileCommand(fr *flags.Set, conf *string) {
+	var date, balance, statement string
+	var days int
+	var auto, dry bool
+	fr.Add("reconcile").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.StringVar(&date, "date", "", "statement end date (default: today)")
+		set.StringVar(&balance, "balance", "", "statement ending balance")
+		set.StringVar(&statement, "statement", "", "csv statement (date,amount[,description]) to match against")
+		set.IntVar(&days, "days", 3, "max days between a statement line and the matching split")
+		set.BoolVar(&auto, "auto", false, "do not ask, finish if the automatic matches balance")
+		set.BoolVar(&dry, "dry", false, "do not write the book")
+		return func(h *flags.Help) {
+			h.Add("reconcile an account against a bank statement.")
+			h.Add("the account is the first argument: an fqn or a fuzzy query.")
+			h.Add("without -balance the ending balance is the reconciled balance")
+			h.Add("plus the lines of -statement.")
+			h.Add("cleared splits or the splits matching the statement are marked,")
+			h.Add("the remaining differences can be fixed interactively.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		if len(args) == 0 {
+			return errors.New("no account given")
+		}
+		if balance == "" && statement == "" {
+			return errors.New("need -balance or -statement")
+		}
+
+		end := time.Now()
+		var err error
+		if date != "" {
+			if end, err = time.Parse(dFormat, date); err != nil {
+				return err
+			}
+		}
+		end = endOfDay(end)
+
+		var lines []gnucash.BankLine
+		if statement != "" {
+			if lines, err = readStatementCSV(statement); err != nil {
+				return err
+			}
+		}
+
+		data, err := readdata(*conf)
+		if err != nil {
+			return err
+		}
+		if !dry {
+			if err := writable(data); err != nil {
+				return err
+			}
+		}
+		book := data.Books[0]
+
+		account, err := findAccount(book, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+
+		r := book.Reconcile(account, end, gnucash.Value{})
+		if balance != "" {
+			if r.EndingBalance, err = gnucash.ParseDecimal(balance); err != nil {
+				return err
+			}
+		} else {
+			r.EndingBalance = r.Reconciled
+			for _, l := range lines {
+				r.EndingBalance = r.EndingBalance.Add(l.Amount)
+			}
+		}
+
+		if statement != "" {
+			for _, l := range r.Match(lines, days) {
+				fmt.Fprintf(
+					os.Stderr,
+					"\033[1;31mno match for %s %.2f %s\033[0m\n",
+					l.Date.Format(dFormat),
+					l.Amount.Float64(),
+					l.Description,
+				)
+			}
+		} else {
+			r.AutoMark()
+		}
+
+		amount := func(v gnucash.Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+		summary := func() {
+			fmt.Printf(
+				"%s: reconciled %s, statement %s, difference %s\n",
+				account.FQN,
+				amount(r.Balance()),
+				amount(r.EndingBalance),
+				amount(r.Difference()),
+			)
+		}
+		list := func() {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for i, s := range r.Splits {
+				mark := " "
+				if r.Marked(s) {
+					mark = "x"
+				}
+				fmt.Fprintf(
+					w,
+					"%d\t[%s]\t%s\t%s\t%s\t%s\t\n",
+					i+1,
+					mark,
+					s.Transaction.DatePosted.Get().Format(dFormat),
+					s.ReconciledState,
+					s.Transaction.Description,
+					amount(s.Quantity),
+				)
+			}
+			w.Flush()
+			summary()
+		}
+
+		finish := func() error {
+			if err := r.Finish(time.Now()); err != nil {
+				return err
+			}
+			if dry {
+				return nil
+			}
+			return writedata(*conf, data)
+		}
+
+		if auto {
+			summary()
+			if !r.Difference().IsZero() {
+				return errors.New("automatic matches do not balance")
+			}
+			return finish()
+		}
+
+		s := bufio.NewScanner(os.Stdin)
+		for {
+			list()
+			fmt.Print("toggle [1 2 4-6], (a)ll, (n)one, (f)inish, (q)uit: ")
+			if !s.Scan() {
+				if err := s.Err(); err != nil {
+					return err
+				}
+				return errors.New("aborted")
+			}
+
+			switch in := strings.TrimSpace(s.Text()); in {
+			case "q":
+				return errors.New("aborted")
+			case "f":
+				if !r.Difference().IsZero() {
+					fmt.Fprintln(os.Stderr, "\033[1;31mdifference is not zero\033[0m")
+					continue
+				}
+				return finish()
+			case "a", "n":
+				for _, sp := range r.Splits {
+					r.Mark(sp, in == "a")
+				}
+			default:
+				for _, f := range strings.Fields(in) {
+					from, to, _ := strings.Cut(f, "-")
+					a, err1 := strconv.Atoi(from)
+					b, err2 := a, error(nil)
+					if to != "" {
+						b, err2 = strconv.Atoi(to)
+					}
+					if err1 != nil || err2 != nil || a < 1 || b > len(r.Splits) || a > b {
+						fmt.Fprintf(os.Stderr, "\033[1;31minvalid selection '%s'\033[0m\n", f)
+						continue
+					}
+					for i := a - 1; i < b; i++ {
+						r.Mark(r.Splits[i], !r.Marked(r.Splits[i]))
+					}
+				}
+			}
+		}
+	})
+}
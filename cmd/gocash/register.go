@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/flags"
+	"github.com/frizinak/gocash/gnucash"
+)
+
+func registerCommand(fr *flags.Set, conf *string) {
+	var from, to, min, max, desc, states, output string
+	var children bool
+	fr.Add("register").Define(func(set *flag.FlagSet) flags.HelpCB {
+		set.BoolVar(&children, "children", false, "include the splits of child accounts")
+		set.StringVar(&from, "from", "", "only list transactions posted on or after this date")
+		set.StringVar(&to, "to", "", "only list transactions posted on or before this date")
+		set.StringVar(&min, "min", "", "minimum absolute amount")
+		set.StringVar(&max, "max", "", "maximum absolute amount")
+		set.StringVar(&desc, "desc", "", "regex the description or memo must match")
+		set.StringVar(&states, "state", "", "reconciled states to list, e.g.: nc for new and cleared")
+		set.StringVar(&output, "o", "table", "output format: table or csv")
+		return func(h *flags.Help) {
+			h.Add("list the transactions of an account with a running balance.")
+			h.Add("the account is the first argument: an fqn or a fuzzy query.")
+			h.Add("the balance includes transactions hidden by the filters.")
+		}
+	}).Handler(func(set *flags.Set, args []string) error {
+		if len(args) == 0 {
+			return errors.New("no account given")
+		}
+
+		var f gnucash.RegisterFilter
+		var err error
+		if from != "" {
+			if f.From, err = time.Parse(dFormat, from); err != nil {
+				return err
+			}
+		}
+		if to != "" {
+			if f.To, err = time.Parse(dFormat, to); err != nil {
+				return err
+			}
+			f.To = endOfDay(f.To)
+		}
+		for _, v := range []struct {
+			str string
+			dst **gnucash.Value
+		}{{min, &f.Min}, {max, &f.Max}} {
+			if v.str == "" {
+				continue
+			}
+			val, err := gnucash.ParseDecimal(v.str)
+			if err != nil {
+				return err
+			}
+			*v.dst = &val
+		}
+		if desc != "" {
+			if f.Description, err = regexp.Compile(desc); err != nil {
+				return err
+			}
+		}
+		for _, r := range states {
+			state := gnucash.ReconciledState(r)
+			if !state.Valid() {
+				return fmt.Errorf("unknown reconciled state '%c', use n, c, y, f or v", r)
+			}
+			f.States = append(f.States, state)
+		}
+
+		book, err := readbook(*conf)
+		if err != nil {
+			return err
+		}
+
+		account, err := findAccount(book, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+
+		reg := book.Register(account, children, f)
+
+		header := []string{"date", "num", "description", "transfer", "r", "debit", "credit", "balance"}
+		if children {
+			header = append(header[:3], append([]string{"account"}, header[3:]...)...)
+		}
+		amount := func(v gnucash.Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+		row := func(e gnucash.RegisterEntry) []string {
+			debit, credit := "", ""
+			if e.Amount.Sign() >= 0 {
+				debit = amount(e.Amount)
+			} else {
+				credit = amount(e.Amount.Neg())
+			}
+			desc := e.Transaction.Description
+			if e.Split.Memo != "" {
+				desc += " (" + e.Split.Memo + ")"
+			}
+			r := []string{e.Date().Format(dFormat), e.Transaction.Num, desc}
+			if children {
+				r = append(r, e.Split.Account.FQN)
+			}
+			return append(
+				r,
+				e.OthersString(),
+				e.Split.ReconciledState.String(),
+				debit,
+				credit,
+				amount(e.Balance),
+			)
+		}
+
+		switch output {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+			for _, e := range reg {
+				fmt.Fprintln(w, strings.Join(row(e), "\t")+"\t")
+			}
+			return w.Flush()
+
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			for _, e := range reg {
+				if err := w.Write(row(e)); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+
+		return fmt.Errorf("unknown output format '%s'", output)
+	})
+}
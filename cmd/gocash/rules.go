@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frizinak/gocash/gnucash"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// rule categorises a transaction of which only the source account is known
+// by setting its counter account. Empty fields match anything.
+type rule struct {
+	name string
+	// description is matched against the description and the memo,
+	// counterparty against the counterparty's name and IBAN.
+	description  *regexp.Regexp
+	counterparty *regexp.Regexp
+	// min and max bound the amount as seen from the source account, i.e.:
+	// negative when money leaves it.
+	min, max *gnucash.Value
+	weekdays map[time.Weekday]struct{}
+	source   string
+	account  string
+	// memo replaces the memo, $1 etc. expand to the groups of description.
+	memo   string
+	splits []txSplit
+}
+
+// ruleLearned is logged for transactions categorised by the importers
+// using the classifier rather than a rule.
+const ruleLearned = "(learned)"
+
+// ruleLog records how a transaction was categorised.
+type ruleLog struct {
+	rule string
+	tx   *transaction
+}
+
+type rules struct {
+	list []*rule
+	log  []ruleLog
+}
+
+func readRules(conf string, aliases map[string]string) (*rules, error) {
+	o, kv, err := confPrefix(conf, KRule)
+	if err != nil {
+		return nil, err
+	}
+
+	account := func(name, key, alias string) (string, error) {
+		fqn, ok := aliases[alias]
+		if !ok {
+			return "", fmt.Errorf("%s%s.%s: no such account '%s'", KRule, name, key, alias)
+		}
+		return fqn, nil
+	}
+
+	rs := &rules{list: make([]*rule, 0)}
+	seen := make(map[string]struct{})
+	for _, k := range o {
+		name := strings.SplitN(k, ".", 2)[0]
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		get := func(key string) string { return kv[name+"."+key] }
+		re := func(key string) (*regexp.Regexp, error) {
+			v := get(key)
+			if v == "" {
+				return nil, nil
+			}
+			r, err := regexp.Compile(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s%s.%s: %w", KRule, name, key, err)
+			}
+			return r, nil
+		}
+		value := func(key string) (*gnucash.Value, error) {
+			v := get(key)
+			if v == "" {
+				return nil, nil
+			}
+			d, err := gnucash.ParseDecimal(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s%s.%s: %w", KRule, name, key, err)
+			}
+			return &d, nil
+		}
+
+		r := &rule{name: name, memo: get("memo")}
+		if r.description, err = re("description"); err != nil {
+			return nil, err
+		}
+		if r.counterparty, err = re("counterparty"); err != nil {
+			return nil, err
+		}
+		if r.min, err = value("min"); err != nil {
+			return nil, err
+		}
+		if r.max, err = value("max"); err != nil {
+			return nil, err
+		}
+
+		if v := get("weekday"); v != "" {
+			r.weekdays = make(map[time.Weekday]struct{})
+			for _, d := range strings.Split(v, ",") {
+				d = strings.ToLower(strings.TrimSpace(d))
+				if len(d) > 3 {
+					d = d[:3]
+				}
+				wd, ok := weekdays[d]
+				if !ok {
+					return nil, fmt.Errorf("%s%s.weekday: unknown day '%s'", KRule, name, d)
+				}
+				r.weekdays[wd] = struct{}{}
+			}
+		}
+
+		if v := get("source"); v != "" {
+			if r.source, err = account(name, "source", v); err != nil {
+				return nil, err
+			}
+		}
+		if v := get("account"); v != "" {
+			if r.account, err = account(name, "account", v); err != nil {
+				return nil, err
+			}
+		}
+
+		splits, err := confPrefixArray(conf, KRule+name+".split")
+		if err != nil {
+			return nil, err
+		}
+		total := gnucash.Value{}
+		for _, s := range splits {
+			ix := strings.LastIndex(s, " ")
+			if ix < 0 {
+				return nil, fmt.Errorf("%s%s.split: expected '<account> <percentage>' got '%s'", KRule, name, s)
+			}
+			pct, err := gnucash.ParseDecimal(strings.TrimSuffix(strings.TrimSpace(s[ix:]), "%"))
+			if err != nil {
+				return nil, fmt.Errorf("%s%s.split: %w", KRule, name, err)
+			}
+			fqn, err := account(name, "split", strings.TrimSpace(s[:ix]))
+			if err != nil {
+				return nil, err
+			}
+			total = total.Add(pct)
+			r.splits = append(r.splits, txSplit{fqn, pct})
+		}
+		if len(r.splits) != 0 && !total.Equal(gnucash.NewValue(100, 1)) {
+			return nil, fmt.Errorf("%s%s.split: percentages add up to %.2f not 100", KRule, name, total.Float64())
+		}
+
+		if r.account == "" && len(r.splits) == 0 {
+			return nil, fmt.Errorf("%s%s needs an account or splits", KRule, name)
+		}
+
+		rs.list = append(rs.list, r)
+	}
+
+	return rs, nil
+}
+
+// match reports whether the rule applies to tx, source being its known
+// account, and returns the memo to use.
+func (r *rule) match(tx *transaction, source, counterparty, iban string) (string, bool) {
+	memo := tx.memo
+	if r.source != "" && r.source != source {
+		return memo, false
+	}
+
+	amount := tx.amount
+	if source == tx.from {
+		amount = amount.Neg()
+	}
+	if r.min != nil && amount.Sub(*r.min).Sign() < 0 {
+		return memo, false
+	}
+	if r.max != nil && amount.Sub(*r.max).Sign() > 0 {
+		return memo, false
+	}
+
+	if r.weekdays != nil {
+		d, err := time.Parse(dFormat, tx.date)
+		if err != nil {
+			return memo, false
+		}
+		if _, ok := r.weekdays[d.Weekday()]; !ok {
+			return memo, false
+		}
+	}
+
+	if r.counterparty != nil &&
+		!r.counterparty.MatchString(counterparty) &&
+		(iban == "" || !r.counterparty.MatchString(iban)) {
+		return memo, false
+	}
+
+	var groups []int
+	var text string
+	if r.description != nil {
+		for _, t := range []string{tx.descr, tx.memo} {
+			if groups = r.description.FindStringSubmatchIndex(t); groups != nil {
+				text = t
+				break
+			}
+		}
+		if groups == nil {
+			return memo, false
+		}
+	}
+
+	if r.memo != "" {
+		memo = r.memo
+		if groups != nil {
+			memo = string(r.description.ExpandString(nil, r.memo, text, groups))
+		}
+	}
+
+	return memo, true
+}
+
+// categorise sets the empty account of tx, from or to, using the first
+// matching rule and returns its name, or "" if none matches.
+func (rs *rules) categorise(tx *transaction, counterparty, iban string) string {
+	source := tx.from
+	if source == "" {
+		source = tx.to
+	}
+
+	for _, r := range rs.list {
+		memo, ok := r.match(tx, source, counterparty, iban)
+		if !ok {
+			continue
+		}
+
+		target := r.account
+		if target == "" {
+			target = r.splits[0].account
+		}
+
+		tx.memo = memo
+		tx.splits = r.splits
+		tx.splitFrom = tx.from == ""
+		if tx.from == "" {
+			tx.from = target
+		} else {
+			tx.to = target
+		}
+
+		rs.record(r.name, tx)
+		return r.name
+	}
+
+	return ""
+}
+
+// record adds tx to the report as categorised by rule, "" meaning it was
+// not categorised.
+func (rs *rules) record(rule string, tx *transaction) {
+	rs.log = append(rs.log, ruleLog{rule, tx})
+}
+
+// report writes which rule categorised which transaction and a count per
+// rule.
+func (rs *rules) report(w io.Writer) error {
+	if len(rs.log) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "rule\tdate\tamount\tdescription\taccounts\t")
+	for _, l := range rs.log {
+		counts[l.rule]++
+		name := l.rule
+		if name == "" {
+			name = "uncategorised"
+		}
+		accounts := l.tx.from + " > " + l.tx.to
+		if len(l.tx.splits) != 0 {
+			parts := make([]string, len(l.tx.splits))
+			for i, s := range l.tx.splits {
+				parts[i] = s.account + " " + strconv.FormatFloat(s.percent.Float64(), 'f', -1, 64) + "%"
+			}
+			if l.tx.splitFrom {
+				accounts = strings.Join(parts, ", ") + " > " + l.tx.to
+			} else {
+				accounts = l.tx.from + " > " + strings.Join(parts, ", ")
+			}
+		}
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%.2f\t%s\t%s\t\n",
+			name,
+			l.tx.date,
+			l.tx.amount.Float64(),
+			l.tx.descr,
+			accounts,
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, r := range rs.list {
+		fmt.Fprintf(w, "  rule %s: %d\n", r.name, counts[r.name])
+	}
+	if n := counts[ruleLearned]; n != 0 {
+		fmt.Fprintf(w, "  %s: %d\n", ruleLearned, n)
+	}
+	fmt.Fprintf(w, "  uncategorised: %d\n", counts[""])
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/frizinak/gocash/gnucash"
+)
+
+var testAliases = map[string]string{
+	"bank":   "Assets.Bank",
+	"coffee": "Expenses.Coffee",
+	"food":   "Expenses.Food",
+}
+
+func TestRules(t *testing.T) {
+	conf := testConf(t, `
+rule.coffee.description = (?i)starbucks (\w+)
+rule.coffee.min = -10
+rule.coffee.max = 0
+rule.coffee.account = coffee
+rule.coffee.memo = coffee in $1
+rule.lunch.description = (?i)lunch
+rule.lunch.weekday = mon, tue, Wednesday, thu, fri
+rule.lunch.source = bank
+rule.lunch.account = food
+rule.shared.counterparty = ^NL
+rule.shared.min = 100
+rule.shared.split[] = food 60
+rule.shared.split[] = coffee 40%
+`)
+
+	rs, err := readRules(conf, testAliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.list) != 3 {
+		t.Fatalf("expected 3 rules got %d", len(rs.list))
+	}
+
+	out := func(date, descr, memo string, amount int64) *transaction {
+		return &transaction{from: "Assets.Bank", date: date, descr: descr, memo: memo, amount: gnucash.NewValue(amount, 1)}
+	}
+	in := func(date, descr string, amount int64) *transaction {
+		return &transaction{to: "Assets.Bank", date: date, descr: descr, amount: gnucash.NewValue(amount, 1)}
+	}
+
+	tests := []struct {
+		name         string
+		tx           *transaction
+		counterparty string
+		iban         string
+		rule         string
+		accounts     string
+		memo         string
+	}{
+		{"memo expansion", out("2024-01-03", "STARBUCKS Paris", "card", 4), "", "", "coffee", "Assets.Bank > Expenses.Coffee", "coffee in Paris"},
+		{"below min", out("2024-01-03", "Starbucks Paris", "", 25), "", "", "", "Assets.Bank > ", ""},
+		{"incoming above max", in("2024-01-03", "Starbucks refund", 4), "", "", "", " > Assets.Bank", ""},
+		{"weekday on the memo", out("2024-01-08", "card payment", "Lunch", 12), "", "", "lunch", "Assets.Bank > Expenses.Food", "Lunch"},
+		{"weekend", out("2024-01-06", "lunch", "", 12), "", "", "", "Assets.Bank > ", ""},
+		{"other source", &transaction{from: "Assets.Cash", date: "2024-01-08", descr: "lunch"}, "", "", "", "Assets.Cash > ", ""},
+		{"iban and splits", in("2024-01-08", "transfer", 150), "J. Doe", "NL91ABNA0417164300", "shared", "Expenses.Food > Assets.Bank", ""},
+		{"too small", in("2024-01-08", "transfer", 50), "J. Doe", "NL91ABNA0417164300", "", " > Assets.Bank", ""},
+	}
+
+	for _, test := range tests {
+		rule := rs.categorise(test.tx, test.counterparty, test.iban)
+		accounts := test.tx.from + " > " + test.tx.to
+		if rule != test.rule || accounts != test.accounts || test.tx.memo != test.memo {
+			t.Errorf(
+				"%s: expected %q %q %q got %q %q %q",
+				test.name,
+				test.rule, test.accounts, test.memo,
+				rule, accounts, test.tx.memo,
+			)
+		}
+	}
+
+	shared := tests[6].tx
+	if !shared.splitFrom || len(shared.splits) != 2 || shared.splits[1].account != "Expenses.Coffee" || shared.splits[1].percent.Float64() != 40 {
+		t.Errorf("expected the incoming amount split 60/40 from food and coffee got %+v", shared.splits)
+	}
+
+	if len(rs.log) != 3 {
+		t.Errorf("expected 3 categorised transactions in the log got %d", len(rs.log))
+	}
+	var buf bytes.Buffer
+	if err := rs.report(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{"rule coffee: 1", "rule lunch: 1", "rule shared: 1", "Expenses.Food 60%, Expenses.Coffee 40% > Assets.Bank"} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Errorf("expected '%s' in the report:\n%s", exp, buf.String())
+		}
+	}
+}
+
+func TestRulesInvalid(t *testing.T) {
+	tests := []struct {
+		conf string
+		err  string
+	}{
+		{"rule.a.description = x", "needs an account or splits"},
+		{"rule.a.account = nope", "no such account 'nope'"},
+		{"rule.a.account = food\nrule.a.weekday = someday", "unknown day 'som'"},
+		{"rule.a.account = food\nrule.a.min = ten", "rule.a.min"},
+		{"rule.a.description = (\nrule.a.account = food", "rule.a.description"},
+		{"rule.a.split[] = food 60\nrule.a.split[] = coffee 30", "add up to 90.00 not 100"},
+		{"rule.a.split[] = food", "expected '<account> <percentage>'"},
+		{"rule.a.split[] = food sixty", "rule.a.split"},
+	}
+
+	for i, test := range tests {
+		conf := testConf(t, test.conf)
+		_, err := readRules(conf, testAliases)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%d: expected an error containing '%s' got %v", i, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/frizinak/gocash/gnucash"
+)
+
+// statementTotal is a summary line printed after the sections of a
+// statement, e.g.: net income.
+type statementTotal struct {
+	name   string
+	values []gnucash.Value
+}
+
+type jsonStatementLine struct {
+	Account     string    `json:"account,omitempty"`
+	Name        string    `json:"name"`
+	Depth       int       `json:"depth"`
+	Placeholder bool      `json:"placeholder,omitempty"`
+	Values      []float64 `json:"values"`
+}
+
+type jsonStatementSection struct {
+	Name  string              `json:"name"`
+	Lines []jsonStatementLine `json:"lines"`
+	Total []float64           `json:"total"`
+}
+
+type jsonStatement struct {
+	Columns  []string               `json:"columns"`
+	Sections []jsonStatementSection `json:"sections"`
+	Totals   []jsonStatementLine    `json:"totals"`
+}
+
+func floats(vs []gnucash.Value) []float64 {
+	l := make([]float64, len(vs))
+	for i, v := range vs {
+		l[i] = v.Float64()
+	}
+	return l
+}
+
+// writeStatement writes a statement as an aligned table, csv, json or to the
+// given tab of the google sheet.
+func writeStatement(st *gnucash.Statement, totals []statementTotal, output, conf, tab string) error {
+	f := func(v gnucash.Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+	row := func(name string, values []gnucash.Value) []string {
+		r := make([]string, 1, len(values)+1)
+		r[0] = name
+		for _, v := range values {
+			r = append(r, f(v))
+		}
+		return r
+	}
+	fqn := func(l gnucash.StatementLine) string {
+		if l.Account != nil {
+			return l.Account.FQN
+		}
+		return l.Name
+	}
+
+	rows := make([][]string, 0)
+	rows = append(rows, append([]string{""}, st.Columns...))
+	for _, sec := range st.Sections {
+		rows = append(rows, []string{sec.Name})
+		for _, l := range sec.Lines {
+			rows = append(rows, row(strings.Repeat("  ", l.Depth)+l.Name, l.Values))
+		}
+		rows = append(rows, row("Total "+sec.Name, sec.Total), nil)
+	}
+	for _, t := range totals {
+		rows = append(rows, row(t.name, t.values))
+	}
+
+	switch output {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, r := range rows {
+			for len(r) <= len(st.Columns) {
+				r = append(r, "")
+			}
+			fmt.Fprintln(w, strings.Join(r, "\t")+"\t")
+		}
+		return w.Flush()
+
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(append([]string{"section", "account", "depth"}, st.Columns...)); err != nil {
+			return err
+		}
+		for _, sec := range st.Sections {
+			for _, l := range sec.Lines {
+				r := row(fqn(l), l.Values)
+				r = append([]string{sec.Name, r[0], fmt.Sprintf("%d", l.Depth)}, r[1:]...)
+				if err := w.Write(r); err != nil {
+					return err
+				}
+			}
+			r := row("", sec.Total)
+			if err := w.Write(append([]string{sec.Name, "Total " + sec.Name, "0"}, r[1:]...)); err != nil {
+				return err
+			}
+		}
+		for _, t := range totals {
+			r := row("", t.values)
+			if err := w.Write(append([]string{"", t.name, "0"}, r[1:]...)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+
+	case "json":
+		js := jsonStatement{
+			Columns:  st.Columns,
+			Sections: make([]jsonStatementSection, len(st.Sections)),
+			Totals:   make([]jsonStatementLine, len(totals)),
+		}
+		for i, sec := range st.Sections {
+			s := jsonStatementSection{
+				Name:  sec.Name,
+				Lines: make([]jsonStatementLine, len(sec.Lines)),
+				Total: floats(sec.Total),
+			}
+			for j, l := range sec.Lines {
+				s.Lines[j] = jsonStatementLine{
+					Name:        l.Name,
+					Depth:       l.Depth,
+					Placeholder: l.Placeholder,
+					Values:      floats(l.Values),
+				}
+				if l.Account != nil {
+					s.Lines[j].Account = l.Account.FQN
+				}
+			}
+			js.Sections[i] = s
+		}
+		for i, t := range totals {
+			js.Totals[i] = jsonStatementLine{Name: t.name, Values: floats(t.values)}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(js)
+
+	case "sheet":
+		srv, sid, err := sheetService(conf)
+		if err != nil {
+			return err
+		}
+
+		end := start(fmt.Sprintf("Updating %s sheet", tab))
+		defer end()
+		vals := make([][]interface{}, 0, len(rows))
+		vals = append(vals, make([]interface{}, len(st.Columns)+1))
+		vals[0][0] = ""
+		for i, c := range st.Columns {
+			vals[0][i+1] = c
+		}
+		add := func(name string, values []gnucash.Value) {
+			r := make([]interface{}, 1, len(values)+1)
+			r[0] = name
+			for _, v := range values {
+				r = append(r, v.Float64())
+			}
+			vals = append(vals, r)
+		}
+		for _, sec := range st.Sections {
+			vals = append(vals, []interface{}{sec.Name})
+			for _, l := range sec.Lines {
+				add(strings.Repeat("  ", l.Depth)+l.Name, l.Values)
+			}
+			add("Total "+sec.Name, sec.Total)
+			vals = append(vals, nil)
+		}
+		for _, t := range totals {
+			add(t.name, t.values)
+		}
+
+		return sheetUpdate(srv, sid, tab+"!A1", sheetPad(vals, len(st.Columns)+1))
+	}
+
+	return fmt.Errorf("unknown output format '%s'", output)
+}
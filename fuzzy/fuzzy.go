@@ -70,6 +70,13 @@ func (index *Index) Search(q string, include Include) {
 }
 
 func (index *Index) parts(q string) []string {
+	return NGrams(index.fuzzyLength, q)
+}
+
+// NGrams splits q into lowercase words and those into overlapping
+// substrings of the given length. Words that are not longer than length are
+// kept whole, single characters are dropped.
+func NGrams(length int, q string) []string {
 	qs := make([]string, 0, len(q))
 	p := strings.Fields(
 		strings.Trim(strings.TrimSpace(strings.ToLower(q)), "!@#$%^&*=./,"),
@@ -82,12 +89,12 @@ func (index *Index) parts(q string) []string {
 		if len(v) < 2 {
 			continue
 		}
-		if len(v) <= index.fuzzyLength {
+		if len(v) <= length {
 			add(p[i])
 			continue
 		}
-		for j := 0; j < len(v)-index.fuzzyLength+1; j++ {
-			add(strings.TrimSpace(string(v[j : j+index.fuzzyLength])))
+		for j := 0; j < len(v)-length+1; j++ {
+			add(strings.TrimSpace(string(v[j : j+length])))
 		}
 	}
 
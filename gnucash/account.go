@@ -16,7 +16,10 @@ type Account struct {
 	Children     Accounts     `xml:"-"`
 	Transactions Transactions `xml:"-"`
 	Commodity    CommodityRef `xml:"commodity"`
+	SCU          int          `xml:"commodity-scu"`
 	Slots        Slots        `xml:"slots>slot"`
+	Lots         Lots         `xml:"lots>lot"`
+	Extra        Nodes        `xml:",any"`
 }
 
 func (a *Account) String() string {
@@ -48,10 +51,16 @@ func (a *Account) fqn() string {
 
 	fqn := []string{a.Name}
 	parent := a.Parent
+	seen := map[*Account]struct{}{a: {}}
 	for {
 		if parent == nil || parent.Type == AccountTypeRoot {
 			break
 		}
+		// cycles are reported by Book.Check.
+		if _, ok := seen[parent]; ok {
+			break
+		}
+		seen[parent] = struct{}{}
 
 		fqn = append(fqn, parent.Name)
 		parent = parent.Parent
@@ -8,28 +8,38 @@ type Accounts []*Account
 type AccountsLookup struct {
 	byGUID map[GUID]*Account
 	byFQN  map[string]*Account
+	lots   map[GUID]*Lot
 }
 
 func (as Accounts) lookup() *AccountsLookup {
 	lookup := &AccountsLookup{
 		make(map[GUID]*Account, len(as)),
 		make(map[string]*Account, len(as)),
+		make(map[GUID]*Lot),
 	}
 
 	for _, a := range as {
 		lookup.byGUID[a.ID] = a
 		a.Children = make(Accounts, 0)
+		for _, l := range a.Lots {
+			l.Account = a
+			l.Splits = make(Splits, 0, 2)
+			l.Invoice = nil
+			lookup.lots[l.ID] = l
+		}
 	}
 
 	for _, a := range as {
 		if a.ParentID == "" {
 			continue
 		}
-		a.Parent = lookup.byGUID[a.ParentID]
-		lookup.byGUID[a.ParentID].Children = append(
-			lookup.byGUID[a.ParentID].Children,
-			a,
-		)
+		// dangling parents are reported by Book.Check.
+		parent, ok := lookup.byGUID[a.ParentID]
+		if !ok {
+			continue
+		}
+		a.Parent = parent
+		parent.Children = append(parent.Children, a)
 	}
 
 	for _, a := range as {
@@ -76,6 +86,12 @@ func (as *AccountsLookup) ByGUID(id GUID) (*Account, bool) {
 	return a, ok
 }
 
+// Lot returns the lot with the given id from any of the accounts.
+func (as *AccountsLookup) Lot(id GUID) (*Lot, bool) {
+	l, ok := as.lots[id]
+	return l, ok
+}
+
 func (as *AccountsLookup) ByFQN(fqn string) (*Account, bool) {
 	a, ok := as.byFQN[fqn]
 	return a, ok
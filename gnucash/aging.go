@@ -0,0 +1,204 @@
+package gnucash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AgingBuckets is the number of buckets in an aging report.
+const AgingBuckets = 5
+
+// AgingBucketNames are the labels of the aging buckets: not yet due and
+// the number of days overdue.
+var AgingBucketNames = [AgingBuckets]string{"current", "1-30", "31-60", "61-90", "90+"}
+
+// agingBucket returns the bucket for an amount that is the given number of
+// days overdue.
+func agingBucket(days int) int {
+	switch {
+	case days <= 0:
+		return 0
+	case days <= 30:
+		return 1
+	case days <= 60:
+		return 2
+	case days <= 90:
+		return 3
+	}
+
+	return 4
+}
+
+// AgingLine holds what a single customer or vendor owes (or is owed) on a
+// receivable or payable account, bucketed by days overdue.
+type AgingLine struct {
+	Owner   string
+	Account *Account
+	Buckets [AgingBuckets]Value
+}
+
+func (l AgingLine) Total() Value {
+	var v Value
+	for _, b := range l.Buckets {
+		v = v.Add(b)
+	}
+
+	return v
+}
+
+type AgingLines []AgingLine
+
+// Total sums all lines.
+func (ls AgingLines) Total() AgingLine {
+	t := AgingLine{Owner: "total"}
+	for _, l := range ls {
+		for i := range l.Buckets {
+			t.Buckets[i] = t.Buckets[i].Add(l.Buckets[i])
+		}
+	}
+
+	return t
+}
+
+// Aging returns the open amounts of all accounts of the given type
+// (AccountTypeReceivable or AccountTypePayable) at the given time, per owner
+// and account.
+//
+// Splits are grouped by their lot, an open lot is aged from the due date of
+// the invoice it was posted for or, if there is none, the date of its first
+// split. Splits without a lot (e.g.: unapplied payments) are aged by their
+// own date. Payable amounts are negated so what is owed is positive.
+func (b *Book) Aging(typ AccountType, at time.Time) (AgingLines, error) {
+	if typ != AccountTypeReceivable && typ != AccountTypePayable {
+		return nil, fmt.Errorf("can not age accounts of type '%s'", typ)
+	}
+
+	type key struct {
+		owner   string
+		account *Account
+	}
+	lines := make(map[key]*AgingLine)
+	add := func(owner string, a *Account, due time.Time, v Value) {
+		if v.IsZero() {
+			return
+		}
+		if a.Type.CreditNormal() {
+			v = v.Neg()
+		}
+		k := key{owner, a}
+		l, ok := lines[k]
+		if !ok {
+			l = &AgingLine{Owner: owner, Account: a}
+			lines[k] = l
+		}
+		days := int(math.Floor(day(at).Sub(day(due)).Hours() / 24))
+		n := agingBucket(days)
+		l.Buckets[n] = l.Buckets[n].Add(v)
+	}
+
+	for _, a := range b.Accounts {
+		if a.Type != typ {
+			continue
+		}
+
+		ts, _ := b.TransactionsLookup.Find(a.ID)
+		lots := make(map[*Lot]Splits)
+		order := make(Lots, 0)
+		for _, t := range ts {
+			if t.DatePosted.Get().After(at) {
+				continue
+			}
+			for _, s := range t.Splits {
+				if s.AccountID != a.ID {
+					continue
+				}
+				if s.Lot == nil {
+					add(b.splitOwner(s), a, t.DatePosted.Get(), s.Quantity)
+					continue
+				}
+				if _, ok := lots[s.Lot]; !ok {
+					order = append(order, s.Lot)
+				}
+				lots[s.Lot] = append(lots[s.Lot], s)
+			}
+		}
+
+		for _, l := range order {
+			splits := lots[l]
+			due := splits[0].Transaction.DatePosted.Get()
+			for _, s := range splits[1:] {
+				if d := s.Transaction.DatePosted.Get(); d.Before(due) {
+					due = d
+				}
+			}
+			owner := b.lotOwner(l)
+			if inv := l.Invoice; inv != nil && inv.IsPosted() && !inv.Posted.Get().After(at) {
+				due = inv.DueDate()
+			}
+			add(owner, a, due, splits.Quantity())
+		}
+	}
+
+	l := make(AgingLines, 0, len(lines))
+	for _, line := range lines {
+		l = append(l, *line)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Owner != l[j].Owner {
+			return strings.ToLower(l[i].Owner) < strings.ToLower(l[j].Owner)
+		}
+		return l[i].Account.FQN < l[j].Account.FQN
+	})
+
+	return l, nil
+}
+
+// lotOwner returns the name of the customer or vendor a lot belongs to.
+func (b *Book) lotOwner(l *Lot) string {
+	if l.Invoice != nil {
+		return l.Invoice.OwnerName()
+	}
+	if o, ok := l.Owner(); ok {
+		return b.ownerName(o)
+	}
+
+	return ""
+}
+
+// splitOwner returns the owner of the invoice the split's transaction was
+// posted for, if any.
+func (b *Book) splitOwner(s *Split) string {
+	if s.Transaction != nil && s.Transaction.Invoice != nil {
+		return s.Transaction.Invoice.OwnerName()
+	}
+
+	return ""
+}
+
+func (b *Book) ownerName(o Owner) string {
+	switch o.Type {
+	case OwnerCustomer:
+		for _, c := range b.Customers {
+			if c.GUID == o.ID {
+				return c.Name
+			}
+		}
+	case OwnerVendor:
+		for _, v := range b.Vendors {
+			if v.GUID == o.ID {
+				return v.Name
+			}
+		}
+	case OwnerJob:
+		for _, j := range b.Jobs {
+			if j.GUID == o.ID {
+				return b.ownerName(j.Owner)
+			}
+		}
+	}
+
+	return string(o.ID)
+}
@@ -0,0 +1,44 @@
+package gnucash
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAgingMultiSplit(t *testing.T) {
+	lot := func(s *Split) *Split { s.LotID = "lot"; return s }
+	ar := testAccount("ar", "root", AccountTypeReceivable)
+	ar.Lots = Lots{{ID: "lot"}}
+
+	b := testBook(
+		t,
+		Accounts{
+			testAccount("root", "", AccountTypeRoot),
+			ar,
+			testAccount("income", "root", AccountTypeIncome),
+		},
+		Transactions{
+			// two splits in the same lot.
+			{ID: "invoice", DatePosted: NewDate(date(2024, 1, 1)), Splits: Splits{
+				lot(testSplit("ar", 10)), lot(testSplit("ar", 5)), testSplit("income", -15),
+			}},
+			// two splits without a lot.
+			{ID: "sale", DatePosted: NewDate(date(2024, 2, 25)), Splits: Splits{
+				testSplit("ar", 3), testSplit("ar", 4), testSplit("income", -7),
+			}},
+		},
+	)
+
+	lines, err := b.Aging(AccountTypeReceivable, date(2024, 3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := lines.Total()
+	exp := [AgingBuckets]string{"0.00", "7.00", "15.00", "0.00", "0.00"}
+	for i, v := range total.Buckets {
+		if got := fmt.Sprintf("%.2f", v.Float64()); got != exp[i] {
+			t.Errorf("bucket %s: expected %s got %s", AgingBucketNames[i], exp[i], got)
+		}
+	}
+}
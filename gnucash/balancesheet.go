@@ -0,0 +1,166 @@
+package gnucash
+
+import (
+	"time"
+)
+
+// Balance sheet section names.
+const (
+	SectionAssets      = "Assets"
+	SectionLiabilities = "Liabilities"
+	SectionEquity      = "Equity"
+)
+
+// BalanceSheet returns the assets, liabilities and equity at each of the
+// given dates (one column per date), converted to currency at that date.
+// currency defaults to the root account's currency.
+//
+// Amounts are shown with their natural sign: liabilities and equity are
+// positive when they carry a credit balance. Equity includes the retained
+// earnings (all income minus expenses up to the date, at the rates of their
+// dates posted) and unrealized gains, i.e.: the market value of holdings in
+// other commodities minus their cost basis. Whatever difference remains
+// between assets and liabilities plus equity, e.g.: due to unbalanced
+// transactions, is shown as an Imbalance line in equity.
+func (b *Book) BalanceSheet(dates []time.Time, currency CommodityFQN, depth int) (*Statement, error) {
+	if currency == "" {
+		currency = b.defaultCurrency()
+	}
+
+	conv := b.Converter()
+	splits := func(a *Account, at time.Time, f func(s *Split) error) error {
+		ts, _ := b.TransactionsLookup.Find(a.ID)
+		for _, t := range ts {
+			if t.DatePosted.Get().After(at) {
+				continue
+			}
+			for _, s := range t.Splits {
+				if s.AccountID != a.ID {
+					continue
+				}
+				if err := f(s); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// balance is the market value of the account at the date.
+	balance := func(a *Account, col int) (Value, error) {
+		at := dates[col]
+		var q Value
+		err := splits(a, at, func(s *Split) error {
+			q = q.Add(s.Quantity)
+			return nil
+		})
+		if err != nil || q.IsZero() {
+			return q, err
+		}
+
+		return conv.Convert(q, a.Commodity.FQN(), currency, at)
+	}
+
+	// cost is the historical value of the account: its splits' values
+	// converted at their dates posted.
+	cost := func(a *Account, col int) (Value, error) {
+		var v Value
+		err := splits(a, dates[col], func(s *Split) error {
+			if a.Commodity.FQN() == currency {
+				v = v.Add(s.Quantity)
+				return nil
+			}
+			sv, err := conv.SplitValue(s, currency, time.Time{})
+			v = v.Add(sv)
+			return err
+		})
+		return v, err
+	}
+
+	signed := func(a *Account, col int) (Value, error) {
+		v, err := balance(a, col)
+		if a.Type.CreditNormal() {
+			v = v.Neg()
+		}
+		return v, err
+	}
+
+	st := &Statement{Columns: make([]string, len(dates))}
+	for i, d := range dates {
+		st.Columns[i] = d.Format("2006-01-02")
+	}
+
+	sections := []struct {
+		name  string
+		match func(*Account) bool
+	}{
+		{SectionAssets, func(a *Account) bool { return a.Type.Asset() }},
+		{SectionLiabilities, func(a *Account) bool { return a.Type.Liability() }},
+		{SectionEquity, func(a *Account) bool { return a.Type == AccountTypeEquity }},
+	}
+	for _, s := range sections {
+		sec, err := statementSection(s.name, b.RootAccount, s.match, len(dates), depth, signed)
+		if err != nil {
+			return nil, err
+		}
+		st.Sections = append(st.Sections, sec)
+	}
+
+	earnings, err := statementSection(
+		"",
+		b.RootAccount,
+		func(a *Account) bool { return a.Type == AccountTypeIncome || a.Type == AccountTypeExpense },
+		len(dates),
+		0,
+		cost,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	gains, err := statementSection(
+		"",
+		b.RootAccount,
+		func(a *Account) bool {
+			return (a.Type.Asset() || a.Type.Liability() || a.Type == AccountTypeEquity) &&
+				a.Commodity.FQN() != currency
+		},
+		len(dates),
+		0,
+		func(a *Account, col int) (Value, error) {
+			market, err := balance(a, col)
+			if err != nil {
+				return market, err
+			}
+			c, err := cost(a, col)
+			return market.Sub(c), err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	equity := &st.Sections[2]
+	retained := make([]Value, len(dates))
+	for i := range dates {
+		retained[i] = earnings.Total[i].Neg()
+	}
+	if !zeroValues(retained) {
+		equity.add("Retained Earnings", retained)
+	}
+	if !zeroValues(gains.Total) {
+		equity.add("Unrealized Gains", gains.Total)
+	}
+
+	imbalance := make([]Value, len(dates))
+	for i := range dates {
+		imbalance[i] = st.Sections[0].Total[i].
+			Sub(st.Sections[1].Total[i]).
+			Sub(equity.Total[i])
+	}
+	if !zeroValues(imbalance) {
+		equity.add("Imbalance", imbalance)
+	}
+
+	return st, nil
+}
@@ -0,0 +1,79 @@
+package gnucash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBalanceSheet(t *testing.T) {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	aapl := CommodityRef{ID: "AAPL", NS: "NASDAQ"}
+	day := func(d int) Date { return NewDate(time.Date(2024, 1, d, 10, 59, 0, 0, time.UTC)) }
+	account := func(id, name string, typ AccountType, c CommodityRef) *Account {
+		return &Account{ID: GUID(id), Name: name, ParentID: "root", Type: typ, Commodity: c, SCU: 100}
+	}
+	split := func(account string, value, quantity int64) *Split {
+		return &Split{
+			ID:              NewGUID(),
+			AccountID:       GUID(account),
+			ReconciledState: ReconciledStateNew,
+			Value:           NewValue(value, 1),
+			Quantity:        NewValue(quantity, 1),
+		}
+	}
+	tx := func(id string, d int, splits ...*Split) *Transaction {
+		return &Transaction{ID: GUID(id), Currency: eur, DatePosted: day(d), Splits: splits}
+	}
+
+	root := account("root", "Root Account", AccountTypeRoot, eur)
+	root.ParentID = ""
+	b := &Book{
+		ID:          "book",
+		Commodities: Commodities{{CommodityRef: eur}, {CommodityRef: aapl}},
+		Accounts: Accounts{
+			root,
+			account("bank", "Bank", AccountTypeBank, eur),
+			account("broker", "Broker", AccountTypeStock, aapl),
+			account("income", "Income", AccountTypeIncome, eur),
+		},
+		Transactions: Transactions{
+			// two splits in the same account.
+			tx("salary", 1, split("bank", 10, 10), split("bank", 5, 5), split("income", -15, -15)),
+			tx("buy", 2, split("broker", 100, 1), split("bank", -100, -100)),
+			tx("unbalanced", 3, split("bank", 1, 1)),
+		},
+		Prices: Prices{
+			{ID: "p1", Comodity: aapl, Currency: eur, Time: day(2), Value: NewValue(100, 1)},
+			{ID: "p2", Comodity: aapl, Currency: eur, Time: day(10), Value: NewValue(150, 1)},
+		},
+	}
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := b.BalanceSheet([]time.Time{time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}, eur.FQN(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]map[string]string{
+		SectionAssets: {"Bank": "-84.00", "Broker": "150.00"},
+		SectionEquity: {"Retained Earnings": "15.00", "Unrealized Gains": "50.00", "Imbalance": "1.00"},
+	}
+	for name, lines := range exp {
+		sec, ok := st.Section(name)
+		if !ok {
+			t.Fatalf("no section %s", name)
+		}
+		got := make(map[string]string)
+		for _, l := range sec.Lines {
+			got[l.Name] = fmt.Sprintf("%.2f", l.Values[0].Float64())
+		}
+		for line, v := range lines {
+			if got[line] != v {
+				t.Errorf("%s: %s: expected %s got %s", name, line, v, got[line])
+			}
+		}
+	}
+}
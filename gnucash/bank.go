@@ -0,0 +1,52 @@
+package gnucash
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// BankLine is a transaction as listed on a bank statement.
+type BankLine struct {
+	// Date is the booking date.
+	Date      time.Time
+	ValueDate time.Time
+	Amount    Value
+	// Description is the counterparty's name or, if the statement has none,
+	// the bank's description of the transaction.
+	Description string
+	// Memo is the remittance information.
+	Memo string
+	// IBAN is the account number of the counterparty.
+	IBAN       string
+	EndToEndID string
+	// ID is the bank's unique id of the transaction within the account
+	// (e.g.: the OFX FITID), empty if the format has none.
+	ID string
+}
+
+// BankStatement is a statement of a single bank account.
+type BankStatement struct {
+	// Account identifies the bank account, e.g.: its account number or IBAN.
+	Account  string
+	Currency string
+	// Balance is the ending balance at BalanceDate, if the statement lists
+	// one.
+	Balance     Value
+	BalanceDate time.Time
+	Lines       []BankLine
+}
+
+// decodeText returns b as a string, decoding it as latin-1 if it is not
+// valid utf-8, which is what most banks use when they do not use utf-8.
+func decodeText(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+
+	return string(r)
+}
@@ -6,6 +6,7 @@ import (
 )
 
 type Book struct {
+	Version            string             `xml:"version,attr"`
 	ID                 GUID               `xml:"id"`
 	Accounts           Accounts           `xml:"account"`
 	AccountsLookup     *AccountsLookup    `xml:"-"`
@@ -13,9 +14,22 @@ type Book struct {
 	Transactions       Transactions       `xml:"transaction"`
 	TransactionsLookup TransactionsLookup `xml:"-"`
 	Scheduled          Schedules          `xml:"schedxaction"`
+	Templates          Templates          `xml:"template-transactions"`
+	Budgets            Budgets            `xml:"budget"`
+	BillTerms          BillTerms          `xml:"GncBillTerm"`
+	Customers          Customers          `xml:"GncCustomer"`
+	Entries            Entries            `xml:"GncEntry"`
+	Invoices           Invoices           `xml:"GncInvoice"`
+	Jobs               Jobs               `xml:"GncJob"`
+	TaxTables          TaxTables          `xml:"GncTaxTable"`
+	Vendors            Vendors            `xml:"GncVendor"`
 	Commodities        Commodities        `xml:"commodity"`
 	Prices             Prices             `xml:"pricedb>price"`
+	PriceIndex         *PriceIndex        `xml:"-"`
 	Slots              Slots              `xml:"slots>slot"`
+	Extra              Nodes              `xml:",any"`
+
+	transactions map[GUID]*Transaction
 }
 
 func (b *Book) String() string {
@@ -38,18 +52,91 @@ func (b *Book) validate() error {
 	b.RootAccount = b.Accounts.root()
 	b.AccountsLookup = b.Accounts.lookup()
 	b.TransactionsLookup = b.Transactions.lookup()
+	b.transactions = make(map[GUID]*Transaction, len(b.Transactions))
+	for _, t := range b.Transactions {
+		b.transactions[t.ID] = t
+	}
+	b.PriceIndex = b.Prices.Index()
 
 	if err := b.Accounts.validate(b.AccountsLookup, b.TransactionsLookup); err != nil {
 		return err
 	}
 
-	if err := b.Transactions.validate(b.AccountsLookup, b.Prices); err != nil {
+	if err := b.Transactions.validate(b.AccountsLookup); err != nil {
+		return err
+	}
+
+	if err := b.Templates.validate(); err != nil {
+		return err
+	}
+
+	if err := b.Scheduled.validate(b.AccountsLookup, &b.Templates); err != nil {
 		return err
 	}
 
-	if err := b.Scheduled.validate(b.AccountsLookup); err != nil {
+	if err := b.Budgets.validate(b.AccountsLookup, b.Feature(FeatureNaturalBudgetSigns)); err != nil {
 		return err
 	}
 
+	if err := b.linkBusiness(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Feature reports whether the book uses the given GnuCash feature, features
+// are listed in the book's "features" slot frame.
+func (b *Book) Feature(name string) bool {
+	for _, s := range b.Slots {
+		if s.Key != "features" || s.RawValue.Type != "frame" {
+			continue
+		}
+		for _, f := range s.RawValue.Slots {
+			if f.Key == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Transaction returns the transaction with the given id.
+func (b *Book) Transaction(id GUID) (*Transaction, bool) {
+	t, ok := b.transactions[id]
+	return t, ok
+}
+
+// AddTransaction validates a balanced transaction and links it into the book
+// and its lookups.
+func (b *Book) AddTransaction(t *Transaction) error {
+	if len(t.Splits) == 0 {
+		return errors.New("transaction has no splits")
+	}
+
+	if imb := t.Imbalance(); !imb.IsZero() {
+		return fmt.Errorf("transaction '%s' is unbalanced by %.2f", t.Description, imb.Float64())
+	}
+
+	for _, s := range t.Splits {
+		if _, ok := b.AccountsLookup.ByGUID(s.AccountID); !ok {
+			return fmt.Errorf("no such account '%s'", s.AccountID)
+		}
+	}
+
+	if err := t.validate(b.AccountsLookup); err != nil {
+		return err
+	}
+
+	b.Transactions = append(b.Transactions, t)
+	b.transactions[t.ID] = t
+	for _, id := range t.accountIDs() {
+		b.TransactionsLookup[id] = append(b.TransactionsLookup[id], t)
+		if a, ok := b.AccountsLookup.ByGUID(id); ok {
+			a.Transactions = append(a.Transactions, t)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,245 @@
+package gnucash
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeatureNaturalBudgetSigns is the book feature GnuCash sets when budget
+// amounts are stored with the sign of the account's balance instead of the
+// sign they are displayed with.
+const FeatureNaturalBudgetSigns = "Use natural signs in budget amounts"
+
+// Budget holds per account, per period amounts. GnuCash stores them in the
+// budget's slots as a frame per account guid keyed by period number, Amounts
+// is the parsed view of those slots.
+type Budget struct {
+	ID          GUID       `xml:"id"`
+	Name        string     `xml:"name"`
+	Description string     `xml:"description"`
+	NumPeriods  int        `xml:"num-periods"`
+	Recurrence  Recurrence `xml:"recurrence"`
+	Slots       Slots      `xml:"slots>slot"`
+	Extra       Nodes      `xml:",any"`
+
+	Accounts Accounts               `xml:"-"`
+	Amounts  map[GUID]map[int]Value `xml:"-"`
+
+	// NaturalSigns is set when Amounts of accounts that normally carry a
+	// credit balance are negative, see FeatureNaturalBudgetSigns.
+	NaturalSigns bool `xml:"-"`
+}
+
+func (bg *Budget) String() string {
+	return fmt.Sprintf(
+		"BUDGET\n%s\nID: %s\nPeriods: %d, %s",
+		bg.Name,
+		bg.ID,
+		bg.NumPeriods,
+		bg.Recurrence.String(),
+	)
+}
+
+// Period returns the start and (exclusive) end of the n'th period.
+func (bg *Budget) Period(n int) (start, end time.Time) {
+	start, _ = bg.Recurrence.nth(n)
+	end, _ = bg.Recurrence.nth(n + 1)
+	return
+}
+
+// PeriodAt returns the period the given time falls in.
+func (bg *Budget) PeriodAt(t time.Time) (int, bool) {
+	for n := 0; n < bg.NumPeriods; n++ {
+		start, end := bg.Period(n)
+		if !t.Before(start) && t.Before(end) {
+			return n, true
+		}
+	}
+
+	return 0, false
+}
+
+// Amount returns the budgeted amount for an account in the given period.
+func (bg *Budget) Amount(accountID GUID, period int) (Value, bool) {
+	v, ok := bg.Amounts[accountID][period]
+	return v, ok
+}
+
+// BudgetLine compares the budgeted amount of an account in a period with
+// the actual amount. Amounts of accounts that normally carry a credit
+// balance (e.g.: income) are negated so both are positive.
+type BudgetLine struct {
+	Account  *Account
+	Period   int
+	Start    time.Time
+	End      time.Time
+	Budgeted Value
+	Actual   Value
+}
+
+// Variance is the budgeted amount left.
+func (l BudgetLine) Variance() Value {
+	return l.Budgeted.Sub(l.Actual)
+}
+
+// Used returns the percentage of the budget that was used.
+func (l BudgetLine) Used() (float64, bool) {
+	if l.Budgeted.IsZero() {
+		return math.NaN(), false
+	}
+
+	return l.Actual.Float64() / l.Budgeted.Float64() * 100, true
+}
+
+type BudgetLines []BudgetLine
+
+// Total sums the lines per account, keeping the first occurence's order.
+func (ls BudgetLines) Total() BudgetLines {
+	index := make(map[*Account]int)
+	l := make(BudgetLines, 0)
+	for _, line := range ls {
+		i, ok := index[line.Account]
+		if !ok {
+			index[line.Account] = len(l)
+			line.Period = -1
+			l = append(l, line)
+			continue
+		}
+
+		t := &l[i]
+		if line.Start.Before(t.Start) {
+			t.Start = line.Start
+		}
+		if line.End.After(t.End) {
+			t.End = line.End
+		}
+		t.Budgeted = t.Budgeted.Add(line.Budgeted)
+		t.Actual = t.Actual.Add(line.Actual)
+	}
+
+	return l
+}
+
+// Compare returns a line per budgeted account (matching include and not
+// exclude) and period, with the actual amount calculated from the splits
+// of ts posted within the period, including those of child accounts.
+// period < 0 compares all periods.
+func (bg *Budget) Compare(ts Transactions, period int, include, exclude *regexp.Regexp) BudgetLines {
+	periods := make([]int, 0, bg.NumPeriods)
+	for n := 0; n < bg.NumPeriods; n++ {
+		if period < 0 || n == period {
+			periods = append(periods, n)
+		}
+	}
+
+	byPeriod := make(map[int]Transactions, len(periods))
+	for _, t := range ts {
+		if n, ok := bg.PeriodAt(t.DatePosted.Get()); ok {
+			byPeriod[n] = append(byPeriod[n], t)
+		}
+	}
+
+	l := make(BudgetLines, 0, len(bg.Accounts)*len(periods))
+	for _, a := range bg.Accounts {
+		if !matchFQN(include, exclude, a.FQN) {
+			continue
+		}
+
+		for _, n := range periods {
+			start, end := bg.Period(n)
+			line := BudgetLine{Account: a, Period: n, Start: start, End: end}
+			line.Budgeted, _ = bg.Amount(a.ID, n)
+			line.Actual = byPeriod[n].ValueForAccount(a.ID, true)
+			if a.Type.CreditNormal() {
+				line.Actual = line.Actual.Neg()
+				if bg.NaturalSigns {
+					line.Budgeted = line.Budgeted.Neg()
+				}
+			}
+			l = append(l, line)
+		}
+	}
+
+	return l
+}
+
+func (bg *Budget) validate(lookup *AccountsLookup, naturalSigns bool) error {
+	if bg.ID == "" {
+		return errors.New("Empty budget id")
+	}
+
+	bg.NaturalSigns = naturalSigns
+
+	if err := bg.Recurrence.validate(); err != nil {
+		return fmt.Errorf("budget '%s': %w", bg.Name, err)
+	}
+
+	bg.Accounts = make(Accounts, 0)
+	bg.Amounts = make(map[GUID]map[int]Value)
+	for _, s := range bg.Slots {
+		a, ok := lookup.ByGUID(GUID(s.Key))
+		if !ok || s.RawValue.Type != "frame" {
+			continue
+		}
+
+		amounts := make(map[int]Value)
+		for _, p := range s.RawValue.Slots {
+			n, err := strconv.Atoi(p.Key)
+			if err != nil {
+				continue
+			}
+			v, err := ParseValue(p.RawValue.Value)
+			if err != nil {
+				return fmt.Errorf("budget '%s': %w", bg.Name, err)
+			}
+			amounts[n] = v
+		}
+
+		bg.Accounts = append(bg.Accounts, a)
+		bg.Amounts[a.ID] = amounts
+	}
+
+	sort.SliceStable(bg.Accounts, func(i, j int) bool {
+		return strings.Compare(bg.Accounts[i].FQN, bg.Accounts[j].FQN) < 0
+	})
+
+	return nil
+}
+
+type Budgets []*Budget
+
+func (bs Budgets) String() string {
+	str := make([]string, len(bs))
+	for i, b := range bs {
+		str[i] = b.String()
+	}
+
+	return strings.Join(str, "\n")
+}
+
+// ByName returns the budget with the given name.
+func (bs Budgets) ByName(name string) (*Budget, bool) {
+	for _, b := range bs {
+		if b.Name == name {
+			return b, true
+		}
+	}
+
+	return nil, false
+}
+
+func (bs Budgets) validate(lookup *AccountsLookup, naturalSigns bool) error {
+	for _, b := range bs {
+		if err := b.validate(lookup, naturalSigns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package gnucash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func budgetBook(t *testing.T, natural bool) *Book {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	account := func(id string, typ AccountType) *Account {
+		parent := GUID("root")
+		if typ == AccountTypeRoot {
+			parent = ""
+		}
+		return &Account{ID: GUID(id), Name: id, ParentID: parent, Type: typ, Commodity: eur, SCU: 100}
+	}
+	tx := func(id string, d int, from, to string, v int64) *Transaction {
+		return &Transaction{
+			ID:         GUID(id),
+			Currency:   eur,
+			DatePosted: NewDate(time.Date(2024, 1, d, 10, 59, 0, 0, time.UTC)),
+			Splits: Splits{
+				{ID: GUID(id + "-to"), AccountID: GUID(to), ReconciledState: ReconciledStateNew, Value: NewValue(v, 1), Quantity: NewValue(v, 1)},
+				{ID: GUID(id + "-from"), AccountID: GUID(from), ReconciledState: ReconciledStateNew, Value: NewValue(-v, 1), Quantity: NewValue(-v, 1)},
+			},
+		}
+	}
+	amounts := func(v ...string) Slot {
+		s := Slot{RawValue: SlotValue{Type: "frame"}}
+		for i, v := range v {
+			s.RawValue.Slots = append(s.RawValue.Slots, Slot{Key: fmt.Sprint(i), RawValue: SlotValue{Type: "numeric", Value: v}})
+		}
+		return s
+	}
+
+	salary := "3000/1"
+	if natural {
+		salary = "-3000/1"
+	}
+	income, expense := amounts(salary, salary), amounts("400/1", "400/1")
+	income.Key, expense.Key = "salary", "groceries"
+
+	b := &Book{
+		ID:          "book",
+		Commodities: Commodities{{CommodityRef: eur}},
+		Accounts: Accounts{
+			account("root", AccountTypeRoot),
+			account("bank", AccountTypeBank),
+			account("salary", AccountTypeIncome),
+			account("groceries", AccountTypeExpense),
+		},
+		Transactions: Transactions{
+			tx("salary", 25, "salary", "bank", 2900),
+			tx("groceries", 10, "bank", "groceries", 500),
+		},
+		Budgets: Budgets{{
+			ID:         "budget",
+			Name:       "2024",
+			NumPeriods: 2,
+			Recurrence: Recurrence{1, PeriodMonth, NewDate(date(2024, 1, 1)), WeekendAdjustNone},
+			Slots:      Slots{income, expense},
+		}},
+	}
+	if natural {
+		b.Slots = Slots{{
+			Key: "features",
+			RawValue: SlotValue{Type: "frame", Slots: Slots{{
+				Key:      FeatureNaturalBudgetSigns,
+				RawValue: SlotValue{Type: "string", Value: "Use natural signs"},
+			}}},
+		}}
+	}
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestBudgetCompare(t *testing.T) {
+	for _, natural := range []bool{false, true} {
+		b := budgetBook(t, natural)
+		lines := b.Budgets[0].Compare(b.Transactions, 0, nil, nil)
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines got %d", len(lines))
+		}
+
+		// sorted by fqn.
+		exp := []struct {
+			account                      string
+			budgeted, actual, difference string
+			used                         string
+		}{
+			{"groceries", "400.00", "500.00", "-100.00", "125.0"},
+			{"salary", "3000.00", "2900.00", "100.00", "96.7"},
+		}
+		for i, e := range exp {
+			l := lines[i]
+			used, _ := l.Used()
+			got := []string{
+				string(l.Account.ID),
+				fmt.Sprintf("%.2f", l.Budgeted.Float64()),
+				fmt.Sprintf("%.2f", l.Actual.Float64()),
+				fmt.Sprintf("%.2f", l.Variance().Float64()),
+				fmt.Sprintf("%.1f", used),
+			}
+			want := []string{e.account, e.budgeted, e.actual, e.difference, e.used}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("natural signs %t: expected %v got %v", natural, want, got)
+			}
+		}
+
+		lines = b.Budgets[0].Compare(b.Transactions, -1, nil, nil).Total()
+		if len(lines) != 2 || lines[1].Budgeted.Float64() != 6000 || lines[1].Actual.Float64() != 2900 {
+			t.Errorf("natural signs %t: unexpected totals %+v", natural, lines)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package gnucash
+
+import (
+	nxml "encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OwnerType string
+
+const (
+	OwnerCustomer OwnerType = "gncCustomer"
+	OwnerVendor             = "gncVendor"
+	OwnerEmployee           = "gncEmployee"
+	OwnerJob                = "gncJob"
+)
+
+// ownerTypes maps the numeric owner types GnuCash uses in slots and the sql
+// backend.
+var ownerTypes = map[int]OwnerType{
+	2: OwnerCustomer,
+	3: OwnerJob,
+	4: OwnerVendor,
+	5: OwnerEmployee,
+}
+
+// Flag is a boolean stored as 1 or 0.
+type Flag bool
+
+func (f *Flag) UnmarshalXML(d *nxml.Decoder, start nxml.StartElement) error {
+	var content string
+	if err := d.DecodeElement(&content, &start); err != nil {
+		return err
+	}
+
+	*f = strings.TrimSpace(content) == "1"
+	return nil
+}
+
+type Owner struct {
+	Type OwnerType `xml:"type"`
+	ID   GUID      `xml:"id"`
+}
+
+type Address struct {
+	Name  string `xml:"name"`
+	Addr1 string `xml:"addr1"`
+	Addr2 string `xml:"addr2"`
+	Addr3 string `xml:"addr3"`
+	Addr4 string `xml:"addr4"`
+	Phone string `xml:"phone"`
+	Fax   string `xml:"fax"`
+	Email string `xml:"email"`
+	Extra Nodes  `xml:",any"`
+}
+
+type Customer struct {
+	GUID        GUID         `xml:"guid"`
+	Name        string       `xml:"name"`
+	ID          string       `xml:"id"`
+	Addr        Address      `xml:"addr"`
+	ShipAddr    Address      `xml:"shipaddr"`
+	Notes       string       `xml:"notes"`
+	TermsID     GUID         `xml:"terms"`
+	TaxIncluded string       `xml:"taxincluded"`
+	Active      Flag         `xml:"active"`
+	Discount    Value        `xml:"discount"`
+	Credit      Value        `xml:"credit"`
+	Currency    CommodityRef `xml:"currency"`
+	UseTaxTable Flag         `xml:"use-tt"`
+	TaxTableID  GUID         `xml:"taxtable"`
+	Slots       Slots        `xml:"slots>slot"`
+	Extra       Nodes        `xml:",any"`
+
+	Terms    *BillTerm `xml:"-"`
+	TaxTable *TaxTable `xml:"-"`
+	Invoices Invoices  `xml:"-"`
+}
+
+type Vendor struct {
+	GUID        GUID         `xml:"guid"`
+	Name        string       `xml:"name"`
+	ID          string       `xml:"id"`
+	Addr        Address      `xml:"addr"`
+	Notes       string       `xml:"notes"`
+	TermsID     GUID         `xml:"terms"`
+	TaxIncluded string       `xml:"taxincluded"`
+	Active      Flag         `xml:"active"`
+	Currency    CommodityRef `xml:"currency"`
+	UseTaxTable Flag         `xml:"use-tt"`
+	TaxTableID  GUID         `xml:"taxtable"`
+	Slots       Slots        `xml:"slots>slot"`
+	Extra       Nodes        `xml:",any"`
+
+	Terms    *BillTerm `xml:"-"`
+	TaxTable *TaxTable `xml:"-"`
+	Invoices Invoices  `xml:"-"`
+}
+
+type Job struct {
+	GUID      GUID   `xml:"guid"`
+	ID        string `xml:"id"`
+	Name      string `xml:"name"`
+	Reference string `xml:"reference"`
+	Owner     Owner  `xml:"owner"`
+	Active    Flag   `xml:"active"`
+	Slots     Slots  `xml:"slots>slot"`
+	Extra     Nodes  `xml:",any"`
+}
+
+type TaxTableEntry struct {
+	AccountID GUID   `xml:"acct"`
+	Amount    Value  `xml:"amount"`
+	Type      string `xml:"type"`
+	Extra     Nodes  `xml:",any"`
+
+	Account *Account `xml:"-"`
+}
+
+type TaxTable struct {
+	GUID      GUID            `xml:"guid"`
+	Name      string          `xml:"name"`
+	RefCount  int             `xml:"refcount"`
+	Invisible Flag            `xml:"invisible"`
+	Entries   []TaxTableEntry `xml:"entries>GncTaxTableEntry"`
+	Extra     Nodes           `xml:",any"`
+}
+
+type BillTermDays struct {
+	DueDays      int   `xml:"due-days"`
+	DiscountDays int   `xml:"disc-days"`
+	Discount     Value `xml:"discount"`
+}
+
+type BillTermProximo struct {
+	DueDay      int   `xml:"due-day"`
+	DiscountDay int   `xml:"disc-day"`
+	Discount    Value `xml:"discount"`
+	CutoffDay   int   `xml:"cutoff-day"`
+}
+
+type BillTerm struct {
+	GUID        GUID             `xml:"guid"`
+	Name        string           `xml:"name"`
+	Description string           `xml:"desc"`
+	RefCount    int              `xml:"refcount"`
+	Invisible   Flag             `xml:"invisible"`
+	Days        *BillTermDays    `xml:"days"`
+	Proximo     *BillTermProximo `xml:"proximo"`
+	Extra       Nodes            `xml:",any"`
+}
+
+// DueDate calculates the due date of a document posted at the given time.
+func (bt *BillTerm) DueDate(posted time.Time) time.Time {
+	switch {
+	case bt.Days != nil:
+		return posted.AddDate(0, 0, bt.Days.DueDays)
+	case bt.Proximo != nil:
+		y, m, d := posted.Date()
+		cutoff := bt.Proximo.CutoffDay
+		if cutoff <= 0 {
+			cutoff += daysIn(y, m)
+		}
+		if d <= cutoff {
+			m++
+		} else {
+			m += 2
+		}
+		first := time.Date(y, m, 1, 0, 0, 0, 0, posted.Location())
+		due := bt.Proximo.DueDay
+		if dim := daysIn(first.Year(), first.Month()); due > dim {
+			due = dim
+		}
+		return first.AddDate(0, 0, due-1)
+	}
+
+	return posted
+}
+
+type Entry struct {
+	GUID          GUID   `xml:"guid"`
+	Date          Date   `xml:"date>date"`
+	Entered       Date   `xml:"entered>date"`
+	Description   string `xml:"description"`
+	Action        string `xml:"action"`
+	Notes         string `xml:"notes"`
+	Quantity      Value  `xml:"qty"`
+	InvAccountID  GUID   `xml:"i-acct"`
+	InvPrice      Value  `xml:"i-price"`
+	InvoiceID     GUID   `xml:"invoice"`
+	BillAccountID GUID   `xml:"b-acct"`
+	BillPrice     Value  `xml:"b-price"`
+	BillID        GUID   `xml:"bill"`
+	Slots         Slots  `xml:"slots>slot"`
+	Extra         Nodes  `xml:",any"`
+
+	Invoice *Invoice `xml:"-"`
+	Account *Account `xml:"-"`
+}
+
+// Amount is the quantity times the price, before discounts and taxes.
+func (en *Entry) Amount() Value {
+	if en.BillID != "" {
+		return en.Quantity.Mul(en.BillPrice).Reduce()
+	}
+
+	return en.Quantity.Mul(en.InvPrice).Reduce()
+}
+
+type Customers []*Customer
+type Vendors []*Vendor
+type Jobs []*Job
+type TaxTables []*TaxTable
+type BillTerms []*BillTerm
+type Entries []*Entry
+
+// business links the business objects to each other and to the accounts,
+// transactions and lots they were posted to.
+type business struct {
+	customers map[GUID]*Customer
+	vendors   map[GUID]*Vendor
+	jobs      map[GUID]*Job
+	taxTables map[GUID]*TaxTable
+	billTerms map[GUID]*BillTerm
+	invoices  map[GUID]*Invoice
+}
+
+func (b *Book) linkBusiness() error {
+	l := business{
+		customers: make(map[GUID]*Customer, len(b.Customers)),
+		vendors:   make(map[GUID]*Vendor, len(b.Vendors)),
+		jobs:      make(map[GUID]*Job, len(b.Jobs)),
+		taxTables: make(map[GUID]*TaxTable, len(b.TaxTables)),
+		billTerms: make(map[GUID]*BillTerm, len(b.BillTerms)),
+		invoices:  make(map[GUID]*Invoice, len(b.Invoices)),
+	}
+
+	for _, bt := range b.BillTerms {
+		l.billTerms[bt.GUID] = bt
+	}
+	for _, tt := range b.TaxTables {
+		l.taxTables[tt.GUID] = tt
+		for i := range tt.Entries {
+			tt.Entries[i].Account, _ = b.AccountsLookup.ByGUID(tt.Entries[i].AccountID)
+		}
+	}
+	for _, c := range b.Customers {
+		l.customers[c.GUID] = c
+		c.Terms, c.TaxTable = l.billTerms[c.TermsID], l.taxTables[c.TaxTableID]
+		c.Invoices = make(Invoices, 0)
+	}
+	for _, v := range b.Vendors {
+		l.vendors[v.GUID] = v
+		v.Terms, v.TaxTable = l.billTerms[v.TermsID], l.taxTables[v.TaxTableID]
+		v.Invoices = make(Invoices, 0)
+	}
+	for _, j := range b.Jobs {
+		l.jobs[j.GUID] = j
+	}
+
+	for _, inv := range b.Invoices {
+		if err := inv.link(b, l); err != nil {
+			return err
+		}
+		l.invoices[inv.GUID] = inv
+	}
+
+	for _, en := range b.Entries {
+		id, acc := en.InvoiceID, en.InvAccountID
+		if en.BillID != "" {
+			id, acc = en.BillID, en.BillAccountID
+		}
+		en.Account, _ = b.AccountsLookup.ByGUID(acc)
+		if id == "" {
+			continue
+		}
+		inv, ok := l.invoices[id]
+		if !ok {
+			return fmt.Errorf("entry '%s' references unknown invoice '%s'", en.GUID, id)
+		}
+		en.Invoice = inv
+		inv.Entries = append(inv.Entries, en)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package gnucash
+
+import (
+	nxml "encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// camtDate parses an ISO 20022 date choice, i.e.: a Dt or DtTm element.
+func camtDate(n Node, path ...string) (time.Time, error) {
+	c, ok := n.child(path...)
+	if !ok {
+		return time.Time{}, nil
+	}
+	if d := c.text("Dt"); d != "" {
+		return time.Parse("2006-01-02", d)
+	}
+	if d := c.text("DtTm"); len(d) >= 10 {
+		return time.Parse("2006-01-02", d[:10])
+	}
+
+	return time.Time{}, fmt.Errorf("invalid camt date in %s", strings.Join(path, "/"))
+}
+
+// camtAmount parses an amount with its credit / debit indicator.
+func camtAmount(amt, indicator string) (Value, error) {
+	v, err := ParseDecimal(amt)
+	if err != nil {
+		return v, err
+	}
+	switch indicator {
+	case "CRDT":
+	case "DBIT":
+		v = v.Neg()
+	default:
+		return v, fmt.Errorf("invalid camt credit / debit indicator '%s'", indicator)
+	}
+
+	return v, nil
+}
+
+// camtParty returns the name of a party, which is nested in a Pty element
+// since version 8.
+func camtParty(n Node, name string) string {
+	if nm := n.text(name, "Nm"); nm != "" {
+		return nm
+	}
+
+	return n.text(name, "Pty", "Nm")
+}
+
+func camtAccount(n Node, name string) string {
+	if iban := n.text(name, "Id", "IBAN"); iban != "" {
+		return iban
+	}
+
+	return n.text(name, "Id", "Othr", "Id")
+}
+
+// camtDetails fills in the counterparty, remittance information and
+// references of a line from a TxDtls element.
+func camtDetails(l *BankLine, tx Node) {
+	if ref := tx.text("Refs", "EndToEndId"); ref != "NOTPROVIDED" {
+		l.EndToEndID = ref
+	}
+	if ref := tx.text("Refs", "AcctSvcrRef"); ref != "" {
+		l.ID = ref
+	}
+
+	party, account := "Cdtr", "CdtrAcct"
+	if l.Amount.Sign() > 0 {
+		party, account = "Dbtr", "DbtrAcct"
+	}
+	if parties, ok := tx.child("RltdPties"); ok {
+		if nm := camtParty(parties, party); nm != "" {
+			l.Description = nm
+		}
+		l.IBAN = camtAccount(parties, account)
+	}
+
+	if rmt, ok := tx.child("RmtInf"); ok {
+		info := make([]string, 0, 1)
+		for _, u := range rmt.Children.Filter("Ustrd") {
+			info = append(info, strings.TrimSpace(u.Text))
+		}
+		for _, s := range rmt.Children.Filter("Strd") {
+			if ref := s.text("CdtrRefInf", "Ref"); ref != "" {
+				info = append(info, ref)
+			}
+		}
+		l.Memo = strings.Join(info, " ")
+	}
+}
+
+// ParseCAMT053 parses the statements of an ISO 20022 CAMT.053 (bank to
+// customer statement) document. Entries that are not booked are skipped,
+// batch entries with amounts per transaction yield a line per
+// transaction.
+func ParseCAMT053(r io.Reader) ([]BankStatement, error) {
+	var doc Node
+	if err := nxml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	msg, ok := doc.child("BkToCstmrStmt")
+	if !ok {
+		return nil, errors.New("not a camt.053 document")
+	}
+
+	stmts := make([]BankStatement, 0, 1)
+	for _, stmt := range msg.Children.Filter("Stmt") {
+		st := BankStatement{
+			Account:  camtAccount(stmt, "Acct"),
+			Currency: stmt.text("Acct", "Ccy"),
+			Lines:    make([]BankLine, 0),
+		}
+
+		for _, bal := range stmt.Children.Filter("Bal") {
+			if bal.text("Tp", "CdOrPrtry", "Cd") != "CLBD" {
+				continue
+			}
+			amt, _ := bal.child("Amt")
+			var err error
+			if st.Balance, err = camtAmount(strings.TrimSpace(amt.Text), bal.text("CdtDbtInd")); err != nil {
+				return nil, err
+			}
+			if st.BalanceDate, err = camtDate(bal, "Dt"); err != nil {
+				return nil, err
+			}
+			if st.Currency == "" {
+				st.Currency = amt.attr("Ccy")
+			}
+		}
+
+		for _, ntry := range stmt.Children.Filter("Ntry") {
+			status := ntry.text("Sts")
+			if status == "" {
+				status = ntry.text("Sts", "Cd")
+			}
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			amt, _ := ntry.child("Amt")
+			base := BankLine{
+				Description: ntry.text("AddtlNtryInf"),
+				ID:          ntry.text("AcctSvcrRef"),
+			}
+			var err error
+			if base.Amount, err = camtAmount(strings.TrimSpace(amt.Text), ntry.text("CdtDbtInd")); err != nil {
+				return nil, err
+			}
+			if base.Date, err = camtDate(ntry, "BookgDt"); err != nil {
+				return nil, err
+			}
+			if base.ValueDate, err = camtDate(ntry, "ValDt"); err != nil {
+				return nil, err
+			}
+			if base.Date.IsZero() {
+				base.Date = base.ValueDate
+			}
+
+			txs := make(Nodes, 0)
+			for _, d := range ntry.Children.Filter("NtryDtls") {
+				txs = append(txs, d.Children.Filter("TxDtls")...)
+			}
+
+			split := len(txs) > 1
+			for _, tx := range txs {
+				if tx.text("AmtDtls", "TxAmt", "Amt") == "" && tx.text("Amt") == "" {
+					split = false
+				}
+			}
+
+			if !split {
+				if len(txs) != 0 {
+					camtDetails(&base, txs[0])
+				}
+				st.Lines = append(st.Lines, base)
+				continue
+			}
+
+			for _, tx := range txs {
+				l := base
+				l.ID = ""
+				a := tx.text("AmtDtls", "TxAmt", "Amt")
+				if a == "" {
+					a = tx.text("Amt")
+				}
+				ind := tx.text("CdtDbtInd")
+				if ind == "" {
+					ind = ntry.text("CdtDbtInd")
+				}
+				if l.Amount, err = camtAmount(a, ind); err != nil {
+					return nil, err
+				}
+				camtDetails(&l, tx)
+				st.Lines = append(st.Lines, l)
+			}
+		}
+
+		stmts = append(stmts, st)
+	}
+
+	return stmts, nil
+}
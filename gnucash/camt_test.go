@@ -0,0 +1,107 @@
+package gnucash
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const camt053 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Acct><Id><IBAN>NL91ABNA0417164300</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">987.50</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Dt><Dt>2024-01-31</Dt></Dt>
+      </Bal>
+      <Ntry>
+        <Amt Ccy="EUR">12.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2024-01-05</Dt></BookgDt>
+        <ValDt><Dt>2024-01-04</Dt></ValDt>
+        <AcctSvcrRef>REF1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E1</EndToEndId></Refs>
+          <RltdPties>
+            <Cdtr><Nm>Bakery</Nm></Cdtr>
+            <CdtrAcct><Id><IBAN>NL20INGB0001234567</IBAN></Id></CdtrAcct>
+          </RltdPties>
+          <RmtInf><Ustrd>bread</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">5.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>PDNG</Sts>
+        <BookgDt><Dt>2024-01-06</Dt></BookgDt>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">300.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2024-01-10T08:00:00</DtTm></BookgDt>
+        <AddtlNtryInf>batch</AddtlNtryInf>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">100.00</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Pty><Nm>Alice</Nm></Pty></Dbtr></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">200.00</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Nm>Bob</Nm></Dbtr></RltdPties>
+            <RmtInf><Strd><CdtrRefInf><Ref>RF18</Ref></CdtrRefInf></Strd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>
+`
+
+func TestParseCAMT053(t *testing.T) {
+	stmts, err := ParseCAMT053(strings.NewReader(camt053))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 {
+		t.Fatalf("expected 1 statement got %d", len(stmts))
+	}
+
+	st := stmts[0]
+	if st.Account != "NL91ABNA0417164300" || st.Currency != "EUR" {
+		t.Errorf("unexpected account %s %s", st.Account, st.Currency)
+	}
+	if bal := fmt.Sprintf("%.2f %s", st.Balance.Float64(), st.BalanceDate.Format("2006-01-02")); bal != "987.50 2024-01-31" {
+		t.Errorf("unexpected balance %s", bal)
+	}
+
+	exp := []string{
+		"2024-01-05 2024-01-04 -12.50 REF1 E2E1 Bakery NL20INGB0001234567 bread",
+		"2024-01-10 0001-01-01 100.00   Alice  ",
+		"2024-01-10 0001-01-01 200.00   Bob  RF18",
+	}
+	if len(st.Lines) != len(exp) {
+		t.Fatalf("expected %d lines got %d", len(exp), len(st.Lines))
+	}
+	for i, l := range st.Lines {
+		str := fmt.Sprintf(
+			"%s %s %.2f %s %s %s %s %s",
+			l.Date.Format("2006-01-02"),
+			l.ValueDate.Format("2006-01-02"),
+			l.Amount.Float64(),
+			l.ID,
+			l.EndToEndID,
+			l.Description,
+			l.IBAN,
+			l.Memo,
+		)
+		if str != exp[i] {
+			t.Errorf("expected '%s' got '%s'", exp[i], str)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package gnucash
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity of a Problem. Errors break double-entry bookkeeping or the
+// structure of the book, warnings point at entries that need attention.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// The checks performed by Book.Check.
+const (
+	CheckUnbalanced         = "unbalanced"
+	CheckUnknownAccount     = "unknown-account"
+	CheckImbalanceAccount   = "imbalance-account"
+	CheckOrphanAccount      = "orphan-account"
+	CheckPlaceholder        = "placeholder"
+	CheckCommodity          = "commodity-mismatch"
+	CheckDanglingParent     = "dangling-parent"
+	CheckCycle              = "cycle"
+	CheckUnknownCommodity   = "unknown-commodity"
+	CheckUnknownLot         = "unknown-lot"
+	CheckUnknownTransaction = "unknown-transaction"
+)
+
+// Problem is an inconsistency found by Book.Check in the account,
+// transaction, split, price or invoice with the given ID.
+type Problem struct {
+	Severity Severity
+	Check    string
+	Object   string
+	ID       GUID
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s %s: %s", p.Severity, p.Check, p.Object, p.ID, p.Message)
+}
+
+type Problems []Problem
+
+// Count returns the number of problems of the given severity.
+func (ps Problems) Count(s Severity) int {
+	n := 0
+	for _, p := range ps {
+		if p.Severity == s {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Check verifies the integrity of the book: the account tree, whether
+// transactions balance and their splits match their accounts, whether
+// prices refer to known commodities and whether splits and invoices refer to
+// known lots, transactions and accounts.
+func (b *Book) Check() Problems {
+	ps := make(Problems, 0)
+	add := func(s Severity, check, object string, id GUID, format string, args ...interface{}) {
+		ps = append(ps, Problem{s, check, object, id, fmt.Sprintf(format, args...)})
+	}
+
+	commodities := b.Commodities.Lookup()
+	ps = append(ps, b.checkAccounts(commodities)...)
+
+	for _, t := range b.Transactions {
+		if imb := t.Imbalance(); !imb.IsZero() {
+			add(
+				SeverityError, CheckUnbalanced, "transaction", t.ID,
+				"'%s' on %s is unbalanced by %s %s",
+				t.Description,
+				t.DatePosted.Get().Format("2006-01-02"),
+				decimal(imb),
+				t.Currency.ID,
+			)
+		}
+
+		for _, s := range t.Splits {
+			if s.LotID != "" && s.Lot == nil {
+				add(
+					SeverityError, CheckUnknownLot, "split", s.ID,
+					"'%s' refers to unknown lot '%s'",
+					t.Description,
+					s.LotID,
+				)
+			}
+
+			a := s.Account
+			if a == nil {
+				add(
+					SeverityError, CheckUnknownAccount, "split", s.ID,
+					"'%s' refers to unknown account '%s'",
+					t.Description,
+					s.AccountID,
+				)
+				continue
+			}
+
+			switch {
+			case strings.HasPrefix(a.Name, "Imbalance-"):
+				add(SeverityWarning, CheckImbalanceAccount, "split", s.ID, "'%s' uses %s", t.Description, a.FQN)
+			case strings.HasPrefix(a.Name, "Orphan-"):
+				add(SeverityWarning, CheckOrphanAccount, "split", s.ID, "'%s' uses %s", t.Description, a.FQN)
+			}
+
+			if a.Placeholder() {
+				add(SeverityWarning, CheckPlaceholder, "split", s.ID, "'%s' uses placeholder %s", t.Description, a.FQN)
+			}
+
+			if s.ReconciledState == ReconciledStateVoid {
+				continue
+			}
+			switch {
+			case a.Commodity == t.Currency && !s.Quantity.Equal(s.Value):
+				add(
+					SeverityError, CheckCommodity, "split", s.ID,
+					"'%s': quantity %s differs from value %s in %s account %s",
+					t.Description,
+					decimal(s.Quantity),
+					decimal(s.Value),
+					a.Commodity.ID,
+					a.FQN,
+				)
+			case s.Quantity.Sign()*s.Value.Sign() < 0:
+				add(
+					SeverityWarning, CheckCommodity, "split", s.ID,
+					"'%s': quantity %s and value %s have opposite signs in %s",
+					t.Description,
+					decimal(s.Quantity),
+					decimal(s.Value),
+					a.FQN,
+				)
+			case a.SCU > 0 && !s.Quantity.Convert(int64(a.SCU), RoundBankers).Equal(s.Quantity):
+				add(
+					SeverityWarning, CheckCommodity, "split", s.ID,
+					"'%s': quantity %s is finer than 1/%d %s of %s",
+					t.Description,
+					decimal(s.Quantity),
+					a.SCU,
+					a.Commodity.ID,
+					a.FQN,
+				)
+			}
+		}
+	}
+
+	for _, p := range b.Prices {
+		for _, c := range []CommodityRef{p.Comodity, p.Currency} {
+			if _, ok := commodities[c.FQN()]; !ok {
+				add(SeverityError, CheckUnknownCommodity, "price", p.ID, "unknown commodity %s", c.FQN())
+			}
+		}
+	}
+
+	for _, inv := range b.Invoices {
+		if inv.PostTransactionID != "" && inv.PostTransaction == nil {
+			add(SeverityError, CheckUnknownTransaction, "invoice", inv.GUID, "'%s' refers to unknown transaction '%s'", inv.ID, inv.PostTransactionID)
+		}
+		if inv.PostLotID != "" && inv.PostLot == nil {
+			add(SeverityError, CheckUnknownLot, "invoice", inv.GUID, "'%s' refers to unknown lot '%s'", inv.ID, inv.PostLotID)
+		}
+		if inv.PostAccountID != "" && inv.PostAccount == nil {
+			add(SeverityError, CheckUnknownAccount, "invoice", inv.GUID, "'%s' refers to unknown account '%s'", inv.ID, inv.PostAccountID)
+		}
+	}
+
+	return ps
+}
+
+// decimal formats v without rounding it to cents.
+func decimal(v Value) string {
+	return strconv.FormatFloat(v.Float64(), 'f', -1, 64)
+}
+
+func (b *Book) checkAccounts(commodities CommoditiesLookup) Problems {
+	ps := make(Problems, 0)
+	byGUID := make(map[GUID]*Account, len(b.Accounts))
+	for _, a := range b.Accounts {
+		byGUID[a.ID] = a
+	}
+
+	reported := make(map[GUID]struct{})
+	for _, a := range b.Accounts {
+		if a.Type == AccountTypeRoot {
+			continue
+		}
+
+		if _, ok := commodities[a.Commodity.FQN()]; !ok {
+			ps = append(ps, Problem{
+				SeverityError, CheckUnknownCommodity, "account", a.ID,
+				fmt.Sprintf("%s is denominated in unknown commodity %s", a.FQN, a.Commodity.FQN()),
+			})
+		}
+
+		if _, ok := byGUID[a.ParentID]; !ok {
+			msg := fmt.Sprintf("%s has no parent", a.FQN)
+			if a.ParentID != "" {
+				msg = fmt.Sprintf("%s refers to unknown parent '%s'", a.FQN, a.ParentID)
+			}
+			ps = append(ps, Problem{SeverityError, CheckDanglingParent, "account", a.ID, msg})
+			continue
+		}
+
+		// walk up the tree, an account is in a cycle if it is its own
+		// ancestor.
+		path := []*Account{a}
+		for p := byGUID[a.ParentID]; p != nil && len(path) <= len(b.Accounts); p = byGUID[p.ParentID] {
+			if p != a {
+				path = append(path, p)
+				continue
+			}
+
+			if _, ok := reported[a.ID]; ok {
+				break
+			}
+			names := make([]string, len(path)+1)
+			for i, c := range path {
+				reported[c.ID] = struct{}{}
+				names[i] = c.Name
+			}
+			names[len(path)] = a.Name
+			ps = append(ps, Problem{
+				SeverityError, CheckCycle, "account", a.ID,
+				fmt.Sprintf("account tree cycle %s", strings.Join(names, " > ")),
+			})
+			break
+		}
+	}
+
+	return ps
+}
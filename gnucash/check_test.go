@@ -0,0 +1,128 @@
+package gnucash
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	aapl := CommodityRef{ID: "AAPL", NS: "NASDAQ"}
+	account := func(id, name, parent string, typ AccountType, c CommodityRef, scu int) *Account {
+		return &Account{ID: GUID(id), Name: name, ParentID: GUID(parent), Type: typ, Commodity: c, SCU: scu}
+	}
+	split := func(id, account string, value, quantity Value) *Split {
+		return &Split{ID: GUID(id), AccountID: GUID(account), Value: value, Quantity: quantity, ReconciledState: ReconciledStateNew}
+	}
+	eur2 := func(n int64) Value { return NewValue(n, 100) }
+
+	placeholder := account("assets", "Assets", "root", AccountTypeAsset, eur, 100)
+	placeholder.Slots.Set("placeholder", SlotValue{Type: "string", Value: "true"})
+	lotless := split("l1", "bank", eur2(-100), eur2(-100))
+	lotless.LotID = "gone"
+
+	b := &Book{
+		ID:          "book",
+		Commodities: Commodities{{CommodityRef: eur}, {CommodityRef: aapl}},
+		Accounts: Accounts{
+			account("root", "Root Account", "", AccountTypeRoot, CommodityRef{}, 0),
+			placeholder,
+			account("bank", "Bank", "assets", AccountTypeBank, eur, 100),
+			account("broker", "Broker", "assets", AccountTypeStock, aapl, 100),
+			account("imbalance", "Imbalance-EUR", "root", AccountTypeBank, eur, 100),
+			account("a", "A", "b", AccountTypeExpense, eur, 100),
+			account("b", "B", "a", AccountTypeExpense, eur, 100),
+			account("lost", "Lost", "gone", AccountTypeExpense, eur, 100),
+		},
+		Transactions: Transactions{
+			{ID: "ok", Currency: eur, Splits: Splits{
+				split("ok1", "bank", eur2(-100), eur2(-100)),
+				split("ok2", "broker", eur2(100), NewValue(1, 1)),
+			}},
+			{ID: "unbalanced", Currency: eur, Splits: Splits{
+				split("u1", "bank", eur2(-100), eur2(-100)),
+				split("u2", "unknown", eur2(99), eur2(99)),
+			}},
+			{ID: "mismatch", Currency: eur, Splits: Splits{
+				split("m1", "bank", eur2(-100), eur2(-90)),
+				split("m2", "broker", eur2(50), NewValue(-1, 1)),
+				split("m3", "broker", eur2(50), NewValue(1, 1000)),
+			}},
+			{ID: "imbalance", Currency: eur, Splits: Splits{
+				split("i1", "assets", eur2(-100), eur2(-100)),
+				split("i2", "imbalance", eur2(100), eur2(100)),
+			}},
+			{ID: "lotless", Currency: eur, Splits: Splits{
+				lotless,
+				split("l2", "bank", eur2(100), eur2(100)),
+			}},
+		},
+		Invoices: Invoices{
+			{GUID: "invoice", ID: "000001", PostTransactionID: "gone", PostLotID: "gone", PostAccountID: "gone"},
+		},
+		Prices: Prices{
+			{ID: "price", Comodity: CommodityRef{ID: "MSFT", NS: "NASDAQ"}, Currency: eur, Value: NewValue(1, 1)},
+		},
+	}
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []struct {
+		severity Severity
+		check    string
+		id       GUID
+	}{
+		{SeverityError, CheckCycle, "a"},
+		{SeverityError, CheckDanglingParent, "lost"},
+		{SeverityError, CheckUnbalanced, "unbalanced"},
+		{SeverityError, CheckUnknownAccount, "u2"},
+		{SeverityError, CheckCommodity, "m1"},
+		{SeverityWarning, CheckCommodity, "m2"},
+		{SeverityWarning, CheckCommodity, "m3"},
+		{SeverityWarning, CheckPlaceholder, "i1"},
+		{SeverityWarning, CheckImbalanceAccount, "i2"},
+		{SeverityError, CheckUnknownLot, "l1"},
+		{SeverityError, CheckUnknownCommodity, "price"},
+		{SeverityError, CheckUnknownTransaction, "invoice"},
+		{SeverityError, CheckUnknownLot, "invoice"},
+		{SeverityError, CheckUnknownAccount, "invoice"},
+	}
+
+	ps := b.Check()
+	if len(ps) != len(exp) {
+		t.Fatalf("expected %d problems got %d:\n%v", len(exp), len(ps), ps)
+	}
+	for i, e := range exp {
+		p := ps[i]
+		if p.Severity != e.severity || p.Check != e.check || p.ID != e.id {
+			t.Errorf("expected %s %s %s got %s", e.severity, e.check, e.id, p)
+		}
+	}
+	if n := ps.Count(SeverityError); n != 10 {
+		t.Errorf("expected 10 errors got %d", n)
+	}
+}
+
+func TestAccountCycle(t *testing.T) {
+	b := testBook(
+		t,
+		Accounts{
+			testAccount("root", "", AccountTypeRoot),
+			testAccount("bank", "root", AccountTypeBank),
+			testAccount("a", "b", AccountTypeExpense),
+			testAccount("b", "a", AccountTypeExpense),
+		},
+		Transactions{
+			{ID: "tx", Splits: Splits{testSplit("bank", -10), testSplit("a", 10)}},
+		},
+	)
+
+	if v := b.Transactions.ValueForAccount("root", true); v.Float64() != -10 {
+		t.Errorf("expected -10 in the tree got %.2f", v.Float64())
+	}
+	if v := b.Transactions.ValueForAccount("b", true); v.Float64() != 10 {
+		t.Errorf("expected 10 in the cycle got %.2f", v.Float64())
+	}
+	a, _ := b.AccountsLookup.ByGUID("a")
+	if reg := b.Register(a, true, RegisterFilter{}); len(reg) != 1 {
+		t.Errorf("expected 1 register entry got %d", len(reg))
+	}
+}
@@ -0,0 +1,197 @@
+package gnucash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/frizinak/gocash/fuzzy"
+)
+
+// classifierNGram is the length of the description n-grams, see
+// fuzzy.NGrams.
+const classifierNGram = 3
+
+// ClassifierInput describes a transaction whose counter account is to be
+// predicted.
+type ClassifierInput struct {
+	// Source is the known account, e.g.: the bank account of a statement.
+	Source *Account
+	// Amount is the amount as seen from Source, negative when money
+	// leaves it.
+	Amount      Value
+	Description string
+	// Payee is the counterparty's name, defaults to Description.
+	Payee string
+}
+
+// Suggestion is a predicted counter account, Confidence is the estimated
+// probability in [0, 1].
+type Suggestion struct {
+	Account    *Account
+	Confidence float64
+}
+
+func (s Suggestion) String() string {
+	return fmt.Sprintf("%s %.0f%%", s.Account.FQN, s.Confidence*100)
+}
+
+// Classifier predicts counter accounts using a naive Bayes model trained on
+// the transactions of a book.
+type Classifier struct {
+	accounts map[GUID]*Account
+	// docs counts the examples and features the occurrences of each
+	// feature per counter account.
+	docs     map[GUID]int
+	features map[GUID]map[string]int
+	totals   map[GUID]int
+	vocab    map[string]struct{}
+	n        int
+}
+
+// Classifier trains a classifier on the book's transactions with exactly
+// two splits, each split's account being the counter account of the other.
+// Void splits, placeholder accounts and GnuCash's Imbalance- and Orphan-
+// accounts are skipped.
+func (b *Book) Classifier() *Classifier {
+	c := &Classifier{
+		accounts: make(map[GUID]*Account),
+		docs:     make(map[GUID]int),
+		features: make(map[GUID]map[string]int),
+		totals:   make(map[GUID]int),
+		vocab:    make(map[string]struct{}),
+	}
+
+	for _, t := range b.Transactions {
+		if len(t.Splits) != 2 {
+			continue
+		}
+		for i, s := range t.Splits {
+			other := t.Splits[1-i]
+			if s.Account == nil || other.Account == nil ||
+				s.ReconciledState == ReconciledStateVoid ||
+				other.Account.Placeholder() ||
+				strings.HasPrefix(other.Account.Name, "Imbalance-") ||
+				strings.HasPrefix(other.Account.Name, "Orphan-") {
+				continue
+			}
+
+			c.add(other.Account, ClassifierInput{
+				Source:      s.Account,
+				Amount:      s.Quantity,
+				Description: t.Description + " " + s.Memo + " " + other.Memo,
+				Payee:       t.Description,
+			})
+		}
+	}
+
+	return c
+}
+
+// amountBucket buckets amounts per half order of magnitude and sign.
+func amountBucket(v Value) string {
+	f := v.Float64()
+	if f == 0 {
+		return "0"
+	}
+
+	b := int(math.Floor(2 * math.Log10(math.Abs(f))))
+	if f < 0 {
+		return fmt.Sprintf("-%d", b)
+	}
+
+	return fmt.Sprintf("+%d", b)
+}
+
+func classifierFeatures(in ClassifierInput) []string {
+	payee := in.Payee
+	if payee == "" {
+		payee = in.Description
+	}
+
+	grams := fuzzy.NGrams(classifierNGram, in.Description)
+	l := make([]string, 0, len(grams)+3)
+	for _, g := range grams {
+		l = append(l, "d:"+g)
+	}
+	if p := strings.Join(strings.Fields(strings.ToLower(payee)), " "); p != "" {
+		l = append(l, "p:"+p)
+	}
+	l = append(l, "a:"+amountBucket(in.Amount))
+	if in.Source != nil {
+		l = append(l, "s:"+string(in.Source.ID))
+	}
+
+	return l
+}
+
+func (c *Classifier) add(label *Account, in ClassifierInput) {
+	c.accounts[label.ID] = label
+	c.docs[label.ID]++
+	c.n++
+	if c.features[label.ID] == nil {
+		c.features[label.ID] = make(map[string]int)
+	}
+	for _, f := range classifierFeatures(in) {
+		c.features[label.ID][f]++
+		c.totals[label.ID]++
+		c.vocab[f] = struct{}{}
+	}
+}
+
+// Predict returns at most n suggestions ordered by confidence. The source
+// account itself is never suggested.
+func (c *Classifier) Predict(in ClassifierInput, n int) []Suggestion {
+	if c.n == 0 {
+		return nil
+	}
+
+	features := classifierFeatures(in)
+	vocab := float64(len(c.vocab))
+	type score struct {
+		id    GUID
+		score float64
+	}
+	scores := make([]score, 0, len(c.docs))
+	for id, docs := range c.docs {
+		if in.Source != nil && id == in.Source.ID {
+			continue
+		}
+		s := math.Log(float64(docs) / float64(c.n))
+		total := float64(c.totals[id])
+		for _, f := range features {
+			s += math.Log((float64(c.features[id][f]) + 1) / (total + vocab))
+		}
+		scores = append(scores, score{id, s})
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+		return c.accounts[scores[i].id].FQN < c.accounts[scores[j].id].FQN
+	})
+
+	// softmax relative to the best score to avoid underflow.
+	var sum float64
+	for _, s := range scores {
+		sum += math.Exp(s.score - scores[0].score)
+	}
+
+	if n <= 0 || n > len(scores) {
+		n = len(scores)
+	}
+	l := make([]Suggestion, n)
+	for i := range l {
+		l[i] = Suggestion{
+			Account:    c.accounts[scores[i].id],
+			Confidence: math.Exp(scores[i].score-scores[0].score) / sum,
+		}
+	}
+
+	return l
+}
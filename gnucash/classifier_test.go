@@ -0,0 +1,56 @@
+package gnucash
+
+import "testing"
+
+func TestClassifier(t *testing.T) {
+	bank := &Account{ID: "bank", FQN: "Assets.Bank"}
+	food := &Account{ID: "food", FQN: "Expenses.Groceries"}
+	dining := &Account{ID: "dining", FQN: "Expenses.Dining"}
+	salary := &Account{ID: "salary", FQN: "Income.Salary"}
+
+	tx := func(descr string, amount int64, other *Account) *Transaction {
+		t := &Transaction{Description: descr}
+		t.Splits = Splits{
+			{Account: bank, Quantity: NewValue(amount, 1), Transaction: t},
+			{Account: other, Quantity: NewValue(-amount, 1), Transaction: t},
+		}
+		return t
+	}
+
+	b := &Book{Transactions: Transactions{
+		tx("Supermarket Foodies 123", -45, food),
+		tx("Supermarket Foodies 456", -60, food),
+		tx("Bakery Bread", -5, food),
+		tx("Restaurant Da Mario", -80, dining),
+		tx("Pizzeria Mario", -35, dining),
+		tx("ACME payroll", 3000, salary),
+		tx("ACME payroll", 3100, salary),
+	}}
+	c := b.Classifier()
+
+	tests := []struct {
+		descr  string
+		amount int64
+		exp    *Account
+	}{
+		{"SUPERMARKET FOODIES 789", -52, food},
+		{"Mario restaurant", -70, dining},
+		{"ACME Corp payroll", 2900, salary},
+	}
+
+	for _, test := range tests {
+		s := c.Predict(ClassifierInput{Source: bank, Amount: NewValue(test.amount, 1), Description: test.descr}, 2)
+		if len(s) != 2 {
+			t.Fatalf("expected 2 suggestions got %d", len(s))
+		}
+		if s[0].Account != test.exp {
+			t.Errorf("%s: expected %s got %s", test.descr, test.exp.FQN, s)
+		}
+		if s[0].Confidence < s[1].Confidence || s[0].Confidence > 1 {
+			t.Errorf("%s: invalid confidence %s", test.descr, s)
+		}
+		if s[0].Account == bank || s[1].Account == bank {
+			t.Errorf("%s: suggested the source account", test.descr)
+		}
+	}
+}
@@ -21,6 +21,7 @@ type Commodity struct {
 	CommodityRef
 	Fraction int   `xml:"fraction"`
 	Slots    Slots `xml:"slots>slot"`
+	Extra    Nodes `xml:",any"`
 }
 
 func (c CommodityRef) FQN() CommodityFQN {
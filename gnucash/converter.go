@@ -0,0 +1,184 @@
+package gnucash
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Converter converts amounts between commodities using the price database,
+// inverting rates and chaining through intermediate commodities
+// (e.g.: stock > USD > EUR) when no direct price exists.
+type Converter struct {
+	Strategy PriceStrategy
+
+	prices    *PriceIndex
+	fractions map[CommodityFQN]int64
+	graph     map[CommodityFQN][]CommodityFQN
+	cache     map[rateKey]*big.Rat
+}
+
+type rateKey struct {
+	from, to CommodityFQN
+	at       time.Time
+}
+
+// NewConverter creates a converter, fractions holds the smallest unit of
+// each commodity (e.g.: 100 for EUR) and determines the denominator of
+// converted values.
+func NewConverter(prices *PriceIndex, fractions map[CommodityFQN]int64) *Converter {
+	c := &Converter{
+		Strategy:  PriceNearestBefore,
+		prices:    prices,
+		fractions: fractions,
+		graph:     make(map[CommodityFQN][]CommodityFQN),
+		cache:     make(map[rateKey]*big.Rat),
+	}
+
+	for k := range prices.m {
+		c.graph[k.com] = append(c.graph[k.com], k.cur)
+		c.graph[k.cur] = append(c.graph[k.cur], k.com)
+	}
+
+	return c
+}
+
+// Converter returns a Converter for the book's prices, using the commodity
+// fractions and account scus found in the book.
+func (b *Book) Converter() *Converter {
+	fractions := make(map[CommodityFQN]int64)
+	for _, a := range b.Accounts {
+		if a.SCU != 0 {
+			fractions[a.Commodity.FQN()] = int64(a.SCU)
+		}
+	}
+	for _, c := range b.Commodities {
+		if c.Fraction != 0 {
+			fractions[c.FQN()] = int64(c.Fraction)
+		}
+	}
+
+	return NewConverter(b.PriceIndex, fractions)
+}
+
+func (c *Converter) Fraction(com CommodityFQN) int64 {
+	if f, ok := c.fractions[com]; ok {
+		return f
+	}
+
+	return 100
+}
+
+func (c *Converter) direct(from, to CommodityFQN, at time.Time) (*big.Rat, bool) {
+	if p, ok := c.prices.At(from, to, at, c.Strategy); ok && !p.Value.IsZero() {
+		return p.Value.rat(), true
+	}
+
+	if p, ok := c.prices.At(to, from, at, c.Strategy); ok && !p.Value.IsZero() {
+		return new(big.Rat).Inv(p.Value.rat()), true
+	}
+
+	return nil, false
+}
+
+// rate returns the exact amount of to one unit of from is worth at the given
+// time, using the shortest chain of known prices. Chained rates are
+// composed exactly as they easily outgrow a Value.
+func (c *Converter) rate(from, to CommodityFQN, at time.Time) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	key := rateKey{from, to, at}
+	if r, ok := c.cache[key]; ok {
+		return r, nil
+	}
+
+	rates := map[CommodityFQN]*big.Rat{from: big.NewRat(1, 1)}
+	queue := []CommodityFQN{from}
+	for len(queue) != 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range c.graph[cur] {
+			if _, ok := rates[next]; ok {
+				continue
+			}
+
+			r, ok := c.direct(cur, next, at)
+			if !ok {
+				continue
+			}
+
+			rates[next] = new(big.Rat).Mul(rates[cur], r)
+			if next == to {
+				c.cache[key] = rates[next]
+				return rates[next], nil
+			}
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"no price to convert %s to %s at %s",
+		from,
+		to,
+		at.Format("2006-01-02"),
+	)
+}
+
+// Rate returns the amount of to one unit of from is worth at the given
+// time, using the shortest chain of known prices. The rate is exact unless
+// it does not fit a Value, it is then rounded to as many decimals as fit.
+func (c *Converter) Rate(from, to CommodityFQN, at time.Time) (Value, error) {
+	r, err := c.rate(from, to, at)
+	if err != nil {
+		return Value{}, err
+	}
+
+	if r.Num().IsInt64() && r.Denom().IsInt64() {
+		return NewValue(r.Num().Int64(), r.Denom().Int64()), nil
+	}
+
+	for denom := int64(1e18); denom >= 1; denom /= 10 {
+		if v, err := round(r.Num(), r.Denom(), denom, RoundBankers); err == nil {
+			return v.Reduce(), nil
+		}
+	}
+
+	return Value{}, fmt.Errorf("rate of %s in %s: %w", from, to, ErrValueOverflow)
+}
+
+// Convert converts v from one commodity to another at the given time, the
+// result is rounded to the target commodity's fraction.
+func (c *Converter) Convert(v Value, from, to CommodityFQN, at time.Time) (Value, error) {
+	if from == to {
+		return v, nil
+	}
+
+	r, err := c.rate(from, to, at)
+	if err != nil {
+		return Value{}, err
+	}
+
+	x := new(big.Rat).Mul(v.rat(), r)
+	cv, err := round(x.Num(), x.Denom(), c.Fraction(to), RoundBankers)
+	if err != nil {
+		return Value{}, fmt.Errorf("converting %.2f %s to %s: %w", v.Float64(), from, to, err)
+	}
+
+	return cv, nil
+}
+
+// SplitValue converts the split's value from its transaction's currency to
+// currency at the given time, or at the date posted if at is zero.
+func (c *Converter) SplitValue(s *Split, currency CommodityFQN, at time.Time) (Value, error) {
+	if s.Transaction == nil {
+		return s.Value, nil
+	}
+
+	if at.IsZero() {
+		at = s.Transaction.DatePosted.Get()
+	}
+
+	return c.Convert(s.Value, s.Transaction.Currency.FQN(), currency, at)
+}
@@ -0,0 +1,157 @@
+package gnucash
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConverter(t *testing.T) {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	usd := CommodityRef{ID: "USD", NS: CommodityCurrency}
+	gbp := CommodityRef{ID: "GBP", NS: CommodityCurrency}
+	jpy := CommodityRef{ID: "JPY", NS: CommodityCurrency}
+	odd := CommodityRef{ID: "ODD", NS: "NASDAQ"}
+	day := NewDate(time.Date(2024, 1, 2, 10, 59, 0, 0, time.UTC))
+	at := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	prices := Prices{
+		{ID: "odd", Comodity: odd, Currency: usd, Time: day, Value: NewValue(1234567891, 1000000007)},
+		{ID: "eurusd", Comodity: eur, Currency: usd, Time: day, Value: NewValue(1085300007, 1000000000)},
+		{ID: "usdjpy", Comodity: usd, Currency: jpy, Time: day, Value: NewValue(14803, 100)},
+	}
+	c := NewConverter(prices.Index(), map[CommodityFQN]int64{
+		eur.FQN(): 100,
+		usd.FQN(): 100,
+		jpy.FQN(): 1,
+		odd.FQN(): 1,
+	})
+
+	tests := []struct {
+		v        Value
+		from, to CommodityRef
+		exp      string
+	}{
+		{NewValue(1000, 1), eur, eur, "1000.00"},
+		{NewValue(100, 1), eur, usd, "108.53"},
+		{NewValue(10853, 100), usd, eur, "100.00"},
+		{NewValue(1, 1), usd, jpy, "148.00"},
+		{NewValue(1000, 1), odd, usd, "1234.57"},
+		// chained through USD, the exact rate does not fit a Value.
+		{NewValue(1000, 1), odd, eur, "1137.54"},
+		{NewValue(1000, 1), odd, jpy, "182753.00"},
+	}
+	for _, test := range tests {
+		v, err := c.Convert(test.v, test.from.FQN(), test.to.FQN(), at)
+		if err != nil {
+			t.Errorf("%s > %s: %s", test.from.FQN(), test.to.FQN(), err)
+			continue
+		}
+		if got := fmt.Sprintf("%.2f", v.Float64()); got != test.exp {
+			t.Errorf("%s > %s: expected %s got %s", test.from.FQN(), test.to.FQN(), test.exp, got)
+		}
+	}
+
+	r, err := c.Rate(odd.FQN(), eur.FQN(), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%.6f", r.Float64()); got != "1.137536" {
+		t.Errorf("expected rate 1.137536 got %s", got)
+	}
+
+	if _, err := c.Convert(NewValue(1, 1), gbp.FQN(), eur.FQN(), at); err == nil {
+		t.Error("expected an error converting without prices")
+	}
+	if _, err := c.Convert(NewValue(1, 1), eur.FQN(), usd.FQN(), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("expected an error converting before the first price")
+	}
+
+	if _, err := c.Convert(NewValue(1<<62, 1), eur.FQN(), usd.FQN(), at); !errors.Is(err, ErrValueOverflow) {
+		t.Errorf("expected an overflow error got %v", err)
+	}
+}
+
+func TestValuationForAccount(t *testing.T) {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	usd := CommodityRef{ID: "USD", NS: CommodityCurrency}
+	gbp := CommodityRef{ID: "GBP", NS: CommodityCurrency}
+	aapl := CommodityRef{ID: "AAPL", NS: "NASDAQ"}
+	xyz := CommodityRef{ID: "XYZ", NS: "NASDAQ"}
+	day := NewDate(time.Date(2024, 1, 2, 10, 59, 0, 0, time.UTC))
+	at := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	account := func(id, parent string, com CommodityRef, scu int) *Account {
+		return &Account{ID: GUID(id), Name: id, ParentID: GUID(parent), Type: AccountTypeAsset, Commodity: com, SCU: scu}
+	}
+	tx := func(id string, cur CommodityRef, account string, value, quantity Value) *Transaction {
+		return &Transaction{
+			ID:         GUID(id),
+			Currency:   cur,
+			DatePosted: day,
+			Splits: Splits{
+				{ID: GUID(id + "-a"), AccountID: GUID(account), ReconciledState: ReconciledStateNew, Value: value, Quantity: quantity},
+				{ID: GUID(id + "-b"), AccountID: "equity", ReconciledState: ReconciledStateNew, Value: value.Neg(), Quantity: value.Neg()},
+			},
+		}
+	}
+
+	b := &Book{
+		ID:          "book",
+		Commodities: Commodities{{CommodityRef: eur}, {CommodityRef: aapl, Fraction: 10000}},
+		Accounts: Accounts{
+			account("root", "", eur, 100),
+			account("equity", "root", eur, 100),
+			account("assets", "root", eur, 100),
+			account("aapl", "assets", aapl, 10000),
+			account("xyz", "assets", xyz, 10000),
+			account("gbp", "root", gbp, 100),
+		},
+		Prices: Prices{
+			{ID: "aapl", Comodity: aapl, Currency: eur, Time: day, Value: NewValue(200, 1)},
+			{ID: "eurusd", Comodity: eur, Currency: usd, Time: day, Value: NewValue(11, 10)},
+		},
+		Transactions: Transactions{
+			tx("buy-aapl", eur, "aapl", NewValue(300, 1), NewValue(2, 1)),
+			tx("buy-xyz", eur, "xyz", NewValue(100, 1), NewValue(5, 1)),
+			tx("gbp", gbp, "gbp", NewValue(50, 1), NewValue(50, 1)),
+		},
+	}
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+	conv := b.Converter()
+
+	tests := []struct {
+		account  GUID
+		children bool
+		currency CommodityFQN
+		exp      string
+	}{
+		// valued at the latest price.
+		{"aapl", false, "", "400.00"},
+		{"aapl", false, usd.FQN(), "440.00"},
+		// no price, keeps its recorded value.
+		{"xyz", false, "", "100.00"},
+		{"xyz", false, usd.FQN(), "110.00"},
+		{"assets", false, eur.FQN(), "0.00"},
+		{"assets", true, eur.FQN(), "500.00"},
+		{"gbp", false, "", "50.00"},
+		{"gbp", false, gbp.FQN(), "50.00"},
+	}
+	for _, test := range tests {
+		v, err := b.Transactions.ValuationForAccount(test.account, test.children, conv, test.currency, at)
+		if err != nil {
+			t.Errorf("%s in %s: %s", test.account, test.currency, err)
+			continue
+		}
+		if got := fmt.Sprintf("%.2f", v.Float64()); got != test.exp {
+			t.Errorf("%s in %s: expected %s got %s", test.account, test.currency, test.exp, got)
+		}
+	}
+
+	if _, err := b.Transactions.ValuationForAccount("gbp", false, conv, eur.FQN(), at); err == nil {
+		t.Error("expected an error valuing gbp in eur without prices")
+	}
+}
@@ -0,0 +1,239 @@
+package gnucash
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/frizinak/gocash/fuzzy"
+)
+
+// duplicateNGram is the length of the description n-grams compared, see
+// fuzzy.NGrams.
+const duplicateNGram = 2
+
+// Duplicate is a pair of transactions that likely record the same event:
+// both move the same quantity in Account within a few days of each other.
+// B is the copy entered last.
+type Duplicate struct {
+	A, B    *Transaction
+	Account *Account
+	Amount  Value
+	// Score in [0, 1] weighs the similarity of the descriptions, the
+	// distance between the dates and the overlap of the accounts involved.
+	Score float64
+}
+
+// Duplicates finds likely duplicate transactions: distinct transactions
+// with a split of equal quantity in the same account posted at most days
+// apart. Pairs scoring less than min are dropped, the rest are ordered by
+// score. Void splits are ignored.
+func (b *Book) Duplicates(days int, min float64) []Duplicate {
+	type candidate struct {
+		split *Split
+		date  time.Time
+	}
+
+	buckets := make(map[string][]candidate)
+	keys := make([]string, 0)
+	for _, t := range b.Transactions {
+		for _, s := range t.Splits {
+			if s.Account == nil || s.Quantity.IsZero() ||
+				s.ReconciledState == ReconciledStateVoid {
+				continue
+			}
+			key := string(s.AccountID) + ":" + s.Quantity.Reduce().String()
+			if _, ok := buckets[key]; !ok {
+				keys = append(keys, key)
+			}
+			buckets[key] = append(buckets[key], candidate{s, t.DatePosted.Get()})
+		}
+	}
+
+	window := time.Duration(days) * 24 * time.Hour
+	pairs := make(map[[2]GUID]int)
+	dupes := make([]Duplicate, 0)
+	for _, key := range keys {
+		l := buckets[key]
+		if len(l) < 2 {
+			continue
+		}
+		sort.SliceStable(l, func(i, j int) bool { return l[i].date.Before(l[j].date) })
+
+		descrs := make([]string, len(l))
+		for i, c := range l {
+			descrs[i] = c.split.Transaction.Description
+		}
+		ix := fuzzy.NewIndex(duplicateNGram, descrs)
+
+		for i, c := range l {
+			shared := make([]int, len(l))
+			ix.Search(descrs[i], func(j int, score, low, high uint8) {
+				shared[j] = int(score)
+			})
+
+			for j := i + 1; j < len(l) && l[j].date.Sub(c.date) <= window; j++ {
+				ta, tb := c.split.Transaction, l[j].split.Transaction
+				if ta.ID == tb.ID {
+					continue
+				}
+				if tb.DateEntered.Get().Before(ta.DateEntered.Get()) {
+					ta, tb = tb, ta
+				}
+
+				closeness := 1.0
+				if days > 0 {
+					closeness -= l[j].date.Sub(c.date).Hours() / 24 / float64(days+1)
+				}
+				score := 0.5*similarity(descrs[i], descrs[j], shared[j]) +
+					0.25*closeness +
+					0.25*accountOverlap(ta, tb)
+				if score < min {
+					continue
+				}
+
+				d := Duplicate{A: ta, B: tb, Account: c.split.Account, Amount: c.split.Quantity, Score: score}
+				pair := [2]GUID{ta.ID, tb.ID}
+				if n, ok := pairs[pair]; ok {
+					if dupes[n].Score < score {
+						dupes[n] = d
+					}
+					continue
+				}
+				pairs[pair] = len(dupes)
+				dupes = append(dupes, d)
+			}
+		}
+	}
+
+	sort.SliceStable(dupes, func(i, j int) bool { return dupes[i].Score > dupes[j].Score })
+
+	return dupes
+}
+
+// similarity is the dice coefficient of the n-grams of a and b given the
+// number of n-grams they share.
+func similarity(a, b string, shared int) float64 {
+	na, nb := len(fuzzy.NGrams(duplicateNGram, a)), len(fuzzy.NGrams(duplicateNGram, b))
+	if na+nb == 0 {
+		return 1
+	}
+
+	return math.Min(1, 2*float64(shared)/float64(na+nb))
+}
+
+// accountOverlap is the jaccard index of the accounts of a and b.
+func accountOverlap(a, b *Transaction) float64 {
+	set := make(map[GUID]uint8)
+	for _, s := range a.Splits {
+		set[s.AccountID] |= 1
+	}
+	for _, s := range b.Splits {
+		set[s.AccountID] |= 2
+	}
+
+	both := 0
+	for _, v := range set {
+		if v == 3 {
+			both++
+		}
+	}
+	if len(set) == 0 {
+		return 0
+	}
+
+	return float64(both) / float64(len(set))
+}
+
+// ErrReconciled is returned when removing or voiding a transaction with
+// reconciled or frozen splits.
+var ErrReconciled = errors.New("transaction has reconciled splits")
+
+// Reconciled reports whether one of the transaction's splits is reconciled
+// or frozen.
+func (t *Transaction) Reconciled() bool {
+	for _, s := range t.Splits {
+		switch s.ReconciledState {
+		case ReconciledStateReconciled, ReconciledStateFrozen:
+			return true
+		}
+	}
+
+	return false
+}
+
+// RemoveTransaction unlinks the transaction from the book, its accounts
+// and lots. Transactions posting an invoice or with reconciled splits can
+// not be removed.
+func (b *Book) RemoveTransaction(t *Transaction) error {
+	if t.Invoice != nil {
+		return fmt.Errorf("transaction '%s' posts invoice '%s'", t.Description, t.Invoice.ID)
+	}
+	if t.Reconciled() {
+		return fmt.Errorf("transaction '%s': %w", t.Description, ErrReconciled)
+	}
+	if _, ok := b.transactions[t.ID]; !ok {
+		return errors.New("transaction is not in the book")
+	}
+
+	b.Transactions = t.without(b.Transactions)
+	delete(b.transactions, t.ID)
+	for _, s := range t.Splits {
+		b.TransactionsLookup[s.AccountID] = t.without(b.TransactionsLookup[s.AccountID])
+		if s.Account != nil {
+			s.Account.Transactions = t.without(s.Account.Transactions)
+		}
+		if s.Lot != nil {
+			splits := make(Splits, 0, len(s.Lot.Splits))
+			for _, ls := range s.Lot.Splits {
+				if ls != s {
+					splits = append(splits, ls)
+				}
+			}
+			s.Lot.Splits = splits
+		}
+	}
+
+	return nil
+}
+
+func (t *Transaction) without(ts Transactions) Transactions {
+	l := make(Transactions, 0, len(ts))
+	for _, o := range ts {
+		if o != t {
+			l = append(l, o)
+		}
+	}
+
+	return l
+}
+
+// Void zeroes the transaction like GnuCash does, keeping the former amounts
+// of the splits in their slots and marking it read-only. Transactions with
+// reconciled splits can not be voided.
+func (t *Transaction) Void(reason string, now time.Time) error {
+	if t.Reconciled() {
+		return fmt.Errorf("transaction '%s': %w", t.Description, ErrReconciled)
+	}
+
+	str := func(v string) SlotValue { return SlotValue{Type: "string", Value: v} }
+	if notes, ok := t.Slots.KeyValue()["notes"]; ok {
+		t.Slots.Set("void-former-notes", notes.RawValue)
+	}
+	t.Slots.Set("notes", str("Voided transaction"))
+	t.Slots.Set("void-reason", str(reason))
+	t.Slots.Set("void-time", str(now.Format("2006-01-02 15:04:05 -0700")))
+	t.Slots.Set("trans-read-only", str("Transaction Voided"))
+
+	for _, s := range t.Splits {
+		s.Slots.Set("void-former-amount", SlotValue{Type: "numeric", Value: s.Quantity.String()})
+		s.Slots.Set("void-former-value", SlotValue{Type: "numeric", Value: s.Value.String()})
+		s.Quantity = NewValue(0, s.Quantity.Denom())
+		s.Value = NewValue(0, s.Value.Denom())
+		s.ReconciledState = ReconciledStateVoid
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package gnucash
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDuplicates(t *testing.T) {
+	bank := &Account{ID: "bank", FQN: "Assets.Bank"}
+	food := &Account{ID: "food", FQN: "Expenses.Groceries"}
+	dining := &Account{ID: "dining", FQN: "Expenses.Dining"}
+
+	day := func(d int) Date { return NewDate(time.Date(2023, 1, d, 10, 59, 0, 0, time.UTC)) }
+	n := 0
+	tx := func(descr string, d int, amount int64, other *Account) *Transaction {
+		n++
+		t := &Transaction{
+			ID:          GUID(string(rune('a' + n))),
+			Description: descr,
+			DatePosted:  day(d),
+			DateEntered: day(n),
+		}
+		t.Splits = Splits{
+			{AccountID: bank.ID, Account: bank, Value: NewValue(-amount, 100), Quantity: NewValue(-amount, 100), Transaction: t},
+			{AccountID: other.ID, Account: other, Value: NewValue(amount, 100), Quantity: NewValue(amount, 100), Transaction: t},
+		}
+		return t
+	}
+
+	sheet := tx("Supermarket Foodies", 3, 4550, food)
+	imported := tx("SUPERMARKET FOODIES 123", 4, 4550, food)
+	other := tx("Restaurant Da Mario", 4, 4550, dining)
+	later := tx("Supermarket Foodies", 20, 4550, food)
+	cheap := tx("Supermarket Foodies", 3, 1000, food)
+
+	b := &Book{Transactions: Transactions{sheet, imported, other, later, cheap}}
+	b.TransactionsLookup = b.Transactions.lookup()
+	b.transactions = make(map[GUID]*Transaction)
+	for _, t := range b.Transactions {
+		b.transactions[t.ID] = t
+	}
+	for _, a := range []*Account{bank, food, dining} {
+		a.Transactions = b.TransactionsLookup[a.ID]
+	}
+
+	if d := b.Duplicates(3, 0.5); len(d) != 1 || d[0].A != sheet || d[0].B != imported {
+		t.Fatalf("expected sheet and imported as only duplicate got %+v", d)
+	}
+
+	d := b.Duplicates(3, 0)
+	if len(d) != 3 {
+		t.Fatalf("expected 3 candidates got %d", len(d))
+	}
+	if d[0].A != sheet || d[0].B != imported {
+		t.Errorf("expected sheet and imported as best match got %s and %s", d[0].A.Description, d[0].B.Description)
+	}
+	if d[0].Score <= d[1].Score || d[0].Score > 1 {
+		t.Errorf("invalid scores %f %f", d[0].Score, d[1].Score)
+	}
+
+	if err := b.RemoveTransaction(imported); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Transaction(imported.ID); ok || len(b.Transactions) != 4 {
+		t.Error("transaction not removed")
+	}
+	for _, l := range []Transactions{b.TransactionsLookup[bank.ID], bank.Transactions, food.Transactions} {
+		for _, o := range l {
+			if o == imported {
+				t.Error("transaction still linked")
+			}
+		}
+	}
+
+	if err := other.Void("duplicate", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range other.Splits {
+		if !s.Value.IsZero() || !s.Quantity.IsZero() || s.ReconciledState != ReconciledStateVoid {
+			t.Errorf("split not voided: %s", s)
+		}
+	}
+	if r, _ := other.Slots.KeyValue()["void-reason"].StringValue(); r != "duplicate" {
+		t.Errorf("expected void reason got '%s'", r)
+	}
+	if len(b.Duplicates(3, 0)) != 0 {
+		t.Error("voided transactions should not be duplicates")
+	}
+}
+
+func TestDuplicatesRepeatedPurchases(t *testing.T) {
+	bank := &Account{ID: "bank", FQN: "Assets.Bank"}
+	dining := &Account{ID: "dining", FQN: "Expenses.Dining"}
+
+	n := 0
+	tx := func(d int, state ReconciledState) *Transaction {
+		n++
+		date := NewDate(time.Date(2023, 1, d, 10, 59, 0, 0, time.UTC))
+		t := &Transaction{ID: GUID(string(rune('a' + n))), Description: "Starbucks", DatePosted: date, DateEntered: date}
+		t.Splits = Splits{
+			{AccountID: bank.ID, Account: bank, Value: NewValue(-350, 100), Quantity: NewValue(-350, 100), ReconciledState: state, Transaction: t},
+			{AccountID: dining.ID, Account: dining, Value: NewValue(350, 100), Quantity: NewValue(350, 100), ReconciledState: ReconciledStateNew, Transaction: t},
+		}
+		return t
+	}
+
+	// three coffees on consecutive days, all on the bank statement, and a
+	// copy of the last one entered by hand.
+	mon, tue, thu := tx(2, ReconciledStateReconciled), tx(3, ReconciledStateReconciled), tx(5, ReconciledStateFrozen)
+	copied := tx(5, ReconciledStateNew)
+
+	b := &Book{Transactions: Transactions{mon, tue, thu, copied}}
+	b.TransactionsLookup = b.Transactions.lookup()
+	b.transactions = make(map[GUID]*Transaction)
+	for _, t := range b.Transactions {
+		b.transactions[t.ID] = t
+	}
+	for _, a := range []*Account{bank, dining} {
+		a.Transactions = b.TransactionsLookup[a.ID]
+	}
+
+	dupes := b.Duplicates(3, 0.6)
+	if len(dupes) != 6 {
+		t.Fatalf("expected every pair as candidate got %d", len(dupes))
+	}
+
+	for _, d := range dupes {
+		for _, o := range []*Transaction{d.A, d.B} {
+			if o == copied {
+				continue
+			}
+			if err := o.Void("duplicate", time.Now()); !errors.Is(err, ErrReconciled) {
+				t.Errorf("expected reconciled %s to be refused got %v", o.DatePosted.Get(), err)
+			}
+			if err := b.RemoveTransaction(o); !errors.Is(err, ErrReconciled) {
+				t.Errorf("expected reconciled %s to be refused got %v", o.DatePosted.Get(), err)
+			}
+		}
+	}
+
+	if err := b.RemoveTransaction(copied); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Transactions) != 3 || len(bank.Transactions) != 3 {
+		t.Errorf("expected the reconciled purchases to remain got %d", len(b.Transactions))
+	}
+	for _, o := range b.Transactions {
+		if !o.Splits[0].Value.Equal(NewValue(-350, 100)) {
+			t.Errorf("purchase %s changed", o.DatePosted.Get())
+		}
+	}
+}
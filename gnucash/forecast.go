@@ -0,0 +1,145 @@
+package gnucash
+
+import (
+	"fmt"
+	"time"
+)
+
+// Forecast is a day by day projection of account balances.
+type Forecast struct {
+	Accounts Accounts
+	Opening  []Value
+	Days     []ForecastDay
+	// Skipped holds the schedules that could not be expanded and why.
+	Skipped []error
+}
+
+// ForecastDay holds the balances at the end of a day, indexed like
+// Forecast.Accounts, and the transactions that were applied that day.
+type ForecastDay struct {
+	Date         time.Time
+	Balances     []Value
+	Transactions Transactions
+}
+
+// ForecastAlert marks a day an asset account's balance is negative.
+type ForecastAlert struct {
+	Date    time.Time
+	Account *Account
+	Balance Value
+}
+
+func (a ForecastAlert) String() string {
+	return fmt.Sprintf(
+		"%s: %s would be %.2f",
+		a.Date.Format("2006-01-02"),
+		a.Account.FQN,
+		a.Balance.Float64(),
+	)
+}
+
+// Forecast projects the balances of accounts (including their children) for
+// every day from from until to, starting with the balance from the book's
+// transactions posted before from and applying transactions posted within
+// the horizon as well as the scheduled transactions not created yet.
+// Overdue scheduled transactions are applied on the first day. Schedules
+// whose transactions can not be created, e.g.: formulas with variables, are
+// left out and listed in Skipped.
+func (b *Book) Forecast(accounts Accounts, from, to time.Time) (*Forecast, error) {
+	from, to = day(from), day(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf(
+			"forecast ends (%s) before it starts (%s)",
+			to.Format("2006-01-02"),
+			from.Format("2006-01-02"),
+		)
+	}
+
+	f := &Forecast{
+		Accounts: accounts,
+		Opening:  make([]Value, len(accounts)),
+		Days:     make([]ForecastDay, 0, int(to.Sub(from).Hours()/24)+1),
+		Skipped:  make([]error, 0),
+	}
+
+	scheduled := make(Transactions, 0)
+	for _, s := range b.Scheduled {
+		ts, err := s.expand(s.Pending(to))
+		if err != nil {
+			f.Skipped = append(f.Skipped, err)
+			continue
+		}
+		scheduled = append(scheduled, ts...)
+	}
+	scheduled = scheduled.SortDatePosted()
+
+	byDay := make(map[time.Time]Transactions)
+	for _, t := range b.Transactions {
+		d := day(t.DatePosted.Get())
+		if d.Before(from) {
+			for i, a := range accounts {
+				f.Opening[i] = f.Opening[i].Add(t.Splits.ValueForAccount(a.ID, true))
+			}
+			continue
+		}
+		if !d.After(to) {
+			byDay[d] = append(byDay[d], t)
+		}
+	}
+
+	for _, t := range scheduled {
+		d := day(t.DatePosted.Get())
+		if d.Before(from) {
+			d = from
+		}
+		byDay[d] = append(byDay[d], t)
+	}
+
+	balances := f.Opening
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		txs := byDay[d]
+		next := make([]Value, len(balances))
+		copy(next, balances)
+		for _, t := range txs {
+			for i, a := range accounts {
+				next[i] = next[i].Add(t.Splits.ValueForAccount(a.ID, true))
+			}
+		}
+
+		f.Days = append(f.Days, ForecastDay{Date: d, Balances: next, Transactions: txs})
+		balances = next
+	}
+
+	return f, nil
+}
+
+// Alerts returns the days on which asset accounts have a negative balance.
+func (f *Forecast) Alerts() []ForecastAlert {
+	l := make([]ForecastAlert, 0)
+	for _, d := range f.Days {
+		for i, a := range f.Accounts {
+			if a.Type.Asset() && d.Balances[i].Sign() < 0 {
+				l = append(l, ForecastAlert{d.Date, a, d.Balances[i]})
+			}
+		}
+	}
+
+	return l
+}
+
+// Changes returns only the days on which a balance changed.
+func (f *Forecast) Changes() []ForecastDay {
+	l := make([]ForecastDay, 0)
+	prev := f.Opening
+	for _, d := range f.Days {
+		for i := range d.Balances {
+			if !d.Balances[i].Equal(prev[i]) {
+				l = append(l, d)
+				break
+			}
+		}
+		prev = d.Balances
+	}
+
+	return l
+}
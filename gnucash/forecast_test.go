@@ -0,0 +1,98 @@
+package gnucash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestForecast(t *testing.T) {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	usd := CommodityRef{ID: "USD", NS: CommodityCurrency}
+	account := func(id, parent string, typ AccountType, c CommodityRef) *Account {
+		return &Account{ID: GUID(id), Name: id, ParentID: GUID(parent), Type: typ, Commodity: c, SCU: 100}
+	}
+	str := func(typ, v string) SlotValue { return SlotValue{Type: typ, Value: v} }
+	split := func(tpl, account, debit, credit string) *Split {
+		s := &Split{ID: NewGUID(), AccountID: GUID(tpl), ReconciledState: ReconciledStateNew}
+		s.Slots.Set("sched-xaction", SlotValue{Type: "frame", Slots: Slots{
+			{Key: "account", RawValue: str("guid", account)},
+			{Key: "debit-formula", RawValue: str("string", debit)},
+			{Key: "credit-formula", RawValue: str("string", credit)},
+		}})
+		return s
+	}
+	tx := func(descr string, splits ...*Split) *Transaction {
+		return &Transaction{ID: NewGUID(), Currency: eur, Description: descr, DatePosted: NewDate(date(2024, 1, 1)), Splits: splits}
+	}
+	schedule := func(id, tpl string) *Scheduled {
+		return &Scheduled{
+			ID:                GUID(id),
+			Name:              id,
+			Enabled:           true,
+			Start:             NewDate(date(2024, 1, 1)),
+			TemplateAccountID: GUID(tpl),
+			Schedule:          Recurrences{{1, PeriodMonth, NewDate(date(2024, 1, 1)), WeekendAdjustNone}},
+		}
+	}
+
+	b := &Book{
+		ID:          "book",
+		Commodities: Commodities{{CommodityRef: eur}, {CommodityRef: usd}},
+		Accounts: Accounts{
+			account("root", "", AccountTypeRoot, eur),
+			account("bank", "root", AccountTypeBank, eur),
+			account("rent", "root", AccountTypeExpense, eur),
+			account("dollars", "root", AccountTypeBank, usd),
+		},
+		Templates: Templates{
+			Accounts: Accounts{
+				account("tpl-root", "", AccountTypeRoot, eur),
+				account("tpl-rent", "tpl-root", AccountTypeBank, eur),
+				account("tpl-salary", "tpl-root", AccountTypeBank, eur),
+				account("tpl-usd", "tpl-root", AccountTypeBank, eur),
+			},
+			Transactions: Transactions{
+				tx("Rent", split("tpl-rent", "rent", "500", ""), split("tpl-rent", "bank", "", "500")),
+				// a variable without numeric fallback.
+				tx("Salary", split("tpl-salary", "bank", "salary", ""), split("tpl-salary", "rent", "", "salary")),
+				// a template in a currency the account is not in.
+				tx("Transfer", split("tpl-usd", "dollars", "100", ""), split("tpl-usd", "bank", "", "100")),
+			},
+		},
+		Scheduled: Schedules{
+			schedule("rent", "tpl-rent"),
+			schedule("salary", "tpl-salary"),
+			schedule("transfer", "tpl-usd"),
+		},
+	}
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	bank, _ := b.AccountsLookup.ByGUID("bank")
+	f, err := b.Forecast(Accounts{bank}, date(2024, 1, 1), date(2024, 3, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := f.Days[len(f.Days)-1]
+	if !last.Balances[0].Equal(NewValue(-1500, 1)) {
+		t.Errorf("expected three rent payments got %.2f", last.Balances[0].Float64())
+	}
+
+	if len(f.Skipped) != 2 {
+		t.Fatalf("expected 2 skipped schedules got %v", f.Skipped)
+	}
+	for i, name := range []string{"salary", "transfer"} {
+		if !strings.Contains(f.Skipped[i].Error(), "'"+name+"'") {
+			t.Errorf("expected schedule %s to be skipped got %s", name, f.Skipped[i])
+		}
+	}
+
+	if _, err := b.Forecast(Accounts{bank}, date(2024, 1, 1), date(2023, 12, 30)); err == nil {
+		t.Error("expected an error for a forecast ending before it starts")
+	}
+	if f, err := b.Forecast(Accounts{bank}, date(2024, 1, 1), date(2024, 1, 1)); err != nil || len(f.Days) != 1 {
+		t.Errorf("expected a single day forecast got %v", err)
+	}
+}
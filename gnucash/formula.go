@@ -0,0 +1,151 @@
+package gnucash
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EvalFormula evaluates the arithmetic formulas GnuCash stores in scheduled
+// transaction templates (e.g.: "1200", "-(100 + 20) * 3", "1,250.50").
+// Variables are not supported.
+func EvalFormula(formula string) (Value, error) {
+	p := &formulaParser{s: strings.ReplaceAll(formula, " ", "")}
+	if p.s == "" {
+		return Value{}, nil
+	}
+
+	v, err := p.expr()
+	if err != nil {
+		return Value{}, fmt.Errorf("formula '%s': %w", formula, err)
+	}
+	if p.pos != len(p.s) {
+		return Value{}, fmt.Errorf("formula '%s': unexpected '%c'", formula, p.s[p.pos])
+	}
+
+	return v, nil
+}
+
+type formulaParser struct {
+	s   string
+	pos int
+}
+
+func (p *formulaParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *formulaParser) expr() (Value, error) {
+	v, err := p.term()
+	if err != nil {
+		return v, err
+	}
+
+	for {
+		switch p.peek() {
+		case '+':
+			p.pos++
+			r, err := p.term()
+			if err != nil {
+				return v, err
+			}
+			v = v.Add(r)
+		case '-':
+			p.pos++
+			r, err := p.term()
+			if err != nil {
+				return v, err
+			}
+			v = v.Sub(r)
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (p *formulaParser) term() (Value, error) {
+	v, err := p.factor()
+	if err != nil {
+		return v, err
+	}
+
+	for {
+		switch p.peek() {
+		case '*':
+			p.pos++
+			r, err := p.factor()
+			if err != nil {
+				return v, err
+			}
+			v = v.Mul(r).Reduce()
+		case '/':
+			p.pos++
+			r, err := p.factor()
+			if err != nil {
+				return v, err
+			}
+			if r.IsZero() {
+				return v, fmt.Errorf("division by zero")
+			}
+			v = v.Div(r).Reduce()
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (p *formulaParser) factor() (Value, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		v, err := p.factor()
+		return v.Neg(), err
+	case '+':
+		p.pos++
+		return p.factor()
+	case '(':
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return v, err
+		}
+		if p.peek() != ')' {
+			return v, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return v, nil
+	}
+
+	start := p.pos
+	for c := p.peek(); (c >= '0' && c <= '9') || c == '.' || c == ','; c = p.peek() {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos == len(p.s) {
+			return Value{}, fmt.Errorf("unexpected end")
+		}
+		return Value{}, fmt.Errorf("unexpected '%c'", p.peek())
+	}
+
+	return ParseDecimal(normalizeNumber(p.s[start:p.pos]))
+}
+
+// normalizeNumber strips thousands separators from a locale formatted number
+// and uses a dot as decimal separator.
+func normalizeNumber(n string) string {
+	dot, comma := strings.LastIndexByte(n, '.'), strings.LastIndexByte(n, ',')
+	switch {
+	case comma == -1:
+		return n
+	case dot == -1 && strings.Count(n, ",") == 1 && len(n)-comma-1 != 3:
+		return strings.Replace(n, ",", ".", 1)
+	case dot == -1:
+		return strings.ReplaceAll(n, ",", "")
+	case dot > comma:
+		return strings.ReplaceAll(n, ",", "")
+	}
+
+	return strings.Replace(strings.ReplaceAll(n, ".", ""), ",", ".", 1)
+}
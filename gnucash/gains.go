@@ -0,0 +1,298 @@
+package gnucash
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GainsPolicy determines which acquisitions a sale is matched against for
+// splits that are not assigned to a lot.
+type GainsPolicy int
+
+const (
+	// GainsFIFO sells the oldest shares first.
+	GainsFIFO GainsPolicy = iota
+	// GainsLIFO sells the newest shares first.
+	GainsLIFO
+	// GainsAverage sells shares at the average cost of all shares held.
+	GainsAverage
+)
+
+func (p GainsPolicy) String() string {
+	switch p {
+	case GainsLIFO:
+		return "lifo"
+	case GainsAverage:
+		return "average"
+	}
+
+	return "fifo"
+}
+
+func ParseGainsPolicy(s string) (GainsPolicy, error) {
+	switch strings.ToLower(s) {
+	case "fifo":
+		return GainsFIFO, nil
+	case "lifo":
+		return GainsLIFO, nil
+	case "average", "avg":
+		return GainsAverage, nil
+	}
+
+	return 0, fmt.Errorf("unknown gains policy '%s'", s)
+}
+
+// RealizedGain is the result of selling (part of) an acquisition.
+// Acquired is zero for GainsAverage.
+type RealizedGain struct {
+	Account  *Account
+	Lot      *Lot
+	Currency CommodityFQN
+	Acquired time.Time
+	Sold     time.Time
+	Quantity Value
+	Cost     Value
+	Proceeds Value
+}
+
+func (g RealizedGain) Gain() Value {
+	return g.Proceeds.Sub(g.Cost)
+}
+
+type RealizedGains []RealizedGain
+
+// Between returns the gains realized in [from, to).
+func (gs RealizedGains) Between(from, to time.Time) RealizedGains {
+	l := make(RealizedGains, 0, len(gs))
+	for _, g := range gs {
+		if !g.Sold.Before(from) && g.Sold.Before(to) {
+			l = append(l, g)
+		}
+	}
+
+	return l
+}
+
+// Total sums the cost and proceeds of all gains.
+func (gs RealizedGains) Total() (cost, proceeds Value) {
+	for _, g := range gs {
+		cost, proceeds = cost.Add(g.Cost), proceeds.Add(g.Proceeds)
+	}
+
+	return
+}
+
+// UnrealizedGain is a holding that has not been sold, valued using the
+// price database.
+type UnrealizedGain struct {
+	Account  *Account
+	Lot      *Lot
+	Currency CommodityFQN
+	Acquired time.Time
+	Quantity Value
+	Cost     Value
+	Market   Value
+}
+
+func (g UnrealizedGain) Gain() Value {
+	return g.Market.Sub(g.Cost)
+}
+
+type UnrealizedGains []UnrealizedGain
+
+// Total sums the cost and market value of all holdings.
+func (gs UnrealizedGains) Total() (cost, market Value) {
+	for _, g := range gs {
+		cost, market = cost.Add(g.Cost), market.Add(g.Market)
+	}
+
+	return
+}
+
+type Gains struct {
+	At         time.Time
+	Realized   RealizedGains
+	Unrealized UnrealizedGains
+}
+
+type holding struct {
+	date     time.Time
+	currency CommodityFQN
+	quantity Value
+	cost     Value
+}
+
+// Gains calculates realized and unrealized gains of the given accounts
+// (usually of type AccountTypeStock or AccountTypeMutual) for all splits
+// posted at or before at.
+//
+// Splits that belong to a lot are matched within that lot, the others are
+// matched according to policy. Cost and proceeds are the split values
+// converted to currency at the date posted (or left in the transaction's
+// currency if currency is empty), market values use the price at at.
+// Splits without a quantity, e.g.: the realized gain splits GnuCash
+// creates, are ignored as gains are calculated from the purchases and sales
+// themselves.
+func (b *Book) Gains(accounts Accounts, policy GainsPolicy, currency CommodityFQN, at time.Time) (*Gains, error) {
+	return b.gains(accounts, policy, currency, at, true)
+}
+
+// gains is Book.Gains, the market value of unrealized gains is left zero
+// unless value is set.
+func (b *Book) gains(accounts Accounts, policy GainsPolicy, currency CommodityFQN, at time.Time, value bool) (*Gains, error) {
+	conv := b.Converter()
+	gains := &Gains{
+		At:         at,
+		Realized:   make(RealizedGains, 0),
+		Unrealized: make(UnrealizedGains, 0),
+	}
+
+	for _, a := range accounts {
+		ts, _ := b.TransactionsLookup.Find(a.ID)
+		splits := make(Splits, 0, len(ts))
+		for _, t := range ts {
+			if t.DatePosted.Get().After(at) {
+				continue
+			}
+			for _, s := range t.Splits {
+				if s.AccountID == a.ID && !s.Quantity.IsZero() {
+					splits = append(splits, s)
+				}
+			}
+		}
+		sort.SliceStable(splits, func(i, j int) bool {
+			return splits[i].Transaction.DatePosted.Get().Before(splits[j].Transaction.DatePosted.Get())
+		})
+
+		pools := make(map[*Lot][]*holding)
+		order := make(Lots, 0)
+		for _, s := range splits {
+			if _, ok := pools[s.Lot]; !ok {
+				order = append(order, s.Lot)
+			}
+
+			cur := currency
+			if cur == "" {
+				cur = s.Transaction.Currency.FQN()
+			}
+			value, err := conv.SplitValue(s, cur, time.Time{})
+			if err != nil {
+				return nil, fmt.Errorf("account '%s': %w", a.FQN, err)
+			}
+
+			date := s.Transaction.DatePosted.Get()
+			if s.Quantity.Sign() > 0 {
+				pools[s.Lot] = acquire(pools[s.Lot], s.Lot == nil && policy == GainsAverage, &holding{
+					date:     date,
+					currency: cur,
+					quantity: s.Quantity,
+					cost:     value,
+				})
+				continue
+			}
+
+			p := policy
+			if s.Lot != nil {
+				p = GainsFIFO
+			}
+			var realized RealizedGains
+			pools[s.Lot], realized = dispose(pools[s.Lot], p, s.Quantity.Neg(), value.Neg(), conv.Fraction(cur))
+			for _, g := range realized {
+				g.Account, g.Lot, g.Currency, g.Sold = a, s.Lot, cur, date
+				if p == GainsAverage {
+					g.Acquired = time.Time{}
+				}
+				gains.Realized = append(gains.Realized, g)
+			}
+		}
+
+		for _, l := range order {
+			for _, h := range pools[l] {
+				var market Value
+				if value {
+					var err error
+					market, err = conv.Convert(h.quantity, a.Commodity.FQN(), h.currency, at)
+					if err != nil {
+						return nil, fmt.Errorf("account '%s': %w", a.FQN, err)
+					}
+				}
+				acquired := h.date
+				if l == nil && policy == GainsAverage {
+					acquired = time.Time{}
+				}
+				gains.Unrealized = append(gains.Unrealized, UnrealizedGain{
+					Account:  a,
+					Lot:      l,
+					Currency: h.currency,
+					Acquired: acquired,
+					Quantity: h.quantity,
+					Cost:     h.cost,
+					Market:   market,
+				})
+			}
+		}
+	}
+
+	return gains, nil
+}
+
+// acquire adds h to the holdings, merging it into a single holding when
+// average is set.
+func acquire(hs []*holding, average bool, h *holding) []*holding {
+	if !average || len(hs) == 0 {
+		return append(hs, h)
+	}
+
+	hs[0].quantity = hs[0].quantity.Add(h.quantity).Reduce()
+	hs[0].cost = hs[0].cost.Add(h.cost)
+	return hs
+}
+
+// dispose sells quantity from the holdings, spreading proceeds over the
+// matched holdings. Selling more than is held results in a gain without
+// cost.
+func dispose(hs []*holding, policy GainsPolicy, quantity, proceeds Value, fraction int64) ([]*holding, RealizedGains) {
+	gains := make(RealizedGains, 0, 1)
+	left, leftProceeds := quantity, proceeds
+	for len(hs) != 0 && left.Sign() > 0 {
+		i := 0
+		if policy == GainsLIFO {
+			i = len(hs) - 1
+		}
+		h := hs[i]
+
+		take, cost := h.quantity, h.cost
+		if left.Sub(h.quantity).Sign() < 0 {
+			take = left
+			cost = h.cost.Mul(take).Div(h.quantity).Convert(fraction, RoundBankers)
+		}
+
+		part := leftProceeds
+		if !left.Equal(take) {
+			part = proceeds.Mul(take).Div(quantity).Convert(fraction, RoundBankers)
+		}
+
+		gains = append(gains, RealizedGain{
+			Acquired: h.date,
+			Quantity: take,
+			Cost:     cost,
+			Proceeds: part,
+		})
+
+		left = left.Sub(take).Reduce()
+		leftProceeds = leftProceeds.Sub(part)
+		h.quantity = h.quantity.Sub(take).Reduce()
+		h.cost = h.cost.Sub(cost)
+		if h.quantity.IsZero() {
+			hs = append(hs[:i], hs[i+1:]...)
+		}
+	}
+
+	if left.Sign() > 0 {
+		gains = append(gains, RealizedGain{Quantity: left, Proceeds: leftProceeds})
+	}
+
+	return hs, gains
+}
@@ -0,0 +1,96 @@
+package gnucash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDispose(t *testing.T) {
+	buys := func(average bool) []*holding {
+		var hs []*holding
+		hs = acquire(hs, average, &holding{date: date(2024, 1, 1), quantity: NewValue(10, 1), cost: NewValue(100000, 100)})
+		hs = acquire(hs, average, &holding{date: date(2024, 2, 1), quantity: NewValue(10, 1), cost: NewValue(200000, 100)})
+		return hs
+	}
+
+	tests := []struct {
+		policy GainsPolicy
+		gains  []string
+		left   string
+	}{
+		{GainsFIFO, []string{"-166.67", "-583.33"}, "1000.00"},
+		{GainsLIFO, []string{"-1166.67", "-83.33"}, "500.00"},
+		{GainsAverage, []string{"-1000.00"}, "750.00"},
+	}
+
+	f := func(v Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+	for _, test := range tests {
+		hs := buys(test.policy == GainsAverage)
+		hs, gains := dispose(hs, test.policy, NewValue(15, 1), NewValue(125000, 100), 100)
+		if len(gains) != len(test.gains) {
+			t.Fatalf("%s: expected %d gains got %d", test.policy, len(test.gains), len(gains))
+		}
+		var proceeds Value
+		for i, g := range gains {
+			proceeds = proceeds.Add(g.Proceeds)
+			if f(g.Gain()) != test.gains[i] {
+				t.Errorf("%s: gain %d: expected %s got %s", test.policy, i, test.gains[i], f(g.Gain()))
+			}
+		}
+		if f(proceeds) != "1250.00" {
+			t.Errorf("%s: proceeds do not add up: %s", test.policy, f(proceeds))
+		}
+
+		var cost Value
+		for _, h := range hs {
+			cost = cost.Add(h.cost)
+		}
+		if f(cost) != test.left {
+			t.Errorf("%s: expected %s cost left got %s", test.policy, test.left, f(cost))
+		}
+	}
+}
+
+func TestGainsMultiSplit(t *testing.T) {
+	aapl := CommodityRef{ID: "AAPL", NS: "NASDAQ"}
+	shares := func(value, quantity int64) *Split {
+		s := testSplit("broker", value)
+		s.Quantity = NewValue(quantity, 1)
+		return s
+	}
+	posted := func(m time.Month) Date { return NewDate(date(2024, m, 1)) }
+	broker := testAccount("broker", "root", AccountTypeStock)
+	broker.Commodity, broker.SCU = aapl, 1
+
+	b := testBook(
+		t,
+		Accounts{
+			testAccount("root", "", AccountTypeRoot),
+			testAccount("bank", "root", AccountTypeBank),
+			broker,
+		},
+		Transactions{
+			// two purchases recorded in a single transaction.
+			{ID: "buy", DatePosted: posted(1), Splits: Splits{
+				shares(100, 1), shares(120, 1), testSplit("bank", -220),
+			}},
+			{ID: "sell", DatePosted: posted(2), Splits: Splits{
+				shares(-300, -2), testSplit("bank", 300),
+			}},
+		},
+	)
+
+	g, err := b.Gains(Accounts{broker}, GainsFIFO, testEUR.FQN(), date(2024, 3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Unrealized) != 0 {
+		t.Errorf("expected no holdings left got %d", len(g.Unrealized))
+	}
+	cost, proceeds := g.Realized.Total()
+	f := func(v Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+	if f(cost) != "220.00" || f(proceeds) != "300.00" {
+		t.Errorf("expected cost 220.00 and proceeds 300.00 got %s and %s", f(cost), f(proceeds))
+	}
+}
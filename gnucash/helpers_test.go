@@ -0,0 +1,47 @@
+package gnucash
+
+import "testing"
+
+var testEUR = CommodityRef{ID: "EUR", NS: CommodityCurrency}
+
+// testAccount returns an EUR account named after its id.
+func testAccount(id, parent string, typ AccountType) *Account {
+	return &Account{ID: GUID(id), Name: id, ParentID: GUID(parent), Type: typ, Commodity: testEUR, SCU: 100}
+}
+
+// testSplit returns a new split of v in the account's commodity.
+func testSplit(account string, v int64) *Split {
+	return &Split{
+		ID:              NewGUID(),
+		AccountID:       GUID(account),
+		ReconciledState: ReconciledStateNew,
+		Value:           NewValue(v, 1),
+		Quantity:        NewValue(v, 1),
+	}
+}
+
+// testBook returns a validated book holding accounts and txs, transactions
+// without a currency are in EUR.
+func testBook(t *testing.T, accounts Accounts, txs Transactions) *Book {
+	t.Helper()
+	b := &Book{ID: "book", Accounts: accounts, Transactions: txs}
+
+	seen := make(map[CommodityRef]struct{})
+	for _, a := range accounts {
+		if _, ok := seen[a.Commodity]; !ok && a.Commodity.ID != "" {
+			seen[a.Commodity] = struct{}{}
+			b.Commodities = append(b.Commodities, Commodity{CommodityRef: a.Commodity})
+		}
+	}
+	for _, tx := range txs {
+		if tx.Currency.ID == "" {
+			tx.Currency = testEUR
+		}
+	}
+
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
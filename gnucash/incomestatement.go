@@ -0,0 +1,92 @@
+package gnucash
+
+// Income statement section names.
+const (
+	SectionIncome   = "Income"
+	SectionExpenses = "Expenses"
+)
+
+// IncomeStatement returns the income and expenses in each period (one
+// column per period). Split values are converted to currency at their date
+// posted, currency defaults to the root account's currency. Income is
+// positive when earned, expenses when spent.
+func (b *Book) IncomeStatement(periods []Period, currency CommodityFQN, depth int) (*Statement, error) {
+	if currency == "" {
+		currency = b.defaultCurrency()
+	}
+
+	conv := b.Converter()
+	value := func(a *Account, col int) (Value, error) {
+		p := periods[col]
+		ts, _ := b.TransactionsLookup.Find(a.ID)
+		var v Value
+		for _, t := range ts {
+			if !p.Contains(t.DatePosted.Get()) {
+				continue
+			}
+			for _, s := range t.Splits {
+				if s.AccountID != a.ID {
+					continue
+				}
+				sv, err := conv.SplitValue(s, currency, t.DatePosted.Get())
+				if err != nil {
+					return v, err
+				}
+				v = v.Add(sv)
+			}
+		}
+		if a.Type.CreditNormal() {
+			v = v.Neg()
+		}
+
+		return v, nil
+	}
+
+	st := &Statement{Columns: make([]string, len(periods))}
+	for i, p := range periods {
+		st.Columns[i] = p.String()
+	}
+
+	sections := []struct {
+		name string
+		typ  AccountType
+	}{
+		{SectionIncome, AccountTypeIncome},
+		{SectionExpenses, AccountTypeExpense},
+	}
+	for _, s := range sections {
+		typ := s.typ
+		sec, err := statementSection(
+			s.name,
+			b.RootAccount,
+			func(a *Account) bool { return a.Type == typ },
+			len(periods),
+			depth,
+			value,
+		)
+		if err != nil {
+			return nil, err
+		}
+		st.Sections = append(st.Sections, sec)
+	}
+
+	return st, nil
+}
+
+// NetIncome returns income minus expenses per column of an income
+// statement.
+func (s *Statement) NetIncome() []Value {
+	income, _ := s.Section(SectionIncome)
+	expenses, _ := s.Section(SectionExpenses)
+	net := make([]Value, len(s.Columns))
+	for i := range net {
+		if i < len(income.Total) {
+			net[i] = net[i].Add(income.Total[i])
+		}
+		if i < len(expenses.Total) {
+			net[i] = net[i].Sub(expenses.Total[i])
+		}
+	}
+
+	return net
+}
@@ -0,0 +1,50 @@
+package gnucash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIncomeStatementMultiSplit(t *testing.T) {
+	posted := NewDate(time.Date(2024, 1, 15, 10, 59, 0, 0, time.UTC))
+	b := testBook(
+		t,
+		Accounts{
+			testAccount("root", "", AccountTypeRoot),
+			testAccount("bank", "root", AccountTypeBank),
+			testAccount("sales", "root", AccountTypeIncome),
+			testAccount("office", "root", AccountTypeExpense),
+		},
+		Transactions{
+			{ID: "invoice", DatePosted: posted, Splits: Splits{
+				testSplit("sales", -10), testSplit("sales", -5), testSplit("bank", 15),
+			}},
+			{ID: "supplies", DatePosted: posted, Splits: Splits{
+				testSplit("office", 3), testSplit("office", 4), testSplit("bank", -7),
+			}},
+		},
+	)
+
+	periods := []Period{{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	st, err := b.IncomeStatement(periods, testEUR.FQN(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := func(v Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+	income, _ := st.Section(SectionIncome)
+	expenses, _ := st.Section(SectionExpenses)
+	if len(income.Total) != 1 || f(income.Total[0]) != "15.00" {
+		t.Errorf("expected 15.00 income got %v", income.Total)
+	}
+	if len(expenses.Total) != 1 || f(expenses.Total[0]) != "7.00" {
+		t.Errorf("expected 7.00 expenses got %v", expenses.Total)
+	}
+	if net := st.NetIncome(); f(net[0]) != "8.00" {
+		t.Errorf("expected 8.00 net income got %s", f(net[0]))
+	}
+}
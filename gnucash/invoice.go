@@ -0,0 +1,217 @@
+package gnucash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Invoice is a customer invoice, vendor bill or employee expense voucher.
+type Invoice struct {
+	GUID              GUID         `xml:"guid"`
+	ID                string       `xml:"id"`
+	Owner             Owner        `xml:"owner"`
+	Opened            Date         `xml:"opened>date"`
+	Posted            Date         `xml:"posted>date"`
+	BillingID         string       `xml:"billing_id"`
+	Notes             string       `xml:"notes"`
+	Active            Flag         `xml:"active"`
+	PostTransactionID GUID         `xml:"posttxn"`
+	PostLotID         GUID         `xml:"postlot"`
+	PostAccountID     GUID         `xml:"postacc"`
+	Currency          CommodityRef `xml:"currency"`
+	TermsID           GUID         `xml:"terms"`
+	BillTo            Owner        `xml:"billto"`
+	Slots             Slots        `xml:"slots>slot"`
+	Extra             Nodes        `xml:",any"`
+
+	Customer        *Customer    `xml:"-"`
+	Vendor          *Vendor      `xml:"-"`
+	Job             *Job         `xml:"-"`
+	Terms           *BillTerm    `xml:"-"`
+	PostTransaction *Transaction `xml:"-"`
+	PostLot         *Lot         `xml:"-"`
+	PostAccount     *Account     `xml:"-"`
+	Entries         Entries      `xml:"-"`
+}
+
+func (inv *Invoice) String() string {
+	return fmt.Sprintf(
+		"%s %s\nID: %s\nOwner: %s\nPosted: %s\nOwed: %.2f",
+		strings.ToUpper(inv.Type()),
+		inv.ID,
+		inv.GUID,
+		inv.OwnerName(),
+		inv.Posted.Get().Format("2006-01-02"),
+		inv.Owed().Float64(),
+	)
+}
+
+// Type returns "invoice", "bill" or "voucher".
+func (inv *Invoice) Type() string {
+	switch {
+	case inv.Vendor != nil:
+		return "bill"
+	case inv.Customer != nil:
+		return "invoice"
+	case inv.Owner.Type == OwnerEmployee:
+		return "voucher"
+	case inv.Owner.Type == OwnerVendor:
+		return "bill"
+	}
+
+	return "invoice"
+}
+
+func (inv *Invoice) OwnerName() string {
+	switch {
+	case inv.Customer != nil:
+		return inv.Customer.Name
+	case inv.Vendor != nil:
+		return inv.Vendor.Name
+	}
+
+	return string(inv.Owner.ID)
+}
+
+// CreditNote reports whether the invoice is a credit note.
+func (inv *Invoice) CreditNote() bool {
+	return inv.Slots.KeyValue()["credit-note"].RawValue.Value == "1"
+}
+
+func (inv *Invoice) IsPosted() bool {
+	return inv.PostTransaction != nil
+}
+
+// sign makes amounts of bills and vouchers, which are credited to a payable
+// account, positive.
+func (inv *Invoice) sign(v Value) Value {
+	if inv.Type() == "invoice" {
+		return v
+	}
+
+	return v.Neg()
+}
+
+// Amount is the total the invoice was posted for.
+func (inv *Invoice) Amount() Value {
+	if inv.PostTransaction == nil {
+		return Value{}
+	}
+
+	return inv.sign(inv.PostTransaction.Splits.ValueForAccount(inv.PostAccountID, false))
+}
+
+// Owed is what is left to be paid, i.e.: the balance of the posted lot.
+func (inv *Invoice) Owed() Value {
+	if inv.PostLot == nil {
+		return inv.Amount()
+	}
+
+	return inv.sign(inv.PostLot.Balance())
+}
+
+// DueDate returns the due date GnuCash recorded on the posted transaction,
+// or calculates it from the invoice's terms.
+func (inv *Invoice) DueDate() time.Time {
+	if inv.PostTransaction != nil {
+		if due, ok := inv.PostTransaction.Slots.KeyValue()["trans-date-due"]; ok {
+			if t, err := due.TimeValue(); err == nil {
+				return t
+			}
+		}
+	}
+
+	if inv.Terms != nil {
+		return inv.Terms.DueDate(inv.Posted.Get())
+	}
+
+	return inv.Posted.Get()
+}
+
+// DaysOverdue returns the number of days past the due date, 0 if the invoice
+// is not due yet.
+func (inv *Invoice) DaysOverdue(now time.Time) int {
+	d := int(math.Floor(day(now).Sub(day(inv.DueDate())).Hours() / 24))
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+func (inv *Invoice) link(b *Book, l business) error {
+	owner := inv.Owner
+	if owner.Type == OwnerJob {
+		if inv.Job = l.jobs[owner.ID]; inv.Job != nil {
+			owner = inv.Job.Owner
+		}
+	}
+
+	switch owner.Type {
+	case OwnerCustomer:
+		inv.Customer = l.customers[owner.ID]
+		if inv.Customer != nil {
+			inv.Customer.Invoices = append(inv.Customer.Invoices, inv)
+		}
+	case OwnerVendor:
+		inv.Vendor = l.vendors[owner.ID]
+		if inv.Vendor != nil {
+			inv.Vendor.Invoices = append(inv.Vendor.Invoices, inv)
+		}
+	}
+
+	inv.Terms = l.billTerms[inv.TermsID]
+	inv.Entries = make(Entries, 0)
+	inv.PostTransaction, inv.PostLot, inv.PostAccount = nil, nil, nil
+	// unknown references are reported by Book.Check.
+	if inv.PostTransactionID != "" {
+		if inv.PostTransaction, _ = b.Transaction(inv.PostTransactionID); inv.PostTransaction != nil {
+			inv.PostTransaction.Invoice = inv
+		}
+	}
+	if inv.PostLotID != "" {
+		if inv.PostLot, _ = b.AccountsLookup.Lot(inv.PostLotID); inv.PostLot != nil {
+			inv.PostLot.Invoice = inv
+		}
+	}
+	if inv.PostAccountID != "" {
+		inv.PostAccount, _ = b.AccountsLookup.ByGUID(inv.PostAccountID)
+	}
+
+	return nil
+}
+
+type Invoices []*Invoice
+
+func (is Invoices) String() string {
+	str := make([]string, len(is))
+	for i, inv := range is {
+		str[i] = inv.String()
+	}
+
+	return strings.Join(str, "\n")
+}
+
+// Open returns the posted invoices that have not been paid in full.
+func (is Invoices) Open() Invoices {
+	l := make(Invoices, 0)
+	for _, inv := range is {
+		if inv.IsPosted() && !inv.Owed().IsZero() {
+			l = append(l, inv)
+		}
+	}
+
+	return l
+}
+
+// SortDueDate sorts the invoices by due date, oldest first.
+func (is Invoices) SortDueDate() Invoices {
+	sort.SliceStable(is, func(i, j int) bool {
+		return is[i].DueDate().Before(is[j].DueDate())
+	})
+
+	return is
+}
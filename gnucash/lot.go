@@ -0,0 +1,67 @@
+package gnucash
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lot groups the splits of an account that belong together, e.g.: a stock
+// purchase and its sales or an invoice and its payments.
+type Lot struct {
+	ID    GUID  `xml:"id"`
+	Slots Slots `xml:"slots>slot"`
+	Extra Nodes `xml:",any"`
+
+	Account *Account `xml:"-"`
+	Splits  Splits   `xml:"-"`
+	Invoice *Invoice `xml:"-"`
+}
+
+func (l *Lot) String() string {
+	return fmt.Sprintf("LOT\nID: %s\nTitle: %s\nSplits:\n%s", l.ID, l.Title(), l.Splits.String())
+}
+
+func (l *Lot) Title() string {
+	s, _ := l.Slots.KeyValue()["title"].StringValue()
+	return s
+}
+
+// Owner returns the customer, vendor, job or employee GnuCash assigned the
+// lot to, e.g.: when recording a payment.
+func (l *Lot) Owner() (Owner, bool) {
+	frame, ok := l.Slots.KeyValue()["gncOwner"]
+	if !ok {
+		return Owner{}, false
+	}
+
+	kv := frame.RawValue.Slots.KeyValue()
+	n, err := strconv.Atoi(strings.TrimSpace(kv["owner-type"].RawValue.Value))
+	if err != nil || ownerTypes[n] == "" {
+		return Owner{}, false
+	}
+
+	id := GUID(strings.TrimSpace(kv["owner-guid"].RawValue.Value))
+	return Owner{Type: ownerTypes[n], ID: id}, id != ""
+}
+
+// Balance is the quantity left in the lot.
+func (l *Lot) Balance() Value {
+	return l.Splits.Quantity()
+}
+
+// Closed reports whether the lot has splits that balance out.
+func (l *Lot) Closed() bool {
+	return len(l.Splits) != 0 && l.Balance().IsZero()
+}
+
+type Lots []*Lot
+
+func (ls Lots) String() string {
+	str := make([]string, len(ls))
+	for i, l := range ls {
+		str[i] = l.String()
+	}
+
+	return strings.Join(str, "\n")
+}
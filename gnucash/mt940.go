@@ -0,0 +1,253 @@
+package gnucash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	mt940Tag     = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	mt940Balance = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+	mt940Line    = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])[A-Z]?(\d+,\d*)[NFS][A-Z0-9]{3}([^/\n]*)(?://([^\n]*))?(?:\n((?s).*))?$`)
+	mt940Keyword = regexp.MustCompile(`/(TRTP|IBAN|BIC|NAME|REMI|EREF|MARF|CSID|CNTP|ORDP|BENM|ID|ADDR|PURP|ULTC|ULTD|ULTB|RTRN|CREF|ISDT|SVCL|PREF)/`)
+	mt940SubCode = regexp.MustCompile(`\?(\d{2})`)
+	mt940German  = regexp.MustCompile(`^\d{3}\?\d{2}`)
+)
+
+type mt940Field struct {
+	tag, value string
+}
+
+// mt940Fields splits a statement into its fields, joining continuation
+// lines with a newline. SWIFT block headers and message terminators are
+// skipped.
+func mt940Fields(r io.Reader) ([]mt940Field, error) {
+	fields := make([]mt940Field, 0)
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		line := strings.TrimRight(decodeText(s.Bytes()), "\r ")
+		if m := mt940Tag.FindStringSubmatch(line); m != nil {
+			fields = append(fields, mt940Field{m[1], m[2]})
+			continue
+		}
+		if line == "" || line[0] == '-' || line[0] == '{' || len(fields) == 0 {
+			continue
+		}
+		fields[len(fields)-1].value += "\n" + line
+	}
+
+	return fields, s.Err()
+}
+
+func mt940Amount(amount string, debit bool) (Value, error) {
+	v, err := ParseDecimal(strings.Replace(amount, ",", ".", 1))
+	if debit {
+		v = v.Neg()
+	}
+
+	return v, err
+}
+
+// mt940Date parses a YYMMDD date.
+func mt940Date(s string) (time.Time, error) {
+	return time.Parse("060102", s)
+}
+
+// ParseMT940 parses the statements of a SWIFT MT940 file.
+//
+// The information to the account owner (field 86) is understood in the
+// SEPA keyword layout (/NAME/…/IBAN/…/REMI/…) and the German ?-subfield
+// layout, otherwise it is used as the line's memo.
+func ParseMT940(r io.Reader) ([]BankStatement, error) {
+	fields, err := mt940Fields(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts := make([]BankStatement, 0, 1)
+	var st *BankStatement
+	var line *BankLine
+	flush := func() {
+		if st != nil {
+			stmts = append(stmts, *st)
+		}
+		st, line = nil, nil
+	}
+
+	for _, f := range fields {
+		if f.tag == "20" {
+			flush()
+			st = &BankStatement{Lines: make([]BankLine, 0)}
+			continue
+		}
+		if st == nil {
+			return nil, fmt.Errorf("mt940 field :%s: outside of a statement", f.tag)
+		}
+
+		switch f.tag {
+		case "25":
+			st.Account = strings.TrimSpace(f.value)
+		case "60F", "60M":
+			m := mt940Balance.FindStringSubmatch(f.value)
+			if m == nil {
+				return nil, fmt.Errorf("invalid mt940 balance '%s'", f.value)
+			}
+			st.Currency = m[3]
+		case "62F", "62M":
+			m := mt940Balance.FindStringSubmatch(f.value)
+			if m == nil {
+				return nil, fmt.Errorf("invalid mt940 balance '%s'", f.value)
+			}
+			if st.Balance, err = mt940Amount(m[4], m[1] == "D"); err != nil {
+				return nil, err
+			}
+			if st.BalanceDate, err = mt940Date(m[2]); err != nil {
+				return nil, err
+			}
+			if st.Currency == "" {
+				st.Currency = m[3]
+			}
+		case "61":
+			l, err := parseMT940Line(f.value)
+			if err != nil {
+				return nil, err
+			}
+			st.Lines = append(st.Lines, l)
+			line = &st.Lines[len(st.Lines)-1]
+		case "86":
+			if line != nil {
+				mt940Info(line, f.value)
+				line = nil
+			}
+		}
+	}
+	flush()
+
+	if len(stmts) == 0 {
+		return nil, errors.New("not an mt940 file")
+	}
+
+	return stmts, nil
+}
+
+// parseMT940Line parses a statement line (field 61):
+// value date, optional entry date, debit / credit mark, amount, type,
+// references and supplementary details.
+func parseMT940Line(v string) (BankLine, error) {
+	var l BankLine
+	m := mt940Line.FindStringSubmatch(v)
+	if m == nil {
+		return l, fmt.Errorf("invalid mt940 statement line '%s'", v)
+	}
+
+	var err error
+	if l.ValueDate, err = mt940Date(m[1]); err != nil {
+		return l, err
+	}
+	l.Date = l.ValueDate
+	if m[2] != "" {
+		// the entry date has no year, it is close to the value date.
+		d, err := time.Parse("0102", m[2])
+		if err != nil {
+			return l, err
+		}
+		l.Date = time.Date(l.ValueDate.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+		if diff := l.Date.Sub(l.ValueDate); diff > 180*24*time.Hour {
+			l.Date = l.Date.AddDate(-1, 0, 0)
+		} else if diff < -180*24*time.Hour {
+			l.Date = l.Date.AddDate(1, 0, 0)
+		}
+	}
+
+	if l.Amount, err = mt940Amount(m[4], m[3] == "D" || m[3] == "RC"); err != nil {
+		return l, err
+	}
+
+	if ref := strings.TrimSpace(m[6]); ref != "" && ref != "NONREF" {
+		l.ID = ref
+	}
+	l.Description = strings.TrimSpace(strings.ReplaceAll(m[7], "\n", " "))
+
+	return l, nil
+}
+
+// mt940Info fills in the line from the information to the account owner.
+func mt940Info(l *BankLine, info string) {
+	flat := strings.ReplaceAll(info, "\n", "")
+	switch {
+	case strings.HasPrefix(flat, "/") && mt940Keyword.MatchString(flat):
+		ix := mt940Keyword.FindAllStringSubmatchIndex(flat, -1)
+		for i, m := range ix {
+			end := len(flat)
+			if i+1 < len(ix) {
+				end = ix[i+1][0]
+			}
+			key, value := flat[m[2]:m[3]], strings.TrimSpace(flat[m[1]:end])
+			switch key {
+			case "NAME":
+				l.Description = value
+			case "IBAN":
+				l.IBAN = value
+			case "EREF":
+				if value != "NOTPROVIDED" {
+					l.EndToEndID = value
+				}
+			case "REMI":
+				value = strings.TrimPrefix(value, "USTD//")
+				value = strings.TrimPrefix(value, "STRD/CUR/")
+				l.Memo = strings.TrimSpace(strings.TrimSuffix(value, "/"))
+			case "CNTP":
+				// account/bic/name/city
+				p := strings.Split(value, "/")
+				if len(p) > 0 && p[0] != "" {
+					l.IBAN = p[0]
+				}
+				if len(p) > 2 && p[2] != "" {
+					l.Description = p[2]
+				}
+			}
+		}
+	case mt940German.MatchString(flat):
+		sub := make(map[string]string)
+		ix := mt940SubCode.FindAllStringSubmatchIndex(flat, -1)
+		for i, m := range ix {
+			end := len(flat)
+			if i+1 < len(ix) {
+				end = ix[i+1][0]
+			}
+			sub[flat[m[2]:m[3]]] += flat[m[1]:end]
+		}
+
+		remi := ""
+		for _, k := range []string{"20", "21", "22", "23", "24", "25", "26", "27", "28", "29", "60", "61", "62", "63"} {
+			remi += sub[k]
+		}
+		if ix := strings.Index(remi, "SVWZ+"); ix >= 0 {
+			if ref := strings.Index(remi, "EREF+"); ref >= 0 && ref < ix {
+				if e := strings.TrimSpace(remi[ref+5 : ix]); e != "NOTPROVIDED" {
+					l.EndToEndID = e
+				}
+			}
+			remi = remi[ix+5:]
+		}
+		l.Memo = strings.TrimSpace(remi)
+		l.IBAN = strings.TrimSpace(sub["31"])
+		if name := strings.TrimSpace(sub["32"] + sub["33"]); name != "" {
+			l.Description = name
+		} else if l.Description == "" {
+			l.Description = strings.TrimSpace(sub["00"])
+		}
+	default:
+		l.Memo = strings.TrimSpace(strings.ReplaceAll(info, "\n", " "))
+	}
+
+	if l.Description == "" {
+		l.Description, l.Memo = l.Memo, ""
+	}
+}
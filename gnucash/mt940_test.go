@@ -0,0 +1,66 @@
+package gnucash
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const mt940 = `{1:F01ABNANL2AXXXX0000000000}{2:I940ABNANL2AXXXXN}{4:
+:20:ABN AMRO BANK NV
+:25:NL91ABNA0417164300
+:28C:1/1
+:60F:C231229EUR1000,00
+:61:2401050105D12,50NTRFNONREF//B4A05
+:86:/TRTP/SEPA OVERBOEKING/IBAN/NL20INGB0001234567/BIC/INGBNL2A/NAME/
+Bakery/REMI/bread/EREF/NOTPROVIDED
+:61:2312290102C2000,NMSCNONREF
+:86:166?00GUTSCHRIFT?20EREF+E2E9 SVWZ+Salary?21 January?31DE89370400440532013000?32ACME GmbH
+:61:240110D5,NMSC
+:86:ATM withdrawal
+:62F:C240131EUR2982,50
+-}
+`
+
+func TestParseMT940(t *testing.T) {
+	stmts, err := ParseMT940(strings.NewReader(mt940))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 {
+		t.Fatalf("expected 1 statement got %d", len(stmts))
+	}
+
+	st := stmts[0]
+	if st.Account != "NL91ABNA0417164300" || st.Currency != "EUR" {
+		t.Errorf("unexpected account %s %s", st.Account, st.Currency)
+	}
+	if bal := fmt.Sprintf("%.2f %s", st.Balance.Float64(), st.BalanceDate.Format("2006-01-02")); bal != "2982.50 2024-01-31" {
+		t.Errorf("unexpected balance %s", bal)
+	}
+
+	exp := []string{
+		"2024-01-05 2024-01-05 -12.50 B4A05  Bakery NL20INGB0001234567 bread",
+		"2024-01-02 2023-12-29 2000.00  E2E9 ACME GmbH DE89370400440532013000 Salary January",
+		"2024-01-10 2024-01-10 -5.00   ATM withdrawal  ",
+	}
+	if len(st.Lines) != len(exp) {
+		t.Fatalf("expected %d lines got %d", len(exp), len(st.Lines))
+	}
+	for i, l := range st.Lines {
+		str := fmt.Sprintf(
+			"%s %s %.2f %s %s %s %s %s",
+			l.Date.Format("2006-01-02"),
+			l.ValueDate.Format("2006-01-02"),
+			l.Amount.Float64(),
+			l.ID,
+			l.EndToEndID,
+			l.Description,
+			l.IBAN,
+			l.Memo,
+		)
+		if str != exp[i] {
+			t.Errorf("expected '%s' got '%s'", exp[i], str)
+		}
+	}
+}
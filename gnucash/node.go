@@ -0,0 +1,100 @@
+package gnucash
+
+import (
+	nxml "encoding/xml"
+	"strings"
+)
+
+// Node holds an xml element gocash does not model so it survives a
+// Read / Write round-trip untouched.
+type Node struct {
+	Name     nxml.Name
+	Attrs    []nxml.Attr
+	Text     string
+	Children Nodes
+}
+
+func (n *Node) UnmarshalXML(d *nxml.Decoder, start nxml.StartElement) error {
+	n.Name = start.Name
+	n.Attrs = start.Attr
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case nxml.StartElement:
+			c := Node{}
+			if err := c.UnmarshalXML(d, t); err != nil {
+				return err
+			}
+			n.Children = append(n.Children, c)
+		case nxml.CharData:
+			n.Text += string(t)
+		case nxml.EndElement:
+			return nil
+		}
+	}
+}
+
+// child returns the first descendant at the given path of local names.
+func (n Node) child(path ...string) (Node, bool) {
+	for _, p := range path {
+		c := n.Children.Filter(p)
+		if len(c) == 0 {
+			return Node{}, false
+		}
+		n = c[0]
+	}
+
+	return n, true
+}
+
+// text returns the trimmed text of the descendant at the given path.
+func (n Node) text(path ...string) string {
+	c, ok := n.child(path...)
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(c.Text)
+}
+
+func (n Node) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+type Nodes []Node
+
+func (ns Nodes) Filter(local string) Nodes {
+	l := make(Nodes, 0)
+	for _, n := range ns {
+		if n.Name.Local == local {
+			l = append(l, n)
+		}
+	}
+
+	return l
+}
+
+func (ns Nodes) Exclude(local ...string) Nodes {
+	l := make(Nodes, 0, len(ns))
+outer:
+	for _, n := range ns {
+		for _, name := range local {
+			if n.Name.Local == name {
+				continue outer
+			}
+		}
+		l = append(l, n)
+	}
+
+	return l
+}
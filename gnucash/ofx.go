@@ -0,0 +1,220 @@
+package gnucash
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// ofxNode is an element of an OFX document. Aggregates have children,
+// elements have a value.
+type ofxNode struct {
+	name     string
+	value    string
+	children []*ofxNode
+}
+
+func (n *ofxNode) child(name string) *ofxNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// get returns the value of the element at the given path of names.
+func (n *ofxNode) get(path ...string) string {
+	for _, p := range path {
+		if n = n.child(p); n == nil {
+			return ""
+		}
+	}
+
+	return n.value
+}
+
+// find calls cb for every aggregate named name below n.
+func (n *ofxNode) find(name string, cb func(*ofxNode)) {
+	for _, c := range n.children {
+		if c.name == name {
+			cb(c)
+			continue
+		}
+		c.find(name, cb)
+	}
+}
+
+// parseOFX parses both OFX 1.x SGML, where elements need not be closed, and
+// OFX 2.x XML. Headers, processing instructions and comments are skipped.
+func parseOFX(doc string) (*ofxNode, error) {
+	root := &ofxNode{}
+	stack := []*ofxNode{root}
+	var leaf *ofxNode
+	for len(doc) != 0 {
+		ix := strings.IndexByte(doc, '<')
+		if ix < 0 {
+			ix = len(doc)
+		}
+		text := strings.TrimSpace(doc[:ix])
+		doc = doc[ix:]
+		if text != "" && len(stack) > 1 {
+			top := stack[len(stack)-1]
+			top.value = html.UnescapeString(text)
+			stack = stack[:len(stack)-1]
+			leaf = top
+		}
+		if len(doc) == 0 {
+			break
+		}
+
+		end := strings.IndexByte(doc, '>')
+		if end < 0 {
+			return nil, errors.New("unterminated ofx tag")
+		}
+		tag := doc[1:end]
+		doc = doc[end+1:]
+		if tag == "" || tag[0] == '?' || tag[0] == '!' {
+			continue
+		}
+
+		if tag[0] == '/' {
+			name := strings.ToUpper(strings.TrimSpace(tag[1:]))
+			if leaf != nil && leaf.name == name {
+				leaf = nil
+				continue
+			}
+			leaf = nil
+			found := false
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name == name {
+					stack, found = stack[:i], true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected ofx closing tag '%s'", name)
+			}
+			continue
+		}
+
+		leaf = nil
+		selfClosing := strings.HasSuffix(tag, "/")
+		n := &ofxNode{name: strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(tag, "/")))}
+		top := stack[len(stack)-1]
+		top.children = append(top.children, n)
+		if !selfClosing {
+			stack = append(stack, n)
+		}
+	}
+
+	ofx := root.child("OFX")
+	if ofx == nil {
+		return nil, errors.New("not an ofx document")
+	}
+
+	return ofx, nil
+}
+
+// parseOFXDate parses the date part of an OFX datetime, i.e.:
+// YYYYMMDD[HHMMSS[.XXX]][[gmt offset[:tz name]]]. The time is ignored as
+// banks report the local date.
+func parseOFXDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("invalid ofx date '%s'", s)
+	}
+
+	return time.Parse("20060102", s[:8])
+}
+
+// parseOFXAmount parses an OFX amount, which some banks write with a
+// decimal comma.
+func parseOFXAmount(s string) (Value, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, ".") {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+
+	return ParseDecimal(s)
+}
+
+// ParseOFX parses the bank and credit card statements of an OFX (or QFX)
+// file, either OFX 1.x SGML or 2.x XML. Files that are not utf-8 are read as
+// latin-1 (e.g.: CHARSET:1252).
+//
+// The line's Description is the payee's name, falling back to the memo if
+// the bank lists none, and its ID is the FITID.
+func ParseOFX(r io.Reader) ([]BankStatement, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ofx, err := parseOFX(decodeText(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	stmts := make([]BankStatement, 0, 1)
+	var ferr error
+	parse := func(rs *ofxNode, from string) {
+		if ferr != nil {
+			return
+		}
+		st := BankStatement{
+			Account:  rs.get(from, "ACCTID"),
+			Currency: rs.get("CURDEF"),
+			Lines:    make([]BankLine, 0),
+		}
+
+		if bal := rs.child("LEDGERBAL"); bal != nil {
+			if st.Balance, ferr = parseOFXAmount(bal.get("BALAMT")); ferr != nil {
+				return
+			}
+			if st.BalanceDate, ferr = parseOFXDate(bal.get("DTASOF")); ferr != nil {
+				return
+			}
+		}
+
+		list := rs.child("BANKTRANLIST")
+		if list == nil {
+			stmts = append(stmts, st)
+			return
+		}
+		for _, tr := range list.children {
+			if tr.name != "STMTTRN" {
+				continue
+			}
+			l := BankLine{
+				Description: tr.get("NAME"),
+				Memo:        tr.get("MEMO"),
+				ID:          tr.get("FITID"),
+			}
+			if l.Description == "" {
+				l.Description = tr.get("PAYEE", "NAME")
+			}
+			if l.Description == "" {
+				l.Description, l.Memo = l.Memo, ""
+			}
+			if l.Date, ferr = parseOFXDate(tr.get("DTPOSTED")); ferr != nil {
+				return
+			}
+			if l.Amount, ferr = parseOFXAmount(tr.get("TRNAMT")); ferr != nil {
+				return
+			}
+			st.Lines = append(st.Lines, l)
+		}
+
+		stmts = append(stmts, st)
+	}
+
+	ofx.find("STMTRS", func(n *ofxNode) { parse(n, "BANKACCTFROM") })
+	ofx.find("CCSTMTRS", func(n *ofxNode) { parse(n, "CCACCTFROM") })
+
+	return stmts, ferr
+}
@@ -0,0 +1,115 @@
+package gnucash
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+CHARSET:1252
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20240201</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>1<STMTRS>
+<CURDEF>EUR
+<BANKACCTFROM><BANKID>ABNA<ACCTID>NL91ABNA0417164300<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST><DTSTART>20240101<DTEND>20240131
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20240105120000.000[+1:CET]<TRNAMT>-12,50<FITID>A1<NAME>Bakery &amp; Co<MEMO>bread</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20240125<TRNAMT>2000.00<FITID>A2<MEMO>Salary</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>1987.50<DTASOF>20240131</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <CCSTMTRS>
+        <CURDEF>USD</CURDEF>
+        <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20240302</DTPOSTED>
+            <TRNAMT>-4.25</TRNAMT>
+            <FITID>X9</FITID>
+            <PAYEE><NAME>Coffee</NAME></PAYEE>
+            <MEMO></MEMO>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		doc     string
+		account string
+		cur     string
+		lines   []string
+	}{
+		{
+			ofxSGML,
+			"NL91ABNA0417164300",
+			"EUR",
+			[]string{
+				"2024-01-05 -12.50 A1 Bakery & Co bread",
+				"2024-01-25 2000.00 A2 Salary ",
+			},
+		},
+		{
+			// CHARSET:1252, not valid utf-8.
+			strings.Replace(ofxSGML, "Bakery", "Caf\xe9", 1),
+			"NL91ABNA0417164300",
+			"EUR",
+			[]string{
+				"2024-01-05 -12.50 A1 Café & Co bread",
+				"2024-01-25 2000.00 A2 Salary ",
+			},
+		},
+		{
+			ofxXML,
+			"4111",
+			"USD",
+			[]string{"2024-03-02 -4.25 X9 Coffee "},
+		},
+	}
+
+	for _, test := range tests {
+		stmts, err := ParseOFX(strings.NewReader(test.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stmts) != 1 {
+			t.Fatalf("expected 1 statement got %d", len(stmts))
+		}
+		st := stmts[0]
+		if st.Account != test.account || st.Currency != test.cur {
+			t.Errorf("expected %s %s got %s %s", test.account, test.cur, st.Account, st.Currency)
+		}
+		if len(st.Lines) != len(test.lines) {
+			t.Fatalf("expected %d lines got %d", len(test.lines), len(st.Lines))
+		}
+		for i, l := range st.Lines {
+			str := fmt.Sprintf(
+				"%s %.2f %s %s %s",
+				l.Date.Format("2006-01-02"),
+				l.Amount.Float64(),
+				l.ID,
+				l.Description,
+				l.Memo,
+			)
+			if str != test.lines[i] {
+				t.Errorf("expected '%s' got '%s'", test.lines[i], str)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package gnucash
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Granularity is the length of the periods of a report.
+type Granularity int
+
+const (
+	// GranularityNone reports the whole range as a single period.
+	GranularityNone Granularity = iota
+	GranularityWeek
+	GranularityMonth
+	GranularityQuarter
+	GranularityYear
+)
+
+func (g Granularity) String() string {
+	switch g {
+	case GranularityWeek:
+		return "week"
+	case GranularityMonth:
+		return "month"
+	case GranularityQuarter:
+		return "quarter"
+	case GranularityYear:
+		return "year"
+	}
+
+	return "none"
+}
+
+func ParseGranularity(s string) (Granularity, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return GranularityNone, nil
+	case "week":
+		return GranularityWeek, nil
+	case "month":
+		return GranularityMonth, nil
+	case "quarter":
+		return GranularityQuarter, nil
+	case "year":
+		return GranularityYear, nil
+	}
+
+	return 0, fmt.Errorf("unknown period '%s'", s)
+}
+
+// Period is a range of time, End is exclusive.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf(
+		"%s - %s",
+		p.Start.Format("2006-01-02"),
+		p.End.AddDate(0, 0, -1).Format("2006-01-02"),
+	)
+}
+
+// Contains reports whether t falls within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// FiscalYear returns the start of the fiscal year t falls in, for fiscal
+// years starting at the given month and day.
+func FiscalYear(t time.Time, month time.Month, day int) time.Time {
+	start := fiscalDate(t.Year(), month, day, t.Location())
+	if t.Before(start) {
+		start = fiscalDate(t.Year()-1, month, day, t.Location())
+	}
+
+	return start
+}
+
+func fiscalDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	if dim := daysIn(year, month); day > dim {
+		day = dim
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// Periods splits [from, to) into periods of the given granularity. Months,
+// quarters and years are aligned to the fiscal year starting at the given
+// month and day, weeks start on monday. The first and last period are
+// clipped to the range.
+func Periods(from, to time.Time, g Granularity, fiscalMonth time.Month, fiscalDay int) []Period {
+	if !from.Before(to) {
+		return nil
+	}
+	if g == GranularityNone {
+		return []Period{{from, to}}
+	}
+
+	y, m, d := from.Date()
+	from = time.Date(y, m, d, 0, 0, 0, 0, from.Location())
+	var next func(n int) time.Time
+	switch g {
+	case GranularityWeek:
+		start := from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+		next = func(n int) time.Time { return start.AddDate(0, 0, 7*n) }
+	default:
+		months := map[Granularity]int{
+			GranularityMonth:   1,
+			GranularityQuarter: 3,
+			GranularityYear:    12,
+		}[g]
+		fy := FiscalYear(from, fiscalMonth, fiscalDay)
+		next = func(n int) time.Time {
+			m := time.Date(fy.Year(), fy.Month()+time.Month(n*months), 1, 0, 0, 0, 0, fy.Location())
+			return fiscalDate(m.Year(), m.Month(), fiscalDay, m.Location())
+		}
+	}
+
+	l := make([]Period, 0)
+	for n := 0; ; n++ {
+		p := Period{next(n), next(n + 1)}
+		if !p.End.After(from) {
+			continue
+		}
+		if !p.Start.Before(to) {
+			break
+		}
+		if p.Start.Before(from) {
+			p.Start = from
+		}
+		if p.End.After(to) {
+			p.End = to
+		}
+		l = append(l, p)
+	}
+
+	return l
+}
@@ -0,0 +1,52 @@
+package gnucash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriods(t *testing.T) {
+	tests := []struct {
+		from, to time.Time
+		g        Granularity
+		month    time.Month
+		day      int
+		exp      []string
+	}{
+		{
+			date(2024, 1, 15), date(2024, 4, 1), GranularityMonth, time.January, 1,
+			[]string{"2024-01-15 - 2024-01-31", "2024-02-01 - 2024-02-29", "2024-03-01 - 2024-03-31"},
+		},
+		{
+			date(2024, 1, 1), date(2025, 1, 1), GranularityQuarter, time.April, 6,
+			[]string{
+				"2024-01-01 - 2024-01-05",
+				"2024-01-06 - 2024-04-05",
+				"2024-04-06 - 2024-07-05",
+				"2024-07-06 - 2024-10-05",
+				"2024-10-06 - 2024-12-31",
+			},
+		},
+		{
+			date(2024, 1, 3), date(2024, 1, 17), GranularityWeek, time.January, 1,
+			[]string{"2024-01-03 - 2024-01-07", "2024-01-08 - 2024-01-14", "2024-01-15 - 2024-01-16"},
+		},
+		{
+			date(2023, 5, 1), date(2024, 8, 1), GranularityYear, time.July, 1,
+			[]string{"2023-05-01 - 2023-06-30", "2023-07-01 - 2024-06-30", "2024-07-01 - 2024-07-31"},
+		},
+	}
+
+	for _, test := range tests {
+		ps := Periods(test.from, test.to, test.g, test.month, test.day)
+		if len(ps) != len(test.exp) {
+			t.Errorf("%s: expected %d periods got %v", test.g, len(test.exp), ps)
+			continue
+		}
+		for i, p := range ps {
+			if p.String() != test.exp[i] {
+				t.Errorf("%s: expected %s got %s", test.g, test.exp[i], p)
+			}
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package gnucash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// CashFlow is an amount invested (negative) or returned (positive) at a
+// point in time.
+type CashFlow struct {
+	Date   time.Time
+	Amount float64
+}
+
+// XIRR returns the annualized internal rate of return of the cash flows,
+// i.e.: the money-weighted return.
+func XIRR(flows []CashFlow) (float64, bool) {
+	if len(flows) < 2 {
+		return math.NaN(), false
+	}
+
+	var pos, neg bool
+	first := flows[0].Date
+	for _, f := range flows {
+		pos, neg = pos || f.Amount > 0, neg || f.Amount < 0
+		if f.Date.Before(first) {
+			first = f.Date
+		}
+	}
+	if !pos || !neg {
+		return math.NaN(), false
+	}
+
+	npv := func(r float64) (v, d float64) {
+		for _, f := range flows {
+			y := f.Date.Sub(first).Hours() / 24 / 365
+			x := math.Pow(1+r, y)
+			v += f.Amount / x
+			d -= y * f.Amount / (x * (1 + r))
+		}
+		return
+	}
+
+	r := 0.1
+	for i := 0; i < 50; i++ {
+		v, d := npv(r)
+		if math.Abs(v) < 1e-7 {
+			return r, true
+		}
+		if d == 0 {
+			break
+		}
+		n := r - v/d
+		if n <= -1 || math.IsNaN(n) || math.IsInf(n, 0) {
+			break
+		}
+		r = n
+	}
+
+	// newton did not converge, bisect instead.
+	lo, hi := -0.9999, 1.0
+	for v, _ := npv(hi); v > 0 && hi < 1e6; v, _ = npv(hi) {
+		hi *= 2
+	}
+	vlo, _ := npv(lo)
+	if vhi, _ := npv(hi); vlo*vhi > 0 {
+		return math.NaN(), false
+	}
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		v, _ := npv(mid)
+		if math.Abs(v) < 1e-7 {
+			return mid, true
+		}
+		if (v > 0) == (vlo > 0) {
+			lo, vlo = mid, v
+			continue
+		}
+		hi = mid
+	}
+
+	return (lo + hi) / 2, true
+}
+
+// Holding is the position of a single commodity holding account.
+type Holding struct {
+	Account  *Account
+	Currency CommodityFQN
+	Shares   Value
+	Price    Value
+	Cost     Value
+	Market   Value
+
+	// TWR is the time-weighted return over the period, NaN if unknown.
+	TWR float64
+	// XIRR is the annualized money-weighted return over the period, NaN if
+	// unknown.
+	XIRR float64
+	// Flows are the cash flows used for XIRR: the value at the start of the
+	// period, purchases, sales and the value at the end of the period.
+	Flows []CashFlow
+}
+
+func (h Holding) Gain() Value {
+	return h.Market.Sub(h.Cost)
+}
+
+type Portfolio struct {
+	From     time.Time
+	To       time.Time
+	Holdings []Holding
+	// Skipped holds the accounts that could not be valued and why.
+	Skipped []error
+}
+
+// Total sums the cost and market value of all holdings.
+func (p *Portfolio) Total() (cost, market Value) {
+	for _, h := range p.Holdings {
+		cost, market = cost.Add(h.Cost), market.Add(h.Market)
+	}
+
+	return
+}
+
+// XIRR returns the money-weighted return of all holdings combined.
+func (p *Portfolio) XIRR() (float64, bool) {
+	flows := make([]CashFlow, 0)
+	for _, h := range p.Holdings {
+		flows = append(flows, h.Flows...)
+	}
+
+	return XIRR(flows)
+}
+
+// Portfolio values all accounts that hold a commodity other than a
+// currency (and have shares or activity) in the period [from, to].
+// Cost basis is determined according to policy (see Book.Gains), values are
+// in currency or, if empty, the currency of the account's first transaction.
+// Only purchases and sales count as cash flows, dividends and fees booked to
+// other accounts are not taken into account.
+//
+// When the price database has no price for a date the price paid in the
+// last purchase or sale is used instead. Accounts that still can not be
+// valued, e.g.: for lack of exchange rates, are left out and listed in
+// Skipped.
+func (b *Book) Portfolio(accounts Accounts, policy GainsPolicy, currency CommodityFQN, from, to time.Time) (*Portfolio, error) {
+	conv := b.Converter()
+	p := &Portfolio{From: from, To: to, Holdings: make([]Holding, 0), Skipped: make([]error, 0)}
+	for _, a := range accounts {
+		if a.Commodity.IsCurrency() || a.Type == AccountTypeRoot {
+			continue
+		}
+
+		h, ok, err := b.holding(conv, a, policy, currency, from, to)
+		if err != nil {
+			p.Skipped = append(p.Skipped, fmt.Errorf("account '%s': %w", a.FQN, err))
+			continue
+		}
+		if ok {
+			p.Holdings = append(p.Holdings, h)
+		}
+	}
+
+	return p, nil
+}
+
+func (b *Book) holding(conv *Converter, a *Account, policy GainsPolicy, currency CommodityFQN, from, to time.Time) (Holding, bool, error) {
+	h := Holding{Account: a, Currency: currency, TWR: math.NaN(), XIRR: math.NaN()}
+
+	ts, _ := b.TransactionsLookup.Find(a.ID)
+	splits := make(Splits, 0, len(ts))
+	for _, t := range ts {
+		if t.DatePosted.Get().After(to) {
+			continue
+		}
+		for _, s := range t.Splits {
+			if s.AccountID == a.ID && !s.Quantity.IsZero() {
+				splits = append(splits, s)
+			}
+		}
+	}
+	if len(splits) == 0 {
+		return h, false, nil
+	}
+	sort.SliceStable(splits, func(i, j int) bool {
+		return splits[i].Transaction.DatePosted.Get().Before(splits[j].Transaction.DatePosted.Get())
+	})
+	if h.Currency == "" {
+		h.Currency = splits[0].Transaction.Currency.FQN()
+	}
+
+	com := a.Commodity.FQN()
+	// paid is the price per share of the last purchase or sale, used when
+	// the price database has no price.
+	var paid *Value
+	pay := func(s *Split) (Value, error) {
+		v, err := conv.SplitValue(s, h.Currency, time.Time{})
+		if err != nil {
+			return v, err
+		}
+		p := v.Div(s.Quantity).Reduce()
+		paid = &p
+		return v, nil
+	}
+	price := func(at time.Time) (Value, error) {
+		r, err := conv.Rate(com, h.Currency, at)
+		if err != nil && paid != nil {
+			return *paid, nil
+		}
+		return r, err
+	}
+	market := func(shares Value, at time.Time) (Value, error) {
+		v, err := conv.Convert(shares, com, h.Currency, at)
+		if err != nil && paid != nil {
+			return shares.Mul(*paid).Convert(conv.Fraction(h.Currency), RoundBankers), nil
+		}
+		return v, err
+	}
+	value := func(shares Value, at time.Time) (float64, error) {
+		if shares.IsZero() {
+			return 0, nil
+		}
+		v, err := market(shares, at)
+		return v.Float64(), err
+	}
+
+	var shares Value
+	i := 0
+	for ; i < len(splits) && !splits[i].Transaction.DatePosted.Get().After(from); i++ {
+		shares = shares.Add(splits[i].Quantity)
+		if _, err := pay(splits[i]); err != nil {
+			return h, false, err
+		}
+	}
+
+	active := i < len(splits)
+	if shares.IsZero() && !active {
+		return h, false, nil
+	}
+
+	start, err := value(shares, from)
+	if err != nil {
+		return h, false, err
+	}
+	if start != 0 {
+		h.Flows = append(h.Flows, CashFlow{from, -start})
+	}
+
+	twr, known := 1.0, false
+	for i < len(splits) {
+		date := splits[i].Transaction.DatePosted.Get()
+		before, err := value(shares, date)
+		if err != nil {
+			return h, false, err
+		}
+		if start != 0 {
+			twr *= before / start
+			known = true
+		}
+
+		for ; i < len(splits) && splits[i].Transaction.DatePosted.Get().Equal(date); i++ {
+			s := splits[i]
+			shares = shares.Add(s.Quantity)
+			v, err := pay(s)
+			if err != nil {
+				return h, false, err
+			}
+			h.Flows = append(h.Flows, CashFlow{date, -v.Float64()})
+		}
+
+		if start, err = value(shares, date); err != nil {
+			return h, false, err
+		}
+	}
+
+	h.Shares = shares.Reduce()
+	if !h.Shares.IsZero() {
+		if h.Price, err = price(to); err != nil {
+			return h, false, err
+		}
+		if h.Market, err = market(h.Shares, to); err != nil {
+			return h, false, err
+		}
+	}
+
+	end := h.Market.Float64()
+	if start != 0 {
+		twr *= end / start
+		known = true
+	}
+	if known {
+		h.TWR = twr - 1
+	}
+	if end != 0 {
+		h.Flows = append(h.Flows, CashFlow{to, end})
+	}
+	h.XIRR, _ = XIRR(h.Flows)
+
+	gains, err := b.gains(Accounts{a}, policy, h.Currency, to, false)
+	if err != nil {
+		return h, false, err
+	}
+	h.Cost, _ = gains.Unrealized.Total()
+
+	return h, true, nil
+}
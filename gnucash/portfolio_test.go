@@ -0,0 +1,94 @@
+package gnucash
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestXIRR(t *testing.T) {
+	tests := []struct {
+		flows []CashFlow
+		exp   float64
+	}{
+		{[]CashFlow{{date(2023, 1, 1), -1000}, {date(2024, 1, 1), 1100}}, 0.1},
+		{[]CashFlow{{date(2023, 1, 1), -1000}, {date(2024, 1, 1), 500}}, -0.5},
+		{
+			[]CashFlow{
+				{date(2008, 1, 1), -10000},
+				{date(2008, 3, 1), 2750},
+				{date(2008, 10, 30), 4250},
+				{date(2009, 2, 15), 3250},
+				{date(2009, 4, 1), 2750},
+			},
+			0.373362535,
+		},
+	}
+
+	for _, test := range tests {
+		r, ok := XIRR(test.flows)
+		if !ok || math.Abs(r-test.exp) > 1e-6 {
+			t.Errorf("expected %f got %f (%t)", test.exp, r, ok)
+		}
+	}
+
+	if _, ok := XIRR([]CashFlow{{date(2023, 1, 1), 1000}, {date(2024, 1, 1), 1100}}); ok {
+		t.Error("expected no return without investment")
+	}
+}
+
+func TestPortfolioWithoutPrices(t *testing.T) {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	usd := CommodityRef{ID: "USD", NS: CommodityCurrency}
+	aapl := CommodityRef{ID: "AAPL", NS: "NASDAQ"}
+	msft := CommodityRef{ID: "MSFT", NS: "NASDAQ"}
+	split := func(account string, value, quantity int64) *Split {
+		return &Split{
+			ID:              NewGUID(),
+			AccountID:       GUID(account),
+			ReconciledState: ReconciledStateNew,
+			Value:           NewValue(value, 1),
+			Quantity:        NewValue(quantity, 1),
+		}
+	}
+	tx := func(id string, currency CommodityRef, posted time.Time, splits ...*Split) *Transaction {
+		return &Transaction{ID: GUID(id), Currency: currency, DatePosted: NewDate(posted), Splits: splits}
+	}
+
+	b := &Book{
+		ID:          "book",
+		Commodities: Commodities{{CommodityRef: eur}, {CommodityRef: usd}, {CommodityRef: aapl}, {CommodityRef: msft}},
+		Accounts: Accounts{
+			{ID: "root", Type: AccountTypeRoot},
+			{ID: "bank", Name: "Bank", ParentID: "root", Type: AccountTypeBank, Commodity: eur, SCU: 100},
+			{ID: "usd", Name: "USD", ParentID: "root", Type: AccountTypeBank, Commodity: usd, SCU: 100},
+			{ID: "aapl", Name: "AAPL", ParentID: "root", Type: AccountTypeStock, Commodity: aapl, SCU: 1},
+			{ID: "msft", Name: "MSFT", ParentID: "root", Type: AccountTypeStock, Commodity: msft, SCU: 1},
+		},
+		Transactions: Transactions{
+			// two purchases in a single transaction, there are no prices.
+			tx("buy", eur, date(2024, 1, 1), split("aapl", 100, 1), split("aapl", 120, 1), split("bank", -220, -220)),
+			tx("buy2", eur, date(2024, 2, 1), split("aapl", 130, 1), split("bank", -130, -130)),
+			// bought in USD, there is no exchange rate to EUR.
+			tx("buy3", usd, date(2024, 2, 1), split("msft", 400, 1), split("usd", -400, -400)),
+		},
+	}
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := b.Portfolio(b.Accounts, GainsFIFO, eur.FQN(), date(2024, 1, 15), date(2024, 3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Holdings) != 1 || len(p.Skipped) != 1 {
+		t.Fatalf("expected 1 holding and 1 skipped account got %d and %v", len(p.Holdings), p.Skipped)
+	}
+
+	h := p.Holdings[0]
+	f := func(v Value) string { return fmt.Sprintf("%.2f", v.Float64()) }
+	if f(h.Shares) != "3.00" || f(h.Cost) != "350.00" || f(h.Market) != "390.00" {
+		t.Errorf("expected 3 shares, cost 350.00 and market 390.00 got %s, %s and %s", f(h.Shares), f(h.Cost), f(h.Market))
+	}
+}
@@ -7,8 +7,10 @@ type Price struct {
 	Comodity CommodityRef `xml:"commodity"`
 	Currency CommodityRef `xml:"currency"`
 	Time     Date         `xml:"time>date"`
+	Source   string       `xml:"source"`
 	Type     string       `xml:"type"`
 	Value    Value        `xml:"value"`
+	Extra    Nodes        `xml:",any"`
 }
 
 func (p Price) String() string {
@@ -17,6 +19,6 @@ func (p Price) String() string {
 		p.Time.Get().Format("2006-01-02 15:04"),
 		p.Comodity.FQN(),
 		p.Currency.FQN(),
-		p.Value,
+		p.Value.Float64(),
 	)
 }
@@ -1,6 +1,22 @@
 package gnucash
 
-import "strings"
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+type PriceStrategy int
+
+const (
+	// PriceNearestBefore uses the last price recorded at or before a date.
+	PriceNearestBefore PriceStrategy = iota
+	// PriceNearest uses the price recorded closest to a date.
+	PriceNearest
+	// PriceInterpolated linearly interpolates between the surrounding
+	// prices, falling back to PriceNearest at either end of the history.
+	PriceInterpolated
+)
 
 type Prices []Price
 
@@ -26,3 +42,95 @@ func (ps Prices) LastFor(com CommodityFQN) Price {
 
 	return b
 }
+
+// At returns the price of com expressed in cur at the given time. Use Index
+// when doing more than a couple of lookups.
+func (ps Prices) At(com, cur CommodityFQN, t time.Time, strategy PriceStrategy) (Price, bool) {
+	return ps.Index().At(com, cur, t, strategy)
+}
+
+func (ps Prices) Index() *PriceIndex {
+	ix := &PriceIndex{make(map[pricePair]Prices)}
+	for _, p := range ps {
+		k := pricePair{p.Comodity.FQN(), p.Currency.FQN()}
+		ix.m[k] = append(ix.m[k], p)
+	}
+
+	for _, l := range ix.m {
+		sort.SliceStable(l, func(i, j int) bool {
+			return l[i].Time.Get().Before(l[j].Time.Get())
+		})
+	}
+
+	return ix
+}
+
+type pricePair struct {
+	com, cur CommodityFQN
+}
+
+// PriceIndex holds prices grouped by commodity / currency pair, sorted by
+// time.
+type PriceIndex struct {
+	m map[pricePair]Prices
+}
+
+func (ix *PriceIndex) For(com, cur CommodityFQN) Prices {
+	return ix.m[pricePair{com, cur}]
+}
+
+func (ix *PriceIndex) At(com, cur CommodityFQN, t time.Time, strategy PriceStrategy) (Price, bool) {
+	l := ix.m[pricePair{com, cur}]
+	if len(l) == 0 {
+		return Price{}, false
+	}
+
+	n := sort.Search(len(l), func(i int) bool {
+		return l[i].Time.Get().After(t)
+	})
+
+	var before, after *Price
+	if n > 0 {
+		before = &l[n-1]
+	}
+	if n < len(l) {
+		after = &l[n]
+	}
+
+	if strategy == PriceNearestBefore {
+		if before == nil {
+			return Price{}, false
+		}
+		return *before, true
+	}
+
+	switch {
+	case before == nil:
+		return *after, true
+	case after == nil:
+		return *before, true
+	}
+
+	tb, ta := before.Time.Get(), after.Time.Get()
+	if strategy == PriceNearest {
+		if t.Sub(tb) <= ta.Sub(t) {
+			return *before, true
+		}
+		return *after, true
+	}
+
+	span := int64(ta.Sub(tb) / time.Second)
+	if span == 0 {
+		return *before, true
+	}
+
+	p := *before
+	p.ID = ""
+	p.Type = "interpolated"
+	p.Time = NewDate(t)
+	p.Value = before.Value.Add(
+		after.Value.Sub(before.Value).Mul(NewValue(int64(t.Sub(tb)/time.Second), span)),
+	).Convert(before.Value.Denom(), RoundBankers)
+
+	return p, true
+}
@@ -0,0 +1,76 @@
+package gnucash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPriceIndexAt(t *testing.T) {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	aapl := CommodityRef{ID: "AAPL", NS: "NASDAQ"}
+	fast := CommodityRef{ID: "FAST", NS: "NASDAQ"}
+	day := func(d, h int) time.Time { return time.Date(2024, 1, d, h, 0, 0, 0, time.UTC) }
+	price := func(id string, com CommodityRef, t time.Time, v int64) Price {
+		return Price{ID: GUID(id), Comodity: com, Currency: eur, Time: NewDate(t), Value: NewValue(v, 100)}
+	}
+
+	// recorded out of order, Index sorts them.
+	ix := Prices{
+		price("late", aapl, day(20, 0), 11000),
+		price("early", aapl, day(10, 0), 10000),
+		price("first", fast, day(10, 0), 10000),
+		price("second", fast, day(10, 0).Add(500*time.Millisecond), 20000),
+	}.Index()
+
+	type exp struct {
+		id    string
+		value string
+	}
+	none := exp{}
+	tests := []struct {
+		name   string
+		com    CommodityRef
+		at     time.Time
+		before exp
+		near   exp
+		interp exp
+	}{
+		{"before the first", aapl, day(5, 0), none, exp{"early", "100.00"}, exp{"early", "100.00"}},
+		{"on the first", aapl, day(10, 0), exp{"early", "100.00"}, exp{"early", "100.00"}, exp{"", "100.00"}},
+		{"nearest the first", aapl, day(13, 0), exp{"early", "100.00"}, exp{"early", "100.00"}, exp{"", "103.00"}},
+		{"halfway", aapl, day(15, 0), exp{"early", "100.00"}, exp{"early", "100.00"}, exp{"", "105.00"}},
+		{"nearest the last", aapl, day(16, 0), exp{"early", "100.00"}, exp{"late", "110.00"}, exp{"", "106.00"}},
+		{"rounded", aapl, day(11, 8), exp{"early", "100.00"}, exp{"early", "100.00"}, exp{"", "101.33"}},
+		{"on the last", aapl, day(20, 0), exp{"late", "110.00"}, exp{"late", "110.00"}, exp{"late", "110.00"}},
+		{"after the last", aapl, day(25, 0), exp{"late", "110.00"}, exp{"late", "110.00"}, exp{"late", "110.00"}},
+		{"within a second", fast, day(10, 0).Add(200 * time.Millisecond), exp{"first", "100.00"}, exp{"first", "100.00"}, exp{"first", "100.00"}},
+	}
+
+	for _, test := range tests {
+		for _, s := range []struct {
+			strategy PriceStrategy
+			exp      exp
+		}{
+			{PriceNearestBefore, test.before},
+			{PriceNearest, test.near},
+			{PriceInterpolated, test.interp},
+		} {
+			p, ok := ix.At(test.com.FQN(), eur.FQN(), test.at, s.strategy)
+			got := none
+			if ok {
+				got = exp{string(p.ID), fmt.Sprintf("%.2f", p.Value.Float64())}
+			}
+			if got != s.exp {
+				t.Errorf("%s (strategy %d): expected %+v got %+v", test.name, s.strategy, s.exp, got)
+			}
+			if ok && p.ID == "" && (p.Type != "interpolated" || !p.Time.Get().Equal(test.at)) {
+				t.Errorf("%s: interpolated price not marked as such: %s", test.name, p)
+			}
+		}
+	}
+
+	if _, ok := ix.At(eur.FQN(), aapl.FQN(), day(15, 0), PriceNearest); ok {
+		t.Error("expected no price for an unknown pair")
+	}
+}
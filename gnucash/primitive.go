@@ -1,10 +1,9 @@
 package gnucash
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	nxml "encoding/xml"
-	"errors"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -36,12 +35,54 @@ type AccountType string
 type ReconciledState rune
 type GUID string
 type Enabled bool
-type Value float64
 type Date struct {
 	parsed bool
 	d      time.Time
 }
 
+func NewGUID() GUID {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return GUID(hex.EncodeToString(b))
+}
+
+func NewDate(t time.Time) Date {
+	return Date{parsed: true, d: t}
+}
+
+// Asset reports whether accounts of this type hold things the owner has
+// (as opposed to owes, earns or spends).
+func (t AccountType) Asset() bool {
+	switch t {
+	case AccountTypeAsset, AccountTypeCash, AccountTypeBank,
+		AccountTypeStock, AccountTypeMutual, AccountTypeReceivable:
+		return true
+	}
+	return false
+}
+
+// Liability reports whether accounts of this type hold what the owner owes.
+func (t AccountType) Liability() bool {
+	switch t {
+	case AccountTypeLiability, AccountTypeCredit, AccountTypePayable:
+		return true
+	}
+	return false
+}
+
+// CreditNormal reports whether accounts of this type normally carry a
+// credit (negative) balance.
+func (t AccountType) CreditNormal() bool {
+	switch t {
+	case AccountTypeCredit, AccountTypeEquity, AccountTypeIncome,
+		AccountTypeLiability, AccountTypePayable:
+		return true
+	}
+	return false
+}
+
 func (r *ReconciledState) UnmarshalXML(d *nxml.Decoder, start nxml.StartElement) error {
 	var content string
 	if err := d.DecodeElement(&content, &start); err != nil {
@@ -64,6 +105,17 @@ func (r ReconciledState) Cleared() bool {
 	return r == ReconciledStateReconciled || r == ReconciledStateCleared
 }
 
+// Valid reports whether r is one of the states GnuCash knows.
+func (r ReconciledState) Valid() bool {
+	switch r {
+	case ReconciledStateNew, ReconciledStateCleared, ReconciledStateReconciled,
+		ReconciledStateFrozen, ReconciledStateVoid:
+		return true
+	}
+
+	return false
+}
+
 func (e *Enabled) UnmarshalXML(d *nxml.Decoder, start nxml.StartElement) error {
 	var content string
 	if err := d.DecodeElement(&content, &start); err != nil {
@@ -80,6 +132,10 @@ func (dt *Date) UnmarshalXML(d *nxml.Decoder, start nxml.StartElement) error {
 		return err
 	}
 
+	return dt.parse(content)
+}
+
+func (dt *Date) parse(content string) error {
 	if content == "" {
 		return nil
 	}
@@ -110,31 +166,3 @@ func (dt *Date) Empty() bool {
 func (dt *Date) Get() time.Time {
 	return dt.d
 }
-
-func (v *Value) UnmarshalXML(d *nxml.Decoder, start nxml.StartElement) error {
-	var content string
-	if err := d.DecodeElement(&content, &start); err != nil {
-		return err
-	}
-
-	p := strings.SplitN(string(content), "/", 2)
-	if len(p) != 2 {
-		return errors.New("Unexpected Value: " + content)
-	}
-
-	val, err := strconv.Atoi(p[0])
-	if err != nil {
-		return err
-	}
-
-	div, err := strconv.Atoi(p[1])
-	if err != nil {
-		return err
-	}
-
-	if div > 0 {
-		*v = Value(float64(val) / float64(div))
-	}
-
-	return nil
-}
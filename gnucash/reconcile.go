@@ -0,0 +1,169 @@
+package gnucash
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Reconciliation reconciles an account against a statement, i.e.: marks the
+// splits that appear on it until the reconciled balance matches the
+// statement's ending balance.
+type Reconciliation struct {
+	Account       *Account
+	StatementDate time.Time
+	EndingBalance Value
+	// Reconciled is the balance of the splits reconciled earlier.
+	Reconciled Value
+	// Splits are the splits that are not reconciled yet and were posted
+	// on or before the statement date.
+	Splits Splits
+
+	marked map[*Split]bool
+}
+
+// Reconcile starts reconciling the account against a statement.
+func (b *Book) Reconcile(account *Account, statementDate time.Time, endingBalance Value) *Reconciliation {
+	r := &Reconciliation{
+		Account:       account,
+		StatementDate: statementDate,
+		EndingBalance: endingBalance,
+		Splits:        make(Splits, 0),
+		marked:        make(map[*Split]bool),
+	}
+
+	ts, _ := b.TransactionsLookup.Find(account.ID)
+	for _, t := range ts {
+		for _, s := range t.Splits {
+			if s.AccountID != account.ID || s.ReconciledState == ReconciledStateVoid {
+				continue
+			}
+			if s.ReconciledState.Reconciled() || s.ReconciledState == ReconciledStateFrozen {
+				r.Reconciled = r.Reconciled.Add(s.Quantity)
+				continue
+			}
+			if !t.DatePosted.Get().After(statementDate) {
+				r.Splits = append(r.Splits, s)
+			}
+		}
+	}
+
+	sort.SliceStable(r.Splits, func(i, j int) bool {
+		return r.Splits[i].Transaction.DatePosted.Get().Before(r.Splits[j].Transaction.DatePosted.Get())
+	})
+
+	return r
+}
+
+// Mark marks or unmarks a split as appearing on the statement.
+func (r *Reconciliation) Mark(s *Split, mark bool) {
+	if mark {
+		r.marked[s] = true
+		return
+	}
+
+	delete(r.marked, s)
+}
+
+func (r *Reconciliation) Marked(s *Split) bool {
+	return r.marked[s]
+}
+
+// Balance is the reconciled balance including the marked splits.
+func (r *Reconciliation) Balance() Value {
+	v := r.Reconciled
+	for s := range r.marked {
+		v = v.Add(s.Quantity)
+	}
+
+	return v
+}
+
+// Difference is what is left to be reconciled.
+func (r *Reconciliation) Difference() Value {
+	return r.EndingBalance.Sub(r.Balance())
+}
+
+// AutoMark marks the cleared splits. If that does not balance but marking
+// all splits does, all splits are marked.
+func (r *Reconciliation) AutoMark() {
+	for _, s := range r.Splits {
+		r.Mark(s, s.ReconciledState.Cleared())
+	}
+	if r.Difference().IsZero() {
+		return
+	}
+
+	all := r.Reconciled
+	for _, s := range r.Splits {
+		all = all.Add(s.Quantity)
+	}
+	if all.Equal(r.EndingBalance) {
+		for _, s := range r.Splits {
+			r.Mark(s, true)
+		}
+	}
+}
+
+// Match marks, for each statement line, the unmarked split with the same
+// amount posted closest to the line's date, at most days apart. The lines
+// without a match are returned.
+func (r *Reconciliation) Match(lines []BankLine, days int) []BankLine {
+	unmatched := make([]BankLine, 0)
+	max := time.Duration(days) * 24 * time.Hour
+	for _, l := range lines {
+		var best *Split
+		var bestDiff time.Duration
+		for _, s := range r.Splits {
+			if r.marked[s] || !s.Quantity.Equal(l.Amount) {
+				continue
+			}
+			diff := s.Transaction.DatePosted.Get().Sub(l.Date)
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > max || (best != nil && diff >= bestDiff) {
+				continue
+			}
+			best, bestDiff = s, diff
+		}
+
+		if best == nil {
+			unmatched = append(unmatched, l)
+			continue
+		}
+		r.Mark(best, true)
+	}
+
+	return unmatched
+}
+
+// Finish sets the reconciled state and date of the marked splits and
+// records the statement date in the account's reconcile-info slot, as
+// GnuCash does. It fails if the difference is not zero.
+func (r *Reconciliation) Finish(now time.Time) error {
+	if !r.Difference().IsZero() {
+		return errors.New("reconciliation does not balance")
+	}
+
+	for s := range r.marked {
+		s.ReconciledState = ReconciledStateReconciled
+		s.ReconcileDate = NewDate(now)
+	}
+
+	var info SlotValue
+	for _, s := range r.Account.Slots {
+		if s.Key == "reconcile-info" && s.RawValue.Type == "frame" {
+			info = s.RawValue
+		}
+	}
+	info.Type = "frame"
+	info.Slots.Set("last-date", SlotValue{
+		Type:  "integer",
+		Value: strconv.FormatInt(r.StatementDate.Unix(), 10),
+	})
+	r.Account.Slots.Set("reconcile-info", info)
+
+	return nil
+}
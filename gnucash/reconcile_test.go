@@ -0,0 +1,88 @@
+package gnucash
+
+import (
+	"testing"
+	"time"
+)
+
+// multiSplitBook returns a book with transactions that have several splits
+// in the bank account.
+func multiSplitBook(t *testing.T) *Book {
+	tx := func(id string, d int, state ReconciledState, bank ...int64) *Transaction {
+		tx := &Transaction{
+			ID:          GUID(id),
+			Description: id,
+			DatePosted:  NewDate(time.Date(2024, 1, d, 10, 59, 0, 0, time.UTC)),
+		}
+		var total int64
+		for _, v := range bank {
+			total += v
+			s := testSplit("bank", v)
+			s.ReconciledState = state
+			tx.Splits = append(tx.Splits, s)
+		}
+		tx.Splits = append(tx.Splits, testSplit("income", -total))
+		return tx
+	}
+
+	return testBook(
+		t,
+		Accounts{
+			testAccount("root", "", AccountTypeRoot),
+			testAccount("bank", "root", AccountTypeBank),
+			testAccount("income", "root", AccountTypeIncome),
+			testAccount("equity", "root", AccountTypeEquity),
+		},
+		Transactions{
+			tx("reconciled", 2, ReconciledStateReconciled, 10, 5),
+			tx("open", 3, ReconciledStateNew, 3, 4),
+		},
+	)
+}
+
+func TestReconcileMultiSplit(t *testing.T) {
+	b := multiSplitBook(t)
+	bank, _ := b.AccountsLookup.ByGUID("bank")
+	if len(bank.Transactions) != 2 {
+		t.Fatalf("expected 2 transactions in the account got %d", len(bank.Transactions))
+	}
+
+	r := b.Reconcile(bank, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), NewValue(22, 1))
+	if !r.Reconciled.Equal(NewValue(15, 1)) {
+		t.Errorf("expected 15 reconciled got %.2f", r.Reconciled.Float64())
+	}
+	if len(r.Splits) != 2 {
+		t.Fatalf("expected 2 splits to reconcile got %d", len(r.Splits))
+	}
+
+	r.AutoMark()
+	if !r.Difference().IsZero() {
+		t.Fatalf("expected automark to balance, difference %.2f", r.Difference().Float64())
+	}
+	if err := r.Finish(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range r.Splits {
+		if s.ReconciledState != ReconciledStateReconciled {
+			t.Errorf("split %s not reconciled", s.ID)
+		}
+	}
+
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	income, _ := b.AccountsLookup.ByGUID("income")
+	tx := NewTransaction(eur, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), "", "added")
+	for _, v := range []int64{1, 2} {
+		if _, err := tx.AddSplit(bank, NewValue(v, 1), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tx.AddSplit(income, NewValue(-3, 1), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := b.TransactionsLookup.Find(bank.ID); len(l) != 3 || len(bank.Transactions) != 3 {
+		t.Errorf("expected the added transaction once, got %d / %d", len(l), len(bank.Transactions))
+	}
+}
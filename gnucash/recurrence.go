@@ -0,0 +1,182 @@
+package gnucash
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PeriodType string
+type WeekendAdjust string
+
+const (
+	PeriodOnce        PeriodType = "once"
+	PeriodDay                    = "day"
+	PeriodWeek                   = "week"
+	PeriodMonth                  = "month"
+	PeriodEndOfMonth             = "end of month"
+	PeriodNthWeekday             = "nth weekday"
+	PeriodLastWeekday            = "last weekday"
+	PeriodYear                   = "year"
+)
+
+const (
+	WeekendAdjustNone    WeekendAdjust = "none"
+	WeekendAdjustBack                  = "back"
+	WeekendAdjustForward               = "forward"
+)
+
+type Recurrence struct {
+	Mult       int           `xml:"mult"`
+	PeriodType PeriodType    `xml:"period_type"`
+	Start      Date          `xml:"start>gdate"`
+	WeekendAdj WeekendAdjust `xml:"weekend_adj"`
+}
+
+func (r Recurrence) String() string {
+	s := fmt.Sprintf(
+		"every %d %s from %s",
+		r.mult(),
+		r.PeriodType,
+		r.Start.Get().Format("2006-01-02"),
+	)
+	if r.WeekendAdj != "" && r.WeekendAdj != WeekendAdjustNone {
+		s += fmt.Sprintf(" (weekend: %s)", r.WeekendAdj)
+	}
+	return s
+}
+
+func (r Recurrence) mult() int {
+	if r.Mult < 1 {
+		return 1
+	}
+	return r.Mult
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func (r Recurrence) adjust(t time.Time) time.Time {
+	switch r.PeriodType {
+	case PeriodDay, PeriodWeek, PeriodOnce:
+		return t
+	}
+
+	switch {
+	case r.WeekendAdj == WeekendAdjustBack && t.Weekday() == time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case r.WeekendAdj == WeekendAdjustBack && t.Weekday() == time.Sunday:
+		return t.AddDate(0, 0, -2)
+	case r.WeekendAdj == WeekendAdjustForward && t.Weekday() == time.Saturday:
+		return t.AddDate(0, 0, 2)
+	case r.WeekendAdj == WeekendAdjustForward && t.Weekday() == time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+
+	return t
+}
+
+// nth returns the n'th instance of the recurrence, unadjusted for weekends.
+func (r Recurrence) nth(n int) (time.Time, bool) {
+	start := day(r.Start.Get())
+	y, m, d := start.Date()
+	months := func(k int) (int, time.Month) {
+		mm := int(m) - 1 + k
+		return y + mm/12, time.Month(mm%12 + 1)
+	}
+
+	switch r.PeriodType {
+	case PeriodOnce:
+		return start, n == 0
+	case PeriodDay:
+		return start.AddDate(0, 0, n*r.mult()), true
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7*n*r.mult()), true
+	case PeriodMonth, PeriodYear:
+		k := n * r.mult()
+		if r.PeriodType == PeriodYear {
+			k *= 12
+		}
+		ny, nm := months(k)
+		nd := d
+		if dim := daysIn(ny, nm); nd > dim {
+			nd = dim
+		}
+		return time.Date(ny, nm, nd, 0, 0, 0, 0, time.UTC), true
+	case PeriodEndOfMonth:
+		ny, nm := months(n * r.mult())
+		return time.Date(ny, nm, daysIn(ny, nm), 0, 0, 0, 0, time.UTC), true
+	case PeriodNthWeekday:
+		week := (d - 1) / 7
+		ny, nm := months(n * r.mult())
+		first := time.Date(ny, nm, 1, 0, 0, 0, 0, time.UTC)
+		offset := (int(start.Weekday()) - int(first.Weekday()) + 7) % 7
+		t := first.AddDate(0, 0, offset+7*week)
+		if t.Month() != nm {
+			// a fifth weekday does not exist in every month.
+			t = t.AddDate(0, 0, -7)
+		}
+		return t, true
+	case PeriodLastWeekday:
+		ny, nm := months(n * r.mult())
+		last := time.Date(ny, nm, daysIn(ny, nm), 0, 0, 0, 0, time.UTC)
+		offset := (int(last.Weekday()) - int(start.Weekday()) + 7) % 7
+		return last.AddDate(0, 0, -offset), true
+	}
+
+	return time.Time{}, false
+}
+
+// Between returns the instances of the recurrence that fall within from and
+// to (inclusive, compared by day).
+func (r Recurrence) Between(from, to time.Time) []time.Time {
+	from, to = day(from), day(to)
+	l := make([]time.Time, 0)
+	for n := 0; ; n++ {
+		t, ok := r.nth(n)
+		if !ok || t.After(to.AddDate(0, 0, 3)) {
+			break
+		}
+		t = r.adjust(t)
+		if t.Before(from) || t.After(to) {
+			continue
+		}
+		l = append(l, t)
+	}
+
+	return l
+}
+
+func (r Recurrence) validate() error {
+	switch r.PeriodType {
+	case PeriodOnce, PeriodDay, PeriodWeek, PeriodMonth, PeriodEndOfMonth,
+		PeriodNthWeekday, PeriodLastWeekday, PeriodYear:
+	default:
+		return fmt.Errorf("Invalid recurrence period type '%s'", r.PeriodType)
+	}
+
+	switch r.WeekendAdj {
+	case "", WeekendAdjustNone, WeekendAdjustBack, WeekendAdjustForward:
+	default:
+		return fmt.Errorf("Invalid recurrence weekend adjustment '%s'", r.WeekendAdj)
+	}
+
+	return nil
+}
+
+type Recurrences []Recurrence
+
+func (rs Recurrences) String() string {
+	s := make([]string, len(rs))
+	for i, r := range rs {
+		s[i] = r.String()
+	}
+
+	return strings.Join(s, ", ")
+}
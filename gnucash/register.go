@@ -0,0 +1,162 @@
+package gnucash
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RegisterEntry is a single split in an account register.
+type RegisterEntry struct {
+	Split       *Split
+	Transaction *Transaction
+	// Others are the accounts of the transaction's other splits.
+	Others Accounts
+	// Amount is the split's quantity, positive for debits. Splits of child
+	// accounts holding another commodity use their value instead.
+	Amount Value
+	// Balance is the account's balance after this split, including splits
+	// that were filtered out.
+	Balance Value
+}
+
+func (e RegisterEntry) Date() time.Time {
+	return e.Transaction.DatePosted.Get()
+}
+
+// OthersString returns the FQNs of the entry's counter accounts.
+func (e RegisterEntry) OthersString() string {
+	names := make([]string, len(e.Others))
+	for i, a := range e.Others {
+		names[i] = a.FQN
+	}
+
+	return strings.Join(names, ", ")
+}
+
+type Register []RegisterEntry
+
+// RegisterFilter selects the entries of a register, zero fields do not
+// filter.
+type RegisterFilter struct {
+	From time.Time
+	To   time.Time
+	// Min and Max bound the absolute amount.
+	Min *Value
+	Max *Value
+	// Description is matched against the transaction's description and the
+	// split's memo.
+	Description *regexp.Regexp
+	// States lists the reconciled states to include.
+	States []ReconciledState
+}
+
+func (f RegisterFilter) match(e RegisterEntry) bool {
+	d := e.Date()
+	if !f.From.IsZero() && d.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && d.After(f.To) {
+		return false
+	}
+
+	abs := e.Amount
+	if abs.Sign() < 0 {
+		abs = abs.Neg()
+	}
+	if f.Min != nil && abs.Sub(*f.Min).Sign() < 0 {
+		return false
+	}
+	if f.Max != nil && abs.Sub(*f.Max).Sign() > 0 {
+		return false
+	}
+
+	if f.Description != nil &&
+		!f.Description.MatchString(e.Transaction.Description) &&
+		!f.Description.MatchString(e.Split.Memo) {
+		return false
+	}
+
+	if len(f.States) != 0 {
+		for _, s := range f.States {
+			if e.Split.ReconciledState == s {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// Register returns the splits of the account (and optionally its
+// children) sorted by date posted with a running balance, filtered by f.
+func (b *Book) Register(account *Account, includeChildren bool, f RegisterFilter) Register {
+	splits := make(Splits, 0)
+	seen := make(map[*Transaction]struct{})
+	added := make(map[*Account]struct{})
+	var add func(a *Account)
+	add = func(a *Account) {
+		// cycles are reported by Book.Check.
+		if _, ok := added[a]; ok {
+			return
+		}
+		added[a] = struct{}{}
+
+		ts, _ := b.TransactionsLookup.Find(a.ID)
+		for _, t := range ts {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			for _, s := range t.Splits {
+				if s.inAccount(account.ID, includeChildren) {
+					splits = append(splits, s)
+				}
+			}
+		}
+		if includeChildren {
+			for _, c := range a.Children {
+				add(c)
+			}
+		}
+	}
+	add(account)
+
+	sort.SliceStable(splits, func(i, j int) bool {
+		ti, tj := splits[i].Transaction, splits[j].Transaction
+		di, dj := ti.DatePosted.Get(), tj.DatePosted.Get()
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return ti.DateEntered.Get().Before(tj.DateEntered.Get())
+	})
+
+	reg := make(Register, 0, len(splits))
+	var balance Value
+	for _, s := range splits {
+		amount := s.Quantity
+		if s.Account.Commodity != account.Commodity {
+			amount = s.Value
+		}
+		balance = balance.Add(amount)
+		e := RegisterEntry{
+			Split:       s,
+			Transaction: s.Transaction,
+			Others:      make(Accounts, 0, len(s.Transaction.Splits)-1),
+			Amount:      amount,
+			Balance:     balance,
+		}
+		for _, o := range s.Transaction.Splits {
+			if o != s && o.Account != nil && !o.inAccount(account.ID, includeChildren) {
+				e.Others = append(e.Others, o.Account)
+			}
+		}
+		if f.match(e) {
+			reg = append(reg, e)
+		}
+	}
+
+	return reg
+}
@@ -0,0 +1,144 @@
+package gnucash
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func registerBook(t *testing.T) *Book {
+	eur := CommodityRef{ID: "EUR", NS: CommodityCurrency}
+	aapl := CommodityRef{ID: "AAPL", NS: "NASDAQ"}
+	account := func(id, parent string, typ AccountType, com CommodityRef) *Account {
+		return &Account{ID: GUID(id), Name: id, ParentID: GUID(parent), Type: typ, Commodity: com, SCU: 100}
+	}
+	type split struct {
+		account    string
+		memo       string
+		value, qty int64
+		reconciled bool
+	}
+	tx := func(id string, d int, splits ...split) *Transaction {
+		tx := &Transaction{
+			ID:          GUID(id),
+			Currency:    eur,
+			Description: id,
+			DatePosted:  NewDate(time.Date(2024, 1, d, 10, 59, 0, 0, time.UTC)),
+		}
+		for i, s := range splits {
+			state := ReconciledStateNew
+			if s.reconciled {
+				state = ReconciledStateReconciled
+			}
+			tx.Splits = append(tx.Splits, &Split{
+				ID:              GUID(fmt.Sprintf("%s-%d", id, i)),
+				AccountID:       GUID(s.account),
+				Memo:            s.memo,
+				ReconciledState: state,
+				Value:           NewValue(s.value, 1),
+				Quantity:        NewValue(s.qty, 1),
+			})
+		}
+		return tx
+	}
+
+	b := &Book{
+		ID:          "book",
+		Commodities: Commodities{{CommodityRef: eur}, {CommodityRef: aapl}},
+		Accounts: Accounts{
+			account("root", "", AccountTypeRoot, eur),
+			account("assets", "root", AccountTypeAsset, eur),
+			account("checking", "assets", AccountTypeBank, eur),
+			account("stocks", "assets", AccountTypeStock, aapl),
+			account("income", "root", AccountTypeIncome, eur),
+			account("groceries", "root", AccountTypeExpense, eur),
+			account("household", "root", AccountTypeExpense, eur),
+		},
+		Transactions: Transactions{
+			tx("salary", 2, split{"checking", "", 1000, 1000, true}, split{"income", "", -1000, -1000, false}),
+			tx("shopping", 5, split{"checking", "", -60, -60, false}, split{"groceries", "", 40, 40, false}, split{"household", "", 20, 20, false}),
+			tx("deposit", 8, split{"checking", "a", 30, 30, false}, split{"checking", "b", 20, 20, false}, split{"income", "", -50, -50, false}),
+			tx("buy", 10, split{"stocks", "", 300, 2, false}, split{"checking", "", -300, -300, false}),
+		},
+	}
+	if err := b.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestRegister(t *testing.T) {
+	b := registerBook(t)
+	value := func(v int64) *Value { n := NewValue(v, 1); return &n }
+	from := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 8, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		account  GUID
+		children bool
+		filter   RegisterFilter
+		exp      []string
+	}{
+		{
+			"all", "checking", false, RegisterFilter{},
+			[]string{"salary 1000 1000", "shopping -60 940", "deposit 30 970", "deposit 20 990", "buy -300 690"},
+		},
+		{
+			// the stock split is listed at its value, not its quantity.
+			"children", "assets", true, RegisterFilter{},
+			[]string{"salary 1000 1000", "shopping -60 940", "deposit 30 970", "deposit 20 990", "buy 300 1290", "buy -300 990"},
+		},
+		{
+			"dates", "checking", false, RegisterFilter{From: from, To: to},
+			[]string{"shopping -60 940", "deposit 30 970", "deposit 20 990"},
+		},
+		{
+			"amounts", "checking", false, RegisterFilter{Min: value(25), Max: value(100)},
+			[]string{"shopping -60 940", "deposit 30 970"},
+		},
+		{
+			"memo", "checking", false, RegisterFilter{Description: regexp.MustCompile("^b$")},
+			[]string{"deposit 20 990"},
+		},
+		{
+			"description", "checking", false, RegisterFilter{Description: regexp.MustCompile("shop")},
+			[]string{"shopping -60 940"},
+		},
+		{
+			"states", "checking", false, RegisterFilter{States: []ReconciledState{ReconciledStateReconciled}},
+			[]string{"salary 1000 1000"},
+		},
+	}
+
+	for _, test := range tests {
+		account, _ := b.AccountsLookup.ByGUID(test.account)
+		reg := b.Register(account, test.children, test.filter)
+		got := make([]string, len(reg))
+		for i, e := range reg {
+			got[i] = fmt.Sprintf("%s %.0f %.0f", e.Transaction.Description, e.Amount.Float64(), e.Balance.Float64())
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.exp) {
+			t.Errorf("%s: expected %v got %v", test.name, test.exp, got)
+		}
+	}
+
+	checking, _ := b.AccountsLookup.ByGUID("checking")
+	groceries, _ := b.AccountsLookup.ByGUID("groceries")
+	household, _ := b.AccountsLookup.ByGUID("household")
+	reg := b.Register(checking, false, RegisterFilter{})
+	if exp := groceries.FQN + ", " + household.FQN; reg[1].OthersString() != exp {
+		t.Errorf("expected others %s got %s", exp, reg[1].OthersString())
+	}
+	if len(reg[2].Others) != 1 || len(reg[3].Others) != 1 {
+		t.Error("splits in the same account should not be listed as others")
+	}
+
+	assets, _ := b.AccountsLookup.ByGUID("assets")
+	reg = b.Register(assets, true, RegisterFilter{})
+	if reg[4].Split.Account.ID != "stocks" || len(reg[4].Others) != 0 {
+		t.Error("transfers within the account should not list others")
+	}
+}
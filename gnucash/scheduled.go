@@ -3,12 +3,33 @@ package gnucash
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 )
 
 type Scheduled struct {
-	ID      GUID    `xml:"id"`
-	Name    string  `xml:"name"`
-	Enabled Enabled `xml:"enabled"`
+	ID                GUID        `xml:"id"`
+	Name              string      `xml:"name"`
+	Enabled           Enabled     `xml:"enabled"`
+	AutoCreate        Enabled     `xml:"autoCreate"`
+	AutoCreateNotify  Enabled     `xml:"autoCreateNotify"`
+	AdvanceCreateDays int         `xml:"advanceCreateDays"`
+	AdvanceRemindDays int         `xml:"advanceRemindDays"`
+	InstanceCount     int         `xml:"instanceCount"`
+	Start             Date        `xml:"start>gdate"`
+	Last              Date        `xml:"last>gdate"`
+	End               Date        `xml:"end>gdate"`
+	NumOccur          int         `xml:"num-occur"`
+	RemOccur          int         `xml:"rem-occur"`
+	TemplateAccountID GUID        `xml:"templ-acct"`
+	Schedule          Recurrences `xml:"schedule>recurrence"`
+	Slots             Slots       `xml:"slots>slot"`
+	Extra             Nodes       `xml:",any"`
+
+	TemplateAccount *Account     `xml:"-"`
+	Templates       Transactions `xml:"-"`
+
+	accounts *AccountsLookup
 }
 
 func (s *Scheduled) String() string {
@@ -17,17 +38,165 @@ func (s *Scheduled) String() string {
 		enab = "n"
 	}
 	return fmt.Sprintf(
-		"SCHEDULED\n[%s] %s\nID: %s",
+		"SCHEDULED\n[%s] %s\nID: %s\nSchedule: %s",
 		enab,
 		s.Name,
 		s.ID,
+		s.Schedule.String(),
 	)
 }
 
-func (s *Scheduled) validate(lookup *AccountsLookup) error {
+// Occurrences returns the days within from and to (inclusive) the schedule
+// fires on, honoring its start and end date and number of occurrences.
+func (s *Scheduled) Occurrences(from, to time.Time) []time.Time {
+	start := day(s.Start.Get())
+	from, to = day(from), day(to)
+	if !s.End.Empty() && day(s.End.Get()).Before(to) {
+		to = day(s.End.Get())
+	}
+
+	// the occurrence limit counts from the start of the schedule.
+	lo := from
+	if s.NumOccur > 0 || lo.Before(start) {
+		lo = start
+	}
+
+	seen := make(map[time.Time]struct{})
+	l := make([]time.Time, 0)
+	for _, r := range s.Schedule {
+		for _, t := range r.Between(lo, to) {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			l = append(l, t)
+		}
+	}
+
+	sort.Slice(l, func(i, j int) bool { return l[i].Before(l[j]) })
+	if s.NumOccur > 0 && len(l) > s.NumOccur {
+		l = l[:s.NumOccur]
+	}
+
+	n := 0
+	for _, t := range l {
+		if !t.Before(from) {
+			l[n] = t
+			n++
+		}
+	}
+
+	return l[:n]
+}
+
+// Pending returns the occurrences up to and including to that have not
+// been created yet, i.e.: those after the last created instance.
+func (s *Scheduled) Pending(to time.Time) []time.Time {
+	if !s.Enabled {
+		return nil
+	}
+
+	from := s.Start.Get()
+	if !s.Last.Empty() {
+		from = day(s.Last.Get()).AddDate(0, 0, 1)
+	}
+
+	return s.Occurrences(from, to)
+}
+
+// Overdue returns the pending occurrences GnuCash would have created by now,
+// taking the number of days transactions are created in advance into account.
+func (s *Scheduled) Overdue(now time.Time) []time.Time {
+	return s.Pending(now.AddDate(0, 0, s.AdvanceCreateDays))
+}
+
+// Instances creates the transactions of the schedule's templates for the
+// given day. The transactions are not added to the book.
+func (s *Scheduled) Instances(at time.Time) (Transactions, error) {
+	if s.accounts == nil {
+		return nil, fmt.Errorf("schedule '%s' is not part of a book", s.Name)
+	}
+
+	l := make(Transactions, 0, len(s.Templates))
+	for _, tpl := range s.Templates {
+		t := NewTransaction(tpl.Currency, at, tpl.Num, tpl.Description)
+		t.Slots = append(t.Slots, Slot{
+			Key:      "from-sched-xaction",
+			RawValue: SlotValue{Type: "guid", Value: string(s.ID)},
+		})
+
+		for _, ts := range tpl.Splits {
+			st, ok := ts.Template()
+			if !ok {
+				return nil, fmt.Errorf("schedule '%s' has a template split without account", s.Name)
+			}
+
+			a, ok := s.accounts.ByGUID(st.AccountID)
+			if !ok {
+				return nil, fmt.Errorf("schedule '%s' references unknown account '%s'", s.Name, st.AccountID)
+			}
+
+			v, err := st.Value()
+			if err != nil {
+				return nil, fmt.Errorf("schedule '%s': %w", s.Name, err)
+			}
+
+			split, err := t.AddSplit(a, v, ts.Memo)
+			if err != nil {
+				return nil, fmt.Errorf("schedule '%s': %w", s.Name, err)
+			}
+			split.Action = ts.Action
+		}
+
+		l = append(l, t)
+	}
+
+	return l, nil
+}
+
+// Expand creates the transactions for all occurrences within from and to.
+func (s *Scheduled) Expand(from, to time.Time) (Transactions, error) {
+	return s.expand(s.Occurrences(from, to))
+}
+
+func (s *Scheduled) expand(occurrences []time.Time) (Transactions, error) {
+	l := make(Transactions, 0, len(occurrences)*len(s.Templates))
+	for _, at := range occurrences {
+		ts, err := s.Instances(at)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, ts...)
+	}
+
+	return l, nil
+}
+
+func (s *Scheduled) validate(lookup *AccountsLookup, templates *Templates) error {
 	if s.ID == "" {
 		return errors.New("Empty schedule id")
 	}
 
+	for _, r := range s.Schedule {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("schedule '%s': %w", s.Name, err)
+		}
+	}
+
+	s.accounts = lookup
+	s.Templates = make(Transactions, 0, 1)
+	if templates.AccountsLookup != nil {
+		s.TemplateAccount, _ = templates.AccountsLookup.ByGUID(s.TemplateAccountID)
+	}
+
+	for _, t := range templates.Transactions {
+		for _, split := range t.Splits {
+			if split.AccountID == s.TemplateAccountID {
+				s.Templates = append(s.Templates, t)
+				break
+			}
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,101 @@
+package gnucash
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRecurrence(t *testing.T) {
+	tests := []struct {
+		r   Recurrence
+		exp []time.Time
+	}{
+		{
+			Recurrence{1, PeriodMonth, NewDate(date(2024, 1, 31)), WeekendAdjustNone},
+			[]time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31)},
+		},
+		{
+			Recurrence{1, PeriodMonth, NewDate(date(2024, 1, 31)), WeekendAdjustBack},
+			[]time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 29)},
+		},
+		{
+			Recurrence{2, PeriodWeek, NewDate(date(2024, 1, 6)), WeekendAdjustForward},
+			[]time.Time{date(2024, 1, 6), date(2024, 1, 20), date(2024, 2, 3), date(2024, 2, 17), date(2024, 3, 2), date(2024, 3, 16), date(2024, 3, 30)},
+		},
+		{
+			Recurrence{1, PeriodNthWeekday, NewDate(date(2024, 1, 29)), WeekendAdjustNone},
+			[]time.Time{date(2024, 1, 29), date(2024, 2, 26), date(2024, 3, 25)},
+		},
+		{
+			Recurrence{1, PeriodLastWeekday, NewDate(date(2024, 1, 26)), WeekendAdjustNone},
+			[]time.Time{date(2024, 1, 26), date(2024, 2, 23), date(2024, 3, 29)},
+		},
+		{
+			Recurrence{1, PeriodEndOfMonth, NewDate(date(2024, 1, 15)), WeekendAdjustNone},
+			[]time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31)},
+		},
+	}
+
+	for _, test := range tests {
+		l := test.r.Between(date(2024, 1, 1), date(2024, 3, 31))
+		if len(l) != len(test.exp) {
+			t.Errorf("%s: expected %v got %v", test.r, test.exp, l)
+			continue
+		}
+		for i := range l {
+			if !l[i].Equal(test.exp[i]) {
+				t.Errorf("%s: expected %v got %v", test.r, test.exp, l)
+				break
+			}
+		}
+	}
+}
+
+func TestScheduledOccurrences(t *testing.T) {
+	s := &Scheduled{
+		Enabled:  true,
+		Start:    NewDate(date(2024, 1, 1)),
+		Last:     NewDate(date(2024, 2, 1)),
+		NumOccur: 4,
+		Schedule: Recurrences{{1, PeriodMonth, NewDate(date(2024, 1, 1)), WeekendAdjustNone}},
+	}
+
+	if l := s.Occurrences(date(2024, 3, 1), date(2025, 1, 1)); len(l) != 2 {
+		t.Errorf("expected 2 occurrences within the limit, got %v", l)
+	}
+
+	if l := s.Overdue(date(2024, 3, 15)); len(l) != 1 || !l[0].Equal(date(2024, 3, 1)) {
+		t.Errorf("expected march to be overdue, got %v", l)
+	}
+}
+
+func TestEvalFormula(t *testing.T) {
+	tests := map[string]Value{
+		"":                NewValue(0, 1),
+		"1200":            NewValue(1200, 1),
+		"-(100 + 20) * 3": NewValue(-360, 1),
+		"1,250.50":        NewValue(125050, 100),
+		"1.250,50":        NewValue(125050, 100),
+		"10,5 / 2":        NewValue(525, 100),
+	}
+	for str, exp := range tests {
+		v, err := EvalFormula(str)
+		if err != nil {
+			t.Errorf("%s: %s", str, err)
+			continue
+		}
+		if !v.Equal(exp) {
+			t.Errorf("%s: expected %s got %s", str, exp, v)
+		}
+	}
+
+	for _, str := range []string{"rent * 2", "(1 + 2", "1 / 0", "3 +"} {
+		if _, err := EvalFormula(str); err == nil {
+			t.Errorf("%s: expected error", str)
+		}
+	}
+}
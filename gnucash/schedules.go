@@ -1,6 +1,9 @@
 package gnucash
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 type Schedules []*Scheduled
 
@@ -13,9 +16,52 @@ func (ss Schedules) String() string {
 	return strings.Join(str, "\n")
 }
 
-func (ss Schedules) validate(lookup *AccountsLookup) error {
+// Expand creates the transactions of all enabled schedules within from and
+// to, sorted by date posted.
+func (ss Schedules) Expand(from, to time.Time) (Transactions, error) {
+	return ss.expand(func(s *Scheduled) []time.Time {
+		if !s.Enabled {
+			return nil
+		}
+		return s.Occurrences(from, to)
+	})
+}
+
+// Pending creates the transactions of all schedules that have not been
+// created yet, up to and including to, sorted by date posted.
+func (ss Schedules) Pending(to time.Time) (Transactions, error) {
+	return ss.expand(func(s *Scheduled) []time.Time { return s.Pending(to) })
+}
+
+// Overdue returns the schedules that have occurrences GnuCash should have
+// created by now.
+func (ss Schedules) Overdue(now time.Time) Schedules {
+	l := make(Schedules, 0)
+	for _, s := range ss {
+		if len(s.Overdue(now)) != 0 {
+			l = append(l, s)
+		}
+	}
+
+	return l
+}
+
+func (ss Schedules) expand(occurrences func(*Scheduled) []time.Time) (Transactions, error) {
+	l := make(Transactions, 0)
+	for _, s := range ss {
+		ts, err := s.expand(occurrences(s))
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, ts...)
+	}
+
+	return l.SortDatePosted(), nil
+}
+
+func (ss Schedules) validate(lookup *AccountsLookup, templates *Templates) error {
 	for _, s := range ss {
-		if err := s.validate(lookup); err != nil {
+		if err := s.validate(lookup, templates); err != nil {
 			return err
 		}
 	}
@@ -15,7 +15,7 @@ type FlatTransaction struct {
 }
 
 func (f *FlatTransaction) String() string {
-	return fmt.Sprintf("%5.2f %s => %s", f.Value, f.From.FQN, f.To.FQN)
+	return fmt.Sprintf("%5.2f %s => %s", f.Value.Float64(), f.From.FQN, f.To.FQN)
 }
 
 func (f *FlatTransaction) Inverse() *FlatTransaction {
@@ -23,7 +23,7 @@ func (f *FlatTransaction) Inverse() *FlatTransaction {
 		f.To,
 		f.From,
 		f.Description,
-		-f.Value,
+		f.Value.Neg(),
 	}
 }
 
@@ -32,7 +32,7 @@ func (f *FlatTransaction) InverseValue() *FlatTransaction {
 		f.From,
 		f.To,
 		f.Description,
-		-f.Value,
+		f.Value.Neg(),
 	}
 }
 
@@ -59,9 +59,9 @@ func (f FlatTransactions) SortNormal(fromFirst bool) FlatTransactions {
 		f,
 		func(i, j int) bool {
 			a, b := f[i], f[j]
-			if a.Value < 0 && b.Value >= 0 {
+			if a.Value.Sign() < 0 && b.Value.Sign() >= 0 {
 				return true
-			} else if a.Value >= 0 && b.Value < 0 {
+			} else if a.Value.Sign() >= 0 && b.Value.Sign() < 0 {
 				return false
 			}
 
@@ -81,7 +81,7 @@ func (f FlatTransactions) SortValue() FlatTransactions {
 	sort.SliceStable(
 		f,
 		func(i, j int) bool {
-			return f[i].Value < f[j].Value
+			return f[i].Value.Cmp(f[j].Value) < 0
 		},
 	)
 
@@ -100,7 +100,7 @@ func (f FlatTransactions) String() string {
 func (f FlatTransactions) Sum() Value {
 	var v Value
 	for _, t := range f {
-		v += t.Value
+		v = v.Add(t.Value)
 	}
 	return v
 }
@@ -185,8 +185,8 @@ func (f FlatTransactions) GroupBy() FlatTransactions {
 	for i, t := range f {
 		if j, ok := m[t.From.FQN]; ok {
 			f[j].To = nil
-			f[j].Value += t.Value
-			t.Value = 0
+			f[j].Value = f[j].Value.Add(t.Value)
+			t.Value = Value{}
 			amount++
 			continue
 		}
@@ -196,7 +196,7 @@ func (f FlatTransactions) GroupBy() FlatTransactions {
 
 	n := make(FlatTransactions, 0, len(f)-amount)
 	for _, t := range f {
-		if t.Value != 0 {
+		if !t.Value.IsZero() {
 			n = append(n, t)
 		}
 	}
@@ -217,10 +217,10 @@ func (m simpleTXMap) flatten() simpleTXMap {
 		for tid := range m[fid] {
 			if _, ok := m[tid][fid]; ok {
 				fromI, toI := fid, tid
-				if m[tid][fid].value < m[fid][tid].value {
+				if m[tid][fid].value.Cmp(m[fid][tid].value) < 0 {
 					fromI, toI = toI, fromI
 				}
-				m[fromI][toI].value -= m[toI][fromI].value
+				m[fromI][toI].value = m[fromI][toI].value.Sub(m[toI][fromI].value)
 				delete(m[toI], fromI)
 			}
 		}
@@ -234,16 +234,16 @@ func (m simpleTXMap) flattxs() FlatTransactions {
 	for fid := range m {
 		for tid := range m[fid] {
 			f, t := m[fid][tid].from, m[fid][tid].to
-			var sign Value = -1
-			if m[fid][tid].value > 0 {
+			value := m[fid][tid].value.Neg()
+			if m[fid][tid].value.Sign() > 0 {
 				f, t = t, f
-				sign = 1
+				value = value.Neg()
 			}
 			tx := &FlatTransaction{
 				From:        f,
 				To:          t,
 				Description: m[fid][tid].description,
-				Value:       sign * m[fid][tid].value,
+				Value:       value,
 			}
 
 			flattxs = append(flattxs, tx)
@@ -253,23 +253,33 @@ func (m simpleTXMap) flattxs() FlatTransactions {
 	return flattxs
 }
 
-func (ts Transactions) mapTransactions() simpleTXMap {
+func (ts Transactions) mapTransactions(value func(*Split) (Value, error)) (simpleTXMap, error) {
 	m := make(simpleTXMap, len(ts))
 	from := make([]*Split, 1)
 	to := make([]*Split, 1)
 	for _, tx := range ts {
-		var diff Value = 0
+		var diff Value
+		values := make(map[*Split]Value, len(tx.Splits))
 		from = from[0:0]
 		to = to[0:0]
 		for _, s := range tx.Splits {
-			if s.Value >= 0 {
-				diff += s.Value
+			v, err := value(s)
+			if err != nil {
+				return nil, err
+			}
+			values[s] = v
+			if v.Sign() >= 0 {
+				diff = diff.Add(v)
 				to = append(to, s)
 				continue
 			}
 			from = append(from, s)
 		}
 
+		if diff.IsZero() {
+			continue
+		}
+
 		for _, f := range from {
 			if _, ok := m[f.AccountID]; !ok {
 				m[f.AccountID] = make(map[GUID]*txMeta, len(to))
@@ -280,14 +290,16 @@ func (ts Transactions) mapTransactions() simpleTXMap {
 					m[f.AccountID][t.AccountID] = &txMeta{
 						f.Account,
 						t.Account,
-						0,
+						Value{},
 						tx.Description,
 					}
 				}
-				m[f.AccountID][t.AccountID].value += t.Value * f.Value / diff
+				tv := values[t]
+				share := tv.Mul(values[f]).Div(diff).Convert(tv.Denom(), RoundBankers)
+				m[f.AccountID][t.AccountID].value = m[f.AccountID][t.AccountID].value.Add(share)
 			}
 		}
 	}
 
-	return m
+	return m, nil
 }
@@ -1,6 +1,9 @@
 package gnucash
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Slot struct {
 	Key      string    `xml:"key"`
@@ -21,6 +24,20 @@ func (s Slot) StringValue() (string, error) {
 	return s.RawValue.Value, nil
 }
 
+func (s Slot) TimeValue() (time.Time, error) {
+	if err := mkValueError(s.Key, "timespec", s.RawValue.Type); err != nil {
+		return time.Time{}, err
+	}
+	d := Date{}
+	for _, n := range s.RawValue.Extra {
+		if n.Name.Local == "date" {
+			err := d.parse(n.Text)
+			return d.Get(), err
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s has no date", s.Key)
+}
+
 func (s Slot) String() string {
 	return fmt.Sprintf("%s: %s", s.Key, s.RawValue.String())
 }
@@ -28,6 +45,8 @@ func (s Slot) String() string {
 type SlotValue struct {
 	Type  string `xml:"type,attr"`
 	Value string `xml:",chardata"`
+	Slots Slots  `xml:"slot"`
+	Extra Nodes  `xml:",any"`
 }
 
 func (s SlotValue) String() string {
@@ -21,3 +21,15 @@ func (s Slots) String() string {
 }
 
 type KeyValue map[string]Slot
+
+// Set replaces the value of the slot with the given key, or adds it.
+func (s *Slots) Set(key string, v SlotValue) {
+	for i := range *s {
+		if (*s)[i].Key == key {
+			(*s)[i].RawValue = v
+			return
+		}
+	}
+
+	*s = append(*s, Slot{Key: key, RawValue: v})
+}
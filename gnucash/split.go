@@ -3,50 +3,124 @@ package gnucash
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 type Split struct {
 	ID              GUID            `xml:"id"`
 	ReconciledState ReconciledState `xml:"reconciled-state"`
+	ReconcileDate   Date            `xml:"reconcile-date>date"`
 	Value           Value           `xml:"value"`
 	Quantity        Value           `xml:"quantity"`
 	AccountID       GUID            `xml:"account"`
+	LotID           GUID            `xml:"lot"`
 	Memo            string          `xml:"memo"`
+	Action          string          `xml:"action"`
+	Slots           Slots           `xml:"slots>slot"`
 	Account         *Account        `xml:"-"`
+	Lot             *Lot            `xml:"-"`
+	Transaction     *Transaction    `xml:"-"`
+	Extra           Nodes           `xml:",any"`
+}
+
+// SlotOnlineID is the split slot in which GnuCash's importers store the
+// bank's id of the transaction (e.g.: the OFX FITID).
+const SlotOnlineID = "online_id"
+
+// OnlineID returns the bank's id of the transaction this split was imported
+// from, if any.
+func (s *Split) OnlineID() string {
+	for _, sl := range s.Slots {
+		if sl.Key == SlotOnlineID {
+			id, _ := sl.StringValue()
+			return id
+		}
+	}
+
+	return ""
+}
+
+func (s *Split) SetOnlineID(id string) {
+	s.Slots.Set(SlotOnlineID, SlotValue{Type: "string", Value: id})
 }
 
 func (s *Split) String() string {
 	dir := ">"
-	if s.Value < 0 {
+	if s.Value.Sign() < 0 {
 		dir = "<"
 	}
 
 	return fmt.Sprintf(
 		"[%s] %8.2f %s %s",
 		s.ReconciledState,
-		s.Value,
+		s.Value.Float64(),
 		dir,
 		s.Account.FQN,
 	)
 }
 
-func (s *Split) validate(lookup *AccountsLookup, prices Prices) error {
+// ValueAt returns the value of the split's quantity in the transaction's
+// currency using the price in effect at the given time. The recorded Value is
+// returned for splits in accounts denominated in that currency or when no
+// price is known.
+func (s *Split) ValueAt(prices *PriceIndex, at time.Time) Value {
+	if s.Account == nil || s.Transaction == nil || s.Account.Commodity == s.Transaction.Currency {
+		return s.Value
+	}
+
+	price, ok := prices.At(
+		s.Account.Commodity.FQN(),
+		s.Transaction.Currency.FQN(),
+		at,
+		PriceNearestBefore,
+	)
+	if !ok {
+		return s.Value
+	}
+
+	return s.Quantity.Mul(price.Value).Convert(s.Value.Denom(), RoundBankers)
+}
+
+func (s *Split) inAccount(accountID GUID, includeChildren bool) bool {
+	var seen map[*Account]struct{}
+	for p := s.Account; p != nil; p = p.Parent {
+		if p.ID == accountID {
+			return true
+		}
+
+		if !includeChildren {
+			break
+		}
+
+		// cycles are reported by Book.Check.
+		if seen == nil {
+			seen = make(map[*Account]struct{})
+		}
+		if _, ok := seen[p]; ok {
+			break
+		}
+		seen[p] = struct{}{}
+	}
+
+	return false
+}
+
+func (s *Split) validate(lookup *AccountsLookup, t *Transaction) error {
 	if s.ID == "" {
 		return errors.New("Empty split id")
 	}
 
 	s.Account, _ = lookup.ByGUID(s.AccountID)
-
-	if !s.Account.Commodity.IsCurrency() {
-		price := prices.LastFor(s.Account.Commodity.FQN())
-		s.Value = s.Quantity * price.Value
+	s.Transaction = t
+	s.Lot = nil
+	if s.LotID != "" {
+		// unknown lots are reported by Book.Check.
+		if s.Lot, _ = lookup.Lot(s.LotID); s.Lot != nil {
+			s.Lot.Splits = append(s.Lot.Splits, s)
+		}
 	}
 
-	if s.ReconciledState != ReconciledStateNew &&
-		s.ReconciledState != ReconciledStateCleared &&
-		s.ReconciledState != ReconciledStateReconciled &&
-		s.ReconciledState != ReconciledStateFrozen &&
-		s.ReconciledState != ReconciledStateVoid {
+	if !s.ReconciledState.Valid() {
 		return fmt.Errorf("Invalid reconciled state '%s'", s.ReconciledState)
 	}
 
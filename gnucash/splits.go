@@ -3,6 +3,7 @@ package gnucash
 import (
 	"regexp"
 	"strings"
+	"time"
 )
 
 type Splits []*Split
@@ -19,25 +20,69 @@ func (ss Splits) String() string {
 func (ss Splits) ValueForAccount(accountID GUID, includeChildren bool) Value {
 	var v Value
 	for _, s := range ss {
-		p := s.Account
-		for {
-			if p == nil {
-				break
-			}
+		if s.inAccount(accountID, includeChildren) {
+			v = v.Add(s.Value)
+		}
+	}
 
-			if p.ID == accountID {
-				v += s.Value
-			}
+	return v
+}
 
-			if !includeChildren {
-				break
-			}
+// ValuationForAccount is like ValueForAccount but values the quantities held
+// in each commodity at the prices in effect at the given time. Values are
+// expressed in currency, or in the currency the holdings were bought in when
+// currency is empty. Holdings without a price keep their recorded value,
+// an error is only returned when that value can not be expressed in currency.
+func (ss Splits) ValuationForAccount(
+	accountID GUID,
+	includeChildren bool,
+	conv *Converter,
+	currency CommodityFQN,
+	at time.Time,
+) (Value, error) {
+	type holding struct {
+		commodity CommodityFQN
+		currency  CommodityFQN
+	}
 
-			p = p.Parent
+	var v Value
+	holdings := make(map[holding]Splits)
+	order := make([]holding, 0)
+	for _, s := range ss {
+		if !s.inAccount(accountID, includeChildren) {
+			continue
 		}
+
+		if s.Transaction == nil {
+			v = v.Add(s.Value)
+			continue
+		}
+
+		h := holding{s.Account.Commodity.FQN(), s.Transaction.Currency.FQN()}
+		if _, ok := holdings[h]; !ok {
+			order = append(order, h)
+		}
+		holdings[h] = append(holdings[h], s)
 	}
 
-	return v
+	for _, h := range order {
+		l := holdings[h]
+		to := currency
+		if to == "" {
+			to = h.currency
+		}
+
+		hv, err := conv.Convert(l.Quantity(), h.commodity, to, at)
+		if err != nil {
+			hv, err = conv.Convert(l.Sum(), h.currency, to, at)
+		}
+		if err != nil {
+			return v, err
+		}
+		v = v.Add(hv)
+	}
+
+	return v, nil
 }
 
 func (ss Splits) Filter(
@@ -54,18 +99,27 @@ func (ss Splits) Filter(
 	return f
 }
 
+func (ss Splits) Quantity() Value {
+	var v Value
+	for _, s := range ss {
+		v = v.Add(s.Quantity)
+	}
+
+	return v
+}
+
 func (ss Splits) Sum() Value {
 	var v Value
 	for _, s := range ss {
-		v += s.Value
+		v = v.Add(s.Value)
 	}
 
 	return v
 }
 
-func (ss Splits) validate(lookup *AccountsLookup, prices Prices) error {
+func (ss Splits) validate(lookup *AccountsLookup, t *Transaction) error {
 	for _, s := range ss {
-		if err := s.validate(lookup, prices); err != nil {
+		if err := s.validate(lookup, t); err != nil {
 			return err
 		}
 	}
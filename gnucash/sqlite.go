@@ -0,0 +1,592 @@
+package gnucash
+
+import (
+	"database/sql"
+	nxml "encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+var ErrReadOnlyBackend = errors.New("writing to sqlite books is not supported")
+
+type Backend int
+
+const (
+	BackendXML Backend = iota
+	BackendSQLite
+)
+
+// kvp value types as stored in the slots.slot_type column.
+const (
+	sqlSlotInt64   = 1
+	sqlSlotDouble  = 2
+	sqlSlotNumeric = 3
+	sqlSlotString  = 4
+	sqlSlotGUID    = 5
+	sqlSlotTime    = 6
+	sqlSlotList    = 8
+	sqlSlotFrame   = 9
+	sqlSlotGDate   = 10
+)
+
+type sqlSlot struct {
+	name    string
+	typ     int
+	int64   sql.NullInt64
+	str     sql.NullString
+	double  sql.NullFloat64
+	time    sql.NullString
+	guid    sql.NullString
+	num     sql.NullInt64
+	denom   sql.NullInt64
+	gdate   sql.NullString
+	objGUID string
+}
+
+type sqlReader struct {
+	db    *sql.DB
+	slots map[string][]sqlSlot
+}
+
+func parseSQLDate(str string) (Date, error) {
+	if str == "" {
+		return Date{}, nil
+	}
+
+	formats := []string{
+		"2006-01-02 15:04:05",
+		"20060102150405",
+		"2006-01-02",
+		"20060102",
+	}
+
+	var err error
+	var t time.Time
+	for _, f := range formats {
+		t, err = time.ParseInLocation(f, str, time.UTC)
+		if err == nil {
+			return NewDate(t), nil
+		}
+	}
+
+	return Date{}, err
+}
+
+func (r *sqlReader) query(q string, cb func(rows *sql.Rows) error) error {
+	rows, err := r.db.Query(q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := cb(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *sqlReader) hasTable(name string) bool {
+	var n int
+	err := r.db.QueryRow(
+		"SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+		name,
+	).Scan(&n)
+	return err == nil && n != 0
+}
+
+func (r *sqlReader) readSlots() error {
+	r.slots = make(map[string][]sqlSlot)
+	return r.query(
+		`SELECT obj_guid, name, slot_type, int64_val, string_val, double_val,
+			timespec_val, guid_val, numeric_val_num, numeric_val_denom, gdate_val
+		FROM slots ORDER BY id`,
+		func(rows *sql.Rows) error {
+			s := sqlSlot{}
+			err := rows.Scan(
+				&s.objGUID,
+				&s.name,
+				&s.typ,
+				&s.int64,
+				&s.str,
+				&s.double,
+				&s.time,
+				&s.guid,
+				&s.num,
+				&s.denom,
+				&s.gdate,
+			)
+			if err != nil {
+				return err
+			}
+			r.slots[s.objGUID] = append(r.slots[s.objGUID], s)
+			return nil
+		},
+	)
+}
+
+func (r *sqlReader) slotsFor(guid string) Slots {
+	raw := r.slots[guid]
+	slots := make(Slots, 0, len(raw))
+	for _, s := range raw {
+		key := s.name
+		if i := strings.LastIndex(key, "/"); i != -1 {
+			key = key[i+1:]
+		}
+
+		v := SlotValue{}
+		switch s.typ {
+		case sqlSlotInt64:
+			v.Type, v.Value = "integer", strconv.FormatInt(s.int64.Int64, 10)
+		case sqlSlotDouble:
+			v.Type, v.Value = "double", strconv.FormatFloat(s.double.Float64, 'g', -1, 64)
+		case sqlSlotNumeric:
+			v.Type, v.Value = "numeric", NewValue(s.num.Int64, s.denom.Int64).String()
+		case sqlSlotString:
+			v.Type, v.Value = "string", s.str.String
+		case sqlSlotGUID:
+			v.Type, v.Value = "guid", s.guid.String
+		case sqlSlotTime:
+			d, _ := parseSQLDate(s.time.String)
+			v.Type = "timespec"
+			v.Extra = Nodes{{
+				Name: nxml.Name{Space: "ts", Local: "date"},
+				Text: d.Get().Format(dateFormat),
+			}}
+		case sqlSlotGDate:
+			d, _ := parseSQLDate(s.gdate.String)
+			v.Type = "gdate"
+			v.Extra = Nodes{{
+				Name: nxml.Name{Local: "gdate"},
+				Text: d.Get().Format("2006-01-02"),
+			}}
+		case sqlSlotFrame:
+			v.Type = "frame"
+			v.Slots = r.slotsFor(s.guid.String)
+		case sqlSlotList:
+			v.Type = "list"
+		default:
+			continue
+		}
+
+		slots = append(slots, Slot{Key: key, RawValue: v})
+	}
+
+	return slots
+}
+
+func (r *sqlReader) read() (*XML, error) {
+	book := &Book{Version: "2.0.0"}
+	var rootTemplate string
+	err := r.query(
+		"SELECT guid, root_template_guid FROM books LIMIT 1",
+		func(rows *sql.Rows) error {
+			return rows.Scan(&book.ID, &rootTemplate)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if book.ID == "" {
+		return &XML{Backend: BackendSQLite}, nil
+	}
+
+	if err := r.readSlots(); err != nil {
+		return nil, err
+	}
+	book.Slots = r.slotsFor(string(book.ID))
+
+	commodities := make(map[string]CommodityRef)
+	err = r.query(
+		"SELECT guid, namespace, mnemonic, fraction FROM commodities",
+		func(rows *sql.Rows) error {
+			var guid string
+			c := Commodity{}
+			if err := rows.Scan(&guid, &c.NS, &c.ID, &c.Fraction); err != nil {
+				return err
+			}
+			commodities[guid] = c.CommodityRef
+			if c.NS == "template" {
+				return nil
+			}
+			if c.IsCurrency() {
+				c.Fraction = 0
+			}
+			c.Slots = r.slotsFor(guid)
+			book.Commodities = append(book.Commodities, c)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.query(
+		`SELECT guid, commodity_guid, currency_guid, date, source, type,
+			value_num, value_denom
+		FROM prices`,
+		func(rows *sql.Rows) error {
+			var com, cur, date string
+			var source, typ sql.NullString
+			var num, denom int64
+			p := Price{}
+			err := rows.Scan(&p.ID, &com, &cur, &date, &source, &typ, &num, &denom)
+			if err != nil {
+				return err
+			}
+			p.Comodity, p.Currency = commodities[com], commodities[cur]
+			p.Source, p.Type = source.String, typ.String
+			p.Value = NewValue(num, denom)
+			p.Time, err = parseSQLDate(date)
+			book.Prices = append(book.Prices, p)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[GUID]struct{})
+	if rootTemplate != "" {
+		templates[GUID(rootTemplate)] = struct{}{}
+	}
+	err = r.query(
+		`SELECT guid, name, account_type, commodity_guid, commodity_scu,
+			parent_guid, description, hidden, placeholder
+		FROM accounts`,
+		func(rows *sql.Rows) error {
+			var com, parent, description sql.NullString
+			var hidden, placeholder sql.NullInt64
+			a := &Account{}
+			err := rows.Scan(
+				&a.ID,
+				&a.Name,
+				&a.Type,
+				&com,
+				&a.SCU,
+				&parent,
+				&description,
+				&hidden,
+				&placeholder,
+			)
+			if err != nil {
+				return err
+			}
+			a.Commodity = commodities[com.String]
+			a.ParentID = GUID(parent.String)
+			a.Description = description.String
+			a.Slots = r.slotsFor(string(a.ID))
+			if hidden.Int64 != 0 {
+				a.Slots = append(a.Slots, Slot{"hidden", SlotValue{Type: "string", Value: "true"}})
+			}
+			if placeholder.Int64 != 0 {
+				a.Slots = append(a.Slots, Slot{"placeholder", SlotValue{Type: "string", Value: "true"}})
+			}
+			book.Accounts = append(book.Accounts, a)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[GUID]*Account, len(book.Accounts))
+	for _, a := range book.Accounts {
+		byID[a.ID] = a
+	}
+	err = r.query(
+		"SELECT guid, account_guid FROM lots",
+		func(rows *sql.Rows) error {
+			var account sql.NullString
+			l := &Lot{}
+			if err := rows.Scan(&l.ID, &account); err != nil {
+				return err
+			}
+			l.Slots = r.slotsFor(string(l.ID))
+			if a, ok := byID[GUID(account.String)]; ok {
+				a.Lots = append(a.Lots, l)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// template accounts live in the same table but are stored in
+	// gnc:template-transactions in xml books.
+	accounts := make(Accounts, 0, len(book.Accounts))
+	templateAccounts := make(Accounts, 0)
+	for n := -1; n != len(templates); {
+		n = len(templates)
+		for _, a := range book.Accounts {
+			if _, ok := templates[a.ParentID]; ok {
+				templates[a.ID] = struct{}{}
+			}
+		}
+	}
+	for _, a := range book.Accounts {
+		if _, ok := templates[a.ID]; ok {
+			templateAccounts = append(templateAccounts, a)
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+	book.Accounts = accounts
+	book.Templates.Accounts = templateAccounts
+
+	txs := make(map[string]*Transaction)
+	err = r.query(
+		`SELECT guid, currency_guid, num, post_date, enter_date, description
+		FROM transactions`,
+		func(rows *sql.Rows) error {
+			var cur string
+			var posted, entered, description sql.NullString
+			t := &Transaction{}
+			err := rows.Scan(&t.ID, &cur, &t.Num, &posted, &entered, &description)
+			if err != nil {
+				return err
+			}
+			t.Currency = commodities[cur]
+			t.Description = description.String
+			if t.DatePosted, err = parseSQLDate(posted.String); err != nil {
+				return err
+			}
+			if t.DateEntered, err = parseSQLDate(entered.String); err != nil {
+				return err
+			}
+			t.Slots = r.slotsFor(string(t.ID))
+			txs[string(t.ID)] = t
+			book.Transactions = append(book.Transactions, t)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	template := make(map[*Transaction]struct{})
+	err = r.query(
+		`SELECT guid, tx_guid, account_guid, memo, action, reconcile_state,
+			reconcile_date, value_num, value_denom, quantity_num, quantity_denom,
+			lot_guid
+		FROM splits`,
+		func(rows *sql.Rows) error {
+			var tx, state string
+			var reconciled, lot sql.NullString
+			var vnum, vdenom, qnum, qdenom int64
+			s := &Split{}
+			err := rows.Scan(
+				&s.ID,
+				&tx,
+				&s.AccountID,
+				&s.Memo,
+				&s.Action,
+				&state,
+				&reconciled,
+				&vnum,
+				&vdenom,
+				&qnum,
+				&qdenom,
+				&lot,
+			)
+			if err != nil {
+				return err
+			}
+			s.LotID = GUID(lot.String)
+			if state != "" {
+				s.ReconciledState = ReconciledState(state[0])
+			}
+			s.Value, s.Quantity = NewValue(vnum, vdenom), NewValue(qnum, qdenom)
+			if s.ReconcileDate, err = parseSQLDate(reconciled.String); err != nil {
+				return err
+			}
+			s.Slots = r.slotsFor(string(s.ID))
+
+			t, ok := txs[tx]
+			if !ok {
+				return fmt.Errorf("split '%s' references unknown transaction '%s'", s.ID, tx)
+			}
+			if _, ok := templates[s.AccountID]; ok {
+				template[t] = struct{}{}
+			}
+			t.Splits = append(t.Splits, s)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make(Transactions, 0, len(book.Transactions))
+	for _, t := range book.Transactions {
+		if _, ok := template[t]; ok {
+			book.Templates.Transactions = append(book.Templates.Transactions, t)
+			continue
+		}
+		transactions = append(transactions, t)
+	}
+	book.Transactions = transactions
+
+	recurrences := make(map[string]Recurrences)
+	err = r.query(
+		`SELECT obj_guid, recurrence_mult, recurrence_period_type,
+			recurrence_period_start, recurrence_weekend_adjust
+		FROM recurrences ORDER BY id`,
+		func(rows *sql.Rows) error {
+			var obj, start string
+			var adj sql.NullString
+			rec := Recurrence{}
+			if err := rows.Scan(&obj, &rec.Mult, &rec.PeriodType, &start, &adj); err != nil {
+				return err
+			}
+			rec.WeekendAdj = WeekendAdjust(adj.String)
+			var err error
+			rec.Start, err = parseSQLDate(start)
+			recurrences[obj] = append(recurrences[obj], rec)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.query(
+		`SELECT guid, name, enabled, start_date, end_date, last_occur,
+			num_occur, rem_occur, auto_create, auto_notify, adv_creation,
+			adv_notify, instance_count, template_act_guid
+		FROM schedxactions`,
+		func(rows *sql.Rows) error {
+			var name, start, end, last sql.NullString
+			var enabled, create, notify int
+			s := &Scheduled{}
+			err := rows.Scan(
+				&s.ID,
+				&name,
+				&enabled,
+				&start,
+				&end,
+				&last,
+				&s.NumOccur,
+				&s.RemOccur,
+				&create,
+				&notify,
+				&s.AdvanceCreateDays,
+				&s.AdvanceRemindDays,
+				&s.InstanceCount,
+				&s.TemplateAccountID,
+			)
+			if err != nil {
+				return err
+			}
+			s.Name = name.String
+			s.Enabled = enabled != 0
+			s.AutoCreate = create != 0
+			s.AutoCreateNotify = notify != 0
+			if s.Start, err = parseSQLDate(start.String); err != nil {
+				return err
+			}
+			if s.End, err = parseSQLDate(end.String); err != nil {
+				return err
+			}
+			if s.Last, err = parseSQLDate(last.String); err != nil {
+				return err
+			}
+			s.Schedule = recurrences[string(s.ID)]
+			s.Slots = r.slotsFor(string(s.ID))
+			book.Scheduled = append(book.Scheduled, s)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(map[string]Slots)
+	err = r.query(
+		`SELECT budget_guid, account_guid, period_num, amount_num, amount_denom
+		FROM budget_amounts ORDER BY id`,
+		func(rows *sql.Rows) error {
+			var budget, account string
+			var period, num, denom int64
+			if err := rows.Scan(&budget, &account, &period, &num, &denom); err != nil {
+				return err
+			}
+			slots := amounts[budget]
+			i := 0
+			for ; i < len(slots); i++ {
+				if slots[i].Key == account {
+					break
+				}
+			}
+			if i == len(slots) {
+				slots = append(slots, Slot{account, SlotValue{Type: "frame"}})
+			}
+			slots[i].RawValue.Slots = append(slots[i].RawValue.Slots, Slot{
+				Key:      strconv.FormatInt(period, 10),
+				RawValue: SlotValue{Type: "numeric", Value: NewValue(num, denom).String()},
+			})
+			amounts[budget] = slots
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.query(
+		"SELECT guid, name, description, num_periods FROM budgets",
+		func(rows *sql.Rows) error {
+			var description sql.NullString
+			bg := &Budget{}
+			if err := rows.Scan(&bg.ID, &bg.Name, &description, &bg.NumPeriods); err != nil {
+				return err
+			}
+			bg.Description = description.String
+			if l := recurrences[string(bg.ID)]; len(l) != 0 {
+				bg.Recurrence = l[0]
+			}
+			bg.Slots = append(r.slotsFor(string(bg.ID)), amounts[string(bg.ID)]...)
+			book.Budgets = append(book.Budgets, bg)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.readBusiness(book, commodities); err != nil {
+		return nil, err
+	}
+
+	return &XML{Backend: BackendSQLite, Books: Books{book}}, nil
+}
+
+// ReadSQLite reads a book stored with GnuCash's sqlite backend into the same
+// structure Read produces for xml books.
+func ReadSQLite(path string) (*XML, error) {
+	escape := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+	db, err := sql.Open("sqlite", "file:"+escape.Replace(path)+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	r := &sqlReader{db: db}
+	xml, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return xml, xml.validate()
+}
@@ -0,0 +1,287 @@
+package gnucash
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// sqlRow is a row of a business table, keyed by column name. The business
+// tables differ slightly between GnuCash versions so they are read with
+// SELECT * and missing columns read as empty values.
+type sqlRow map[string]sql.NullString
+
+func (r sqlRow) str(k string) string { return r[k].String }
+
+func (r sqlRow) guid(k string) GUID { return GUID(r[k].String) }
+
+func (r sqlRow) int(k string) int {
+	n, _ := strconv.Atoi(r[k].String)
+	return n
+}
+
+func (r sqlRow) flag(k string) Flag { return r.int(k) != 0 }
+
+func (r sqlRow) value(prefix string) Value {
+	num, _ := strconv.ParseInt(r[prefix+"_num"].String, 10, 64)
+	denom, _ := strconv.ParseInt(r[prefix+"_denom"].String, 10, 64)
+	return NewValue(num, denom)
+}
+
+func (r sqlRow) date(k string) (Date, error) {
+	return parseSQLDate(r[k].String)
+}
+
+func (r sqlRow) address(prefix string) Address {
+	return Address{
+		Name:  r.str(prefix + "_name"),
+		Addr1: r.str(prefix + "_addr1"),
+		Addr2: r.str(prefix + "_addr2"),
+		Addr3: r.str(prefix + "_addr3"),
+		Addr4: r.str(prefix + "_addr4"),
+		Phone: r.str(prefix + "_phone"),
+		Fax:   r.str(prefix + "_fax"),
+		Email: r.str(prefix + "_email"),
+	}
+}
+
+func (r sqlRow) owner(prefix string) Owner {
+	return Owner{Type: ownerTypes[r.int(prefix+"_type")], ID: r.guid(prefix + "_guid")}
+}
+
+func (r sqlRow) taxIncluded(k string) string {
+	switch r.int(k) {
+	case 1:
+		return "YES"
+	case 2:
+		return "NO"
+	}
+	return "USEGLOBAL"
+}
+
+func (r *sqlReader) rows(table string, cb func(row sqlRow) error) error {
+	if !r.hasTable(table) {
+		return nil
+	}
+
+	rows, err := r.db.Query("SELECT * FROM " + table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	vals := make([]sql.NullString, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		row := make(sqlRow, len(cols))
+		for i, c := range cols {
+			row[c] = vals[i]
+		}
+		if err := cb(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *sqlReader) readBusiness(book *Book, commodities map[string]CommodityRef) error {
+	err := r.rows("billterms", func(row sqlRow) error {
+		bt := &BillTerm{
+			GUID:        row.guid("guid"),
+			Name:        row.str("name"),
+			Description: row.str("description"),
+			RefCount:    row.int("refcount"),
+			Invisible:   row.flag("invisible"),
+		}
+		if strings.Contains(strings.ToUpper(row.str("type")), "PROXIMO") {
+			bt.Proximo = &BillTermProximo{
+				DueDay:      row.int("duedays"),
+				DiscountDay: row.int("discountdays"),
+				Discount:    row.value("discount"),
+				CutoffDay:   row.int("cutoff"),
+			}
+		} else {
+			bt.Days = &BillTermDays{
+				DueDays:      row.int("duedays"),
+				DiscountDays: row.int("discountdays"),
+				Discount:     row.value("discount"),
+			}
+		}
+		book.BillTerms = append(book.BillTerms, bt)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	taxTables := make(map[string]*TaxTable)
+	err = r.rows("taxtables", func(row sqlRow) error {
+		tt := &TaxTable{
+			GUID:      row.guid("guid"),
+			Name:      row.str("name"),
+			RefCount:  row.int("refcount"),
+			Invisible: row.flag("invisible"),
+		}
+		taxTables[string(tt.GUID)] = tt
+		book.TaxTables = append(book.TaxTables, tt)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.rows("taxtable_entries", func(row sqlRow) error {
+		tt, ok := taxTables[row.str("taxtable")]
+		if !ok {
+			return nil
+		}
+		typ := "VALUE"
+		if row.int("type") == 2 {
+			typ = "PERCENT"
+		}
+		tt.Entries = append(tt.Entries, TaxTableEntry{
+			AccountID: row.guid("account"),
+			Amount:    row.value("amount"),
+			Type:      typ,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.rows("customers", func(row sqlRow) error {
+		c := &Customer{
+			GUID:        row.guid("guid"),
+			Name:        row.str("name"),
+			ID:          row.str("id"),
+			Addr:        row.address("addr"),
+			ShipAddr:    row.address("shipaddr"),
+			Notes:       row.str("notes"),
+			TermsID:     row.guid("terms"),
+			TaxIncluded: row.taxIncluded("tax_included"),
+			Active:      row.flag("active"),
+			Discount:    row.value("discount"),
+			Credit:      row.value("credit"),
+			Currency:    commodities[row.str("currency")],
+			UseTaxTable: row.flag("tax_override"),
+			TaxTableID:  row.guid("taxtable"),
+			Slots:       r.slotsFor(row.str("guid")),
+		}
+		book.Customers = append(book.Customers, c)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.rows("vendors", func(row sqlRow) error {
+		v := &Vendor{
+			GUID:        row.guid("guid"),
+			Name:        row.str("name"),
+			ID:          row.str("id"),
+			Addr:        row.address("addr"),
+			Notes:       row.str("notes"),
+			TermsID:     row.guid("terms"),
+			TaxIncluded: row.taxIncluded("tax_inc"),
+			Active:      row.flag("active"),
+			Currency:    commodities[row.str("currency")],
+			UseTaxTable: row.flag("tax_override"),
+			TaxTableID:  row.guid("tax_table"),
+			Slots:       r.slotsFor(row.str("guid")),
+		}
+		book.Vendors = append(book.Vendors, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.rows("jobs", func(row sqlRow) error {
+		book.Jobs = append(book.Jobs, &Job{
+			GUID:      row.guid("guid"),
+			ID:        row.str("id"),
+			Name:      row.str("name"),
+			Reference: row.str("reference"),
+			Owner:     row.owner("owner"),
+			Active:    row.flag("active"),
+			Slots:     r.slotsFor(row.str("guid")),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.rows("invoices", func(row sqlRow) error {
+		inv := &Invoice{
+			GUID:              row.guid("guid"),
+			ID:                row.str("id"),
+			Owner:             row.owner("owner"),
+			BillingID:         row.str("billing_id"),
+			Notes:             row.str("notes"),
+			Active:            row.flag("active"),
+			PostTransactionID: row.guid("post_txn"),
+			PostLotID:         row.guid("post_lot"),
+			PostAccountID:     row.guid("post_acc"),
+			Currency:          commodities[row.str("currency")],
+			TermsID:           row.guid("terms"),
+			BillTo:            row.owner("billto"),
+			Slots:             r.slotsFor(row.str("guid")),
+		}
+		if inv.BillTo.ID == "" {
+			inv.BillTo = Owner{}
+		}
+		var err error
+		if inv.Opened, err = row.date("date_opened"); err != nil {
+			return err
+		}
+		if inv.Posted, err = row.date("date_posted"); err != nil {
+			return err
+		}
+		book.Invoices = append(book.Invoices, inv)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.rows("entries", func(row sqlRow) error {
+		en := &Entry{
+			GUID:          row.guid("guid"),
+			Description:   row.str("description"),
+			Action:        row.str("action"),
+			Notes:         row.str("notes"),
+			Quantity:      row.value("quantity"),
+			InvAccountID:  row.guid("i_acct"),
+			InvPrice:      row.value("i_price"),
+			InvoiceID:     row.guid("invoice"),
+			BillAccountID: row.guid("b_acct"),
+			BillPrice:     row.value("b_price"),
+			BillID:        row.guid("bill"),
+			Slots:         r.slotsFor(row.str("guid")),
+		}
+		var err error
+		if en.Date, err = row.date("date"); err != nil {
+			return err
+		}
+		if en.Entered, err = row.date("date_entered"); err != nil {
+			return err
+		}
+		book.Entries = append(book.Entries, en)
+		return nil
+	})
+}
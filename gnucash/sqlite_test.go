@@ -0,0 +1,617 @@
+package gnucash
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sqliteSchema is the subset of GnuCash's sqlite schema the reader uses.
+const sqliteSchema = `
+CREATE TABLE books(guid text(32) PRIMARY KEY NOT NULL, root_account_guid text(32) NOT NULL, root_template_guid text(32) NOT NULL);
+CREATE TABLE commodities(guid text(32) PRIMARY KEY NOT NULL, namespace text(2048) NOT NULL, mnemonic text(2048) NOT NULL, fullname text(2048), cusip text(2048), fraction integer NOT NULL, quote_flag integer NOT NULL, quote_source text(2048), quote_tz text(2048));
+CREATE TABLE accounts(guid text(32) PRIMARY KEY NOT NULL, name text(2048) NOT NULL, account_type text(2048) NOT NULL, commodity_guid text(32), commodity_scu integer NOT NULL, non_std_scu integer NOT NULL, parent_guid text(32), code text(2048), description text(2048), hidden integer, placeholder integer);
+CREATE TABLE transactions(guid text(32) PRIMARY KEY NOT NULL, currency_guid text(32) NOT NULL, num text(2048) NOT NULL, post_date text(19), enter_date text(19), description text(2048));
+CREATE TABLE splits(guid text(32) PRIMARY KEY NOT NULL, tx_guid text(32) NOT NULL, account_guid text(32) NOT NULL, memo text(2048) NOT NULL, action text(2048) NOT NULL, reconcile_state text(1) NOT NULL, reconcile_date text(19), value_num bigint NOT NULL, value_denom bigint NOT NULL, quantity_num bigint NOT NULL, quantity_denom bigint NOT NULL, lot_guid text(32));
+CREATE TABLE prices(guid text(32) PRIMARY KEY NOT NULL, commodity_guid text(32) NOT NULL, currency_guid text(32) NOT NULL, date text(19) NOT NULL, source text(2048), type text(2048), value_num bigint NOT NULL, value_denom bigint NOT NULL);
+CREATE TABLE slots(id integer PRIMARY KEY AUTOINCREMENT NOT NULL, obj_guid text(32) NOT NULL, name text(4096) NOT NULL, slot_type integer NOT NULL, int64_val bigint, string_val text(4096), double_val float8, timespec_val text(19), guid_val text(32), numeric_val_num bigint, numeric_val_denom bigint, gdate_val date);
+CREATE TABLE schedxactions(guid text(32) PRIMARY KEY NOT NULL, name text(2048), enabled integer NOT NULL, start_date date, end_date date, last_occur date, num_occur integer NOT NULL, rem_occur integer NOT NULL, auto_create integer NOT NULL, auto_notify integer NOT NULL, adv_creation integer NOT NULL, adv_notify integer NOT NULL, instance_count integer NOT NULL, template_act_guid text(32) NOT NULL);
+CREATE TABLE recurrences(id integer PRIMARY KEY AUTOINCREMENT NOT NULL, obj_guid text(32) NOT NULL, recurrence_mult integer NOT NULL, recurrence_period_type text(2048) NOT NULL, recurrence_period_start date NOT NULL, recurrence_weekend_adjust text(2048) NOT NULL);
+CREATE TABLE budgets(guid text(32) PRIMARY KEY NOT NULL, name text(2048) NOT NULL, description text(2048), num_periods integer NOT NULL);
+CREATE TABLE budget_amounts(id integer PRIMARY KEY AUTOINCREMENT NOT NULL, budget_guid text(32) NOT NULL, account_guid text(32) NOT NULL, period_num integer NOT NULL, amount_num bigint NOT NULL, amount_denom bigint NOT NULL);
+CREATE TABLE lots(guid text(32) PRIMARY KEY NOT NULL, account_guid text(32), is_closed integer NOT NULL);
+CREATE TABLE billterms(guid text(32) PRIMARY KEY NOT NULL, name text(2048) NOT NULL, description text(2048) NOT NULL, refcount integer NOT NULL, invisible integer NOT NULL, parent text(32), type text(2048) NOT NULL, duedays integer, discountdays integer, discount_num bigint, discount_denom bigint, cutoff integer);
+CREATE TABLE customers(guid text(32) PRIMARY KEY NOT NULL, name text(2048) NOT NULL, id text(2048) NOT NULL, notes text(2048) NOT NULL, active integer NOT NULL, discount_num bigint NOT NULL, discount_denom bigint NOT NULL, credit_num bigint NOT NULL, credit_denom bigint NOT NULL, currency text(32) NOT NULL, tax_override integer NOT NULL, addr_name text(1024), addr_addr1 text(1024), addr_addr2 text(1024), addr_addr3 text(1024), addr_addr4 text(1024), addr_phone text(128), addr_fax text(128), addr_email text(256), shipaddr_name text(1024), shipaddr_addr1 text(1024), shipaddr_addr2 text(1024), shipaddr_addr3 text(1024), shipaddr_addr4 text(1024), shipaddr_phone text(128), shipaddr_fax text(128), shipaddr_email text(256), terms text(32), tax_included integer, taxtable text(32));
+CREATE TABLE invoices(guid text(32) PRIMARY KEY NOT NULL, id text(2048) NOT NULL, date_opened text(19), date_posted text(19), notes text(2048) NOT NULL, active integer NOT NULL, currency text(32) NOT NULL, owner_type integer, owner_guid text(32), terms text(32), billing_id text(2048), post_txn text(32), post_lot text(32), post_acc text(32), billto_type integer, billto_guid text(32), charge_amt_num bigint, charge_amt_denom bigint);
+`
+
+// sqliteBook is the content of sqliteXMLBook stored the way GnuCash's
+// sqlite backend does.
+const sqliteBook = `
+INSERT INTO books VALUES('b0000000000000000000000000000001', 'a0000000000000000000000000000000', 'r0000000000000000000000000000000');
+INSERT INTO commodities VALUES('c0000000000000000000000000000001', 'CURRENCY', 'EUR', 'Euro', '978', 100, 1, 'currency', '');
+INSERT INTO commodities VALUES('c0000000000000000000000000000002', 'NASDAQ', 'AAPL', 'Apple', '', 10000, 0, NULL, NULL);
+INSERT INTO commodities VALUES('c0000000000000000000000000000003', 'template', 'template', 'template', 'template', 1, 0, NULL, NULL);
+INSERT INTO prices VALUES('p0000000000000000000000000000001', 'c0000000000000000000000000000002', 'c0000000000000000000000000000001', '2024-01-10 10:59:00', 'user:price-editor', 'last', 20000, 100);
+
+INSERT INTO accounts VALUES('a0000000000000000000000000000000', 'Root Account', 'ROOT', 'c0000000000000000000000000000001', 100, 0, NULL, '', '', 0, 0);
+INSERT INTO accounts VALUES('a0000000000000000000000000000001', 'Assets', 'ASSET', 'c0000000000000000000000000000001', 100, 0, 'a0000000000000000000000000000000', '', '', 0, 1);
+INSERT INTO accounts VALUES('a0000000000000000000000000000002', 'Bank', 'BANK', 'c0000000000000000000000000000001', 100, 0, 'a0000000000000000000000000000001', '', 'Checking', 0, 0);
+INSERT INTO accounts VALUES('a0000000000000000000000000000003', 'Broker', 'STOCK', 'c0000000000000000000000000000002', 10000, 0, 'a0000000000000000000000000000001', '', '', 1, 0);
+INSERT INTO accounts VALUES('a0000000000000000000000000000004', 'Salary', 'INCOME', 'c0000000000000000000000000000001', 100, 0, 'a0000000000000000000000000000000', '', '', 0, 0);
+INSERT INTO accounts VALUES('r0000000000000000000000000000000', 'Template Root', 'ROOT', NULL, 0, 0, NULL, '', '', 0, 0);
+INSERT INTO accounts VALUES('r0000000000000000000000000000001', 'x0000000000000000000000000000001', 'BANK', 'c0000000000000000000000000000003', 1, 0, 'r0000000000000000000000000000000', '', '', 0, 0);
+INSERT INTO lots VALUES('l0000000000000000000000000000001', 'a0000000000000000000000000000003', 0);
+
+INSERT INTO transactions VALUES('t0000000000000000000000000000001', 'c0000000000000000000000000000001', '', '2024-01-01 10:59:00', '2024-01-01 12:00:00', 'Salary');
+INSERT INTO splits VALUES('s0000000000000000000000000000001', 't0000000000000000000000000000001', 'a0000000000000000000000000000002', 'net', '', 'y', '2024-01-31 10:59:00', 300000, 100, 300000, 100, NULL);
+INSERT INTO splits VALUES('s0000000000000000000000000000002', 't0000000000000000000000000000001', 'a0000000000000000000000000000004', '', '', 'n', NULL, -300000, 100, -300000, 100, NULL);
+INSERT INTO transactions VALUES('t0000000000000000000000000000002', 'c0000000000000000000000000000001', '7', '2024-01-10 10:59:00', '2024-01-10 10:59:00', 'Buy AAPL');
+INSERT INTO splits VALUES('s0000000000000000000000000000003', 't0000000000000000000000000000002', 'a0000000000000000000000000000003', '', 'Buy', 'c', NULL, 40000, 100, 20000, 10000, 'l0000000000000000000000000000001');
+INSERT INTO splits VALUES('s0000000000000000000000000000004', 't0000000000000000000000000000002', 'a0000000000000000000000000000002', '', '', 'n', NULL, -40000, 100, -40000, 100, NULL);
+
+INSERT INTO transactions VALUES('t0000000000000000000000000000003', 'c0000000000000000000000000000001', '', '2024-01-01 10:59:00', '2024-01-01 10:59:00', 'Salary');
+INSERT INTO splits VALUES('s0000000000000000000000000000005', 't0000000000000000000000000000003', 'r0000000000000000000000000000001', '', '', 'n', NULL, 0, 1, 0, 1, NULL);
+INSERT INTO splits VALUES('s0000000000000000000000000000006', 't0000000000000000000000000000003', 'r0000000000000000000000000000001', '', '', 'n', NULL, 0, 1, 0, 1, NULL);
+
+INSERT INTO schedxactions VALUES('x0000000000000000000000000000001', 'Salary', 1, '20240101', NULL, '20240101', 0, 0, 0, 0, 0, 0, 1, 'r0000000000000000000000000000001');
+INSERT INTO recurrences(obj_guid, recurrence_mult, recurrence_period_type, recurrence_period_start, recurrence_weekend_adjust) VALUES('x0000000000000000000000000000001', 1, 'month', '20240101', 'back');
+
+INSERT INTO budgets VALUES('g0000000000000000000000000000001', '2024', 'Yearly budget', 12);
+INSERT INTO recurrences(obj_guid, recurrence_mult, recurrence_period_type, recurrence_period_start, recurrence_weekend_adjust) VALUES('g0000000000000000000000000000001', 1, 'month', '20240101', 'none');
+INSERT INTO budget_amounts(budget_guid, account_guid, period_num, amount_num, amount_denom) VALUES('g0000000000000000000000000000001', 'a0000000000000000000000000000004', 0, -300000, 100);
+INSERT INTO budget_amounts(budget_guid, account_guid, period_num, amount_num, amount_denom) VALUES('g0000000000000000000000000000001', 'a0000000000000000000000000000004', 1, -310000, 100);
+
+INSERT INTO slots(obj_guid, name, slot_type, guid_val) VALUES('b0000000000000000000000000000001', 'features', 9, 'f0000000000000000000000000000001');
+INSERT INTO slots(obj_guid, name, slot_type, string_val) VALUES('f0000000000000000000000000000001', 'features/Use natural signs in budget amounts', 4, 'Use natural signs');
+INSERT INTO slots(obj_guid, name, slot_type, guid_val) VALUES('a0000000000000000000000000000002', 'reconcile-info', 9, 'f0000000000000000000000000000002');
+INSERT INTO slots(obj_guid, name, slot_type, guid_val) VALUES('f0000000000000000000000000000002', 'reconcile-info/last-interval', 9, 'f0000000000000000000000000000003');
+INSERT INTO slots(obj_guid, name, slot_type, int64_val) VALUES('f0000000000000000000000000000003', 'reconcile-info/last-interval/months', 1, 1);
+INSERT INTO slots(obj_guid, name, slot_type, timespec_val) VALUES('f0000000000000000000000000000002', 'reconcile-info/last-date', 6, '2024-01-31 10:59:00');
+INSERT INTO slots(obj_guid, name, slot_type, string_val) VALUES('t0000000000000000000000000000001', 'notes', 4, 'paid late');
+INSERT INTO slots(obj_guid, name, slot_type, gdate_val) VALUES('t0000000000000000000000000000001', 'date-posted', 10, '20240101');
+INSERT INTO slots(obj_guid, name, slot_type, string_val) VALUES('l0000000000000000000000000000001', 'title', 4, 'Lot 1');
+INSERT INTO slots(obj_guid, name, slot_type, guid_val) VALUES('s0000000000000000000000000000005', 'sched-xaction', 9, 'f0000000000000000000000000000005');
+INSERT INTO slots(obj_guid, name, slot_type, guid_val) VALUES('f0000000000000000000000000000005', 'sched-xaction/account', 5, 'a0000000000000000000000000000002');
+INSERT INTO slots(obj_guid, name, slot_type, string_val) VALUES('f0000000000000000000000000000005', 'sched-xaction/debit-formula', 4, '3000');
+INSERT INTO slots(obj_guid, name, slot_type, numeric_val_num, numeric_val_denom) VALUES('f0000000000000000000000000000005', 'sched-xaction/debit-numeric', 3, 3000, 1);
+INSERT INTO slots(obj_guid, name, slot_type, guid_val) VALUES('s0000000000000000000000000000006', 'sched-xaction', 9, 'f0000000000000000000000000000006');
+INSERT INTO slots(obj_guid, name, slot_type, guid_val) VALUES('f0000000000000000000000000000006', 'sched-xaction/account', 5, 'a0000000000000000000000000000004');
+INSERT INTO slots(obj_guid, name, slot_type, string_val) VALUES('f0000000000000000000000000000006', 'sched-xaction/credit-formula', 4, '3000');
+INSERT INTO slots(obj_guid, name, slot_type, numeric_val_num, numeric_val_denom) VALUES('f0000000000000000000000000000006', 'sched-xaction/credit-numeric', 3, 3000, 1);
+
+INSERT INTO billterms VALUES('e0000000000000000000000000000001', 'Net 30', '30 days', 1, 0, NULL, 'GNC_TERM_TYPE_DAYS', 30, 0, 0, 1, 0);
+INSERT INTO customers(guid, name, id, notes, active, discount_num, discount_denom, credit_num, credit_denom, currency, tax_override, addr_name, addr_addr1, terms, tax_included)
+	VALUES('e0000000000000000000000000000002', 'Acme', '000001', '', 1, 0, 1, 0, 1, 'c0000000000000000000000000000001', 0, 'Acme Corp', 'Street 1', 'e0000000000000000000000000000001', 0);
+INSERT INTO invoices(guid, id, date_opened, notes, active, currency, owner_type, owner_guid, terms, billing_id)
+	VALUES('e0000000000000000000000000000003', '000001', '2024-01-05 10:59:00', '', 1, 'c0000000000000000000000000000001', 2, 'e0000000000000000000000000000002', 'e0000000000000000000000000000001', 'PO-1');
+`
+
+// sqliteXMLBook is sqliteBook as GnuCash's xml backend stores it.
+const sqliteXMLBook = `<?xml version="1.0" encoding="utf-8" ?>
+<gnc-v2
+     xmlns:gnc="http://www.gnucash.org/XML/gnc"
+     xmlns:act="http://www.gnucash.org/XML/act"
+     xmlns:book="http://www.gnucash.org/XML/book"
+     xmlns:cd="http://www.gnucash.org/XML/cd"
+     xmlns:cmdty="http://www.gnucash.org/XML/cmdty"
+     xmlns:price="http://www.gnucash.org/XML/price"
+     xmlns:slot="http://www.gnucash.org/XML/slot"
+     xmlns:split="http://www.gnucash.org/XML/split"
+     xmlns:sx="http://www.gnucash.org/XML/sx"
+     xmlns:trn="http://www.gnucash.org/XML/trn"
+     xmlns:ts="http://www.gnucash.org/XML/ts"
+     xmlns:fs="http://www.gnucash.org/XML/fs"
+     xmlns:bgt="http://www.gnucash.org/XML/bgt"
+     xmlns:recurrence="http://www.gnucash.org/XML/recurrence"
+     xmlns:lot="http://www.gnucash.org/XML/lot"
+     xmlns:addr="http://www.gnucash.org/XML/addr"
+     xmlns:billterm="http://www.gnucash.org/XML/billterm"
+     xmlns:bt-days="http://www.gnucash.org/XML/bt-days"
+     xmlns:bt-prox="http://www.gnucash.org/XML/bt-prox"
+     xmlns:cust="http://www.gnucash.org/XML/cust"
+     xmlns:employee="http://www.gnucash.org/XML/employee"
+     xmlns:entry="http://www.gnucash.org/XML/entry"
+     xmlns:invoice="http://www.gnucash.org/XML/invoice"
+     xmlns:job="http://www.gnucash.org/XML/job"
+     xmlns:order="http://www.gnucash.org/XML/order"
+     xmlns:owner="http://www.gnucash.org/XML/owner"
+     xmlns:taxtable="http://www.gnucash.org/XML/taxtable"
+     xmlns:tte="http://www.gnucash.org/XML/tte"
+     xmlns:vendor="http://www.gnucash.org/XML/vendor">
+<gnc:count-data cd:type="book">1</gnc:count-data>
+<gnc:book version="2.0.0">
+<book:id type="guid">b0000000000000000000000000000001</book:id>
+<book:slots>
+  <slot>
+    <slot:key>features</slot:key>
+    <slot:value type="frame">
+      <slot>
+        <slot:key>Use natural signs in budget amounts</slot:key>
+        <slot:value type="string">Use natural signs</slot:value>
+      </slot>
+    </slot:value>
+  </slot>
+</book:slots>
+<gnc:count-data cd:type="commodity">2</gnc:count-data>
+<gnc:count-data cd:type="account">5</gnc:count-data>
+<gnc:count-data cd:type="transaction">2</gnc:count-data>
+<gnc:count-data cd:type="schedxaction">1</gnc:count-data>
+<gnc:count-data cd:type="budget">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncBillTerm">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncCustomer">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncInvoice">1</gnc:count-data>
+<gnc:count-data cd:type="price">1</gnc:count-data>
+<gnc:commodity version="2.0.0">
+  <cmdty:space>CURRENCY</cmdty:space>
+  <cmdty:id>EUR</cmdty:id>
+</gnc:commodity>
+<gnc:commodity version="2.0.0">
+  <cmdty:space>NASDAQ</cmdty:space>
+  <cmdty:id>AAPL</cmdty:id>
+  <cmdty:fraction>10000</cmdty:fraction>
+</gnc:commodity>
+<gnc:pricedb version="1">
+  <price>
+    <price:id type="guid">p0000000000000000000000000000001</price:id>
+    <price:commodity>
+      <cmdty:space>NASDAQ</cmdty:space>
+      <cmdty:id>AAPL</cmdty:id>
+    </price:commodity>
+    <price:currency>
+      <cmdty:space>CURRENCY</cmdty:space>
+      <cmdty:id>EUR</cmdty:id>
+    </price:currency>
+    <price:time>
+      <ts:date>2024-01-10 10:59:00 +0000</ts:date>
+    </price:time>
+    <price:source>user:price-editor</price:source>
+    <price:type>last</price:type>
+    <price:value>20000/100</price:value>
+  </price>
+</gnc:pricedb>
+<gnc:account version="2.0.0">
+  <act:name>Root Account</act:name>
+  <act:id type="guid">a0000000000000000000000000000000</act:id>
+  <act:type>ROOT</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>Assets</act:name>
+  <act:id type="guid">a0000000000000000000000000000001</act:id>
+  <act:type>ASSET</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+  <act:slots>
+    <slot>
+      <slot:key>placeholder</slot:key>
+      <slot:value type="string">true</slot:value>
+    </slot>
+  </act:slots>
+  <act:parent type="guid">a0000000000000000000000000000000</act:parent>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>Bank</act:name>
+  <act:id type="guid">a0000000000000000000000000000002</act:id>
+  <act:type>BANK</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+  <act:description>Checking</act:description>
+  <act:slots>
+    <slot>
+      <slot:key>reconcile-info</slot:key>
+      <slot:value type="frame">
+        <slot>
+          <slot:key>last-interval</slot:key>
+          <slot:value type="frame">
+            <slot>
+              <slot:key>months</slot:key>
+              <slot:value type="integer">1</slot:value>
+            </slot>
+          </slot:value>
+        </slot>
+        <slot>
+          <slot:key>last-date</slot:key>
+          <slot:value type="timespec">
+            <ts:date>2024-01-31 10:59:00 +0000</ts:date>
+          </slot:value>
+        </slot>
+      </slot:value>
+    </slot>
+  </act:slots>
+  <act:parent type="guid">a0000000000000000000000000000001</act:parent>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>Broker</act:name>
+  <act:id type="guid">a0000000000000000000000000000003</act:id>
+  <act:type>STOCK</act:type>
+  <act:commodity>
+    <cmdty:space>NASDAQ</cmdty:space>
+    <cmdty:id>AAPL</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>10000</act:commodity-scu>
+  <act:slots>
+    <slot>
+      <slot:key>hidden</slot:key>
+      <slot:value type="string">true</slot:value>
+    </slot>
+  </act:slots>
+  <act:parent type="guid">a0000000000000000000000000000001</act:parent>
+  <act:lots>
+    <gnc:lot version="2.0.0">
+      <lot:id type="guid">l0000000000000000000000000000001</lot:id>
+      <lot:slots>
+        <slot>
+          <slot:key>title</slot:key>
+          <slot:value type="string">Lot 1</slot:value>
+        </slot>
+      </lot:slots>
+    </gnc:lot>
+  </act:lots>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>Salary</act:name>
+  <act:id type="guid">a0000000000000000000000000000004</act:id>
+  <act:type>INCOME</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+  <act:parent type="guid">a0000000000000000000000000000000</act:parent>
+</gnc:account>
+<gnc:transaction version="2.0.0">
+  <trn:id type="guid">t0000000000000000000000000000001</trn:id>
+  <trn:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </trn:currency>
+  <trn:date-posted>
+    <ts:date>2024-01-01 10:59:00 +0000</ts:date>
+  </trn:date-posted>
+  <trn:date-entered>
+    <ts:date>2024-01-01 12:00:00 +0000</ts:date>
+  </trn:date-entered>
+  <trn:description>Salary</trn:description>
+  <trn:slots>
+    <slot>
+      <slot:key>notes</slot:key>
+      <slot:value type="string">paid late</slot:value>
+    </slot>
+    <slot>
+      <slot:key>date-posted</slot:key>
+      <slot:value type="gdate">
+        <gdate>2024-01-01</gdate>
+      </slot:value>
+    </slot>
+  </trn:slots>
+  <trn:splits>
+    <trn:split>
+      <split:id type="guid">s0000000000000000000000000000001</split:id>
+      <split:memo>net</split:memo>
+      <split:reconciled-state>y</split:reconciled-state>
+      <split:reconcile-date>
+        <ts:date>2024-01-31 10:59:00 +0000</ts:date>
+      </split:reconcile-date>
+      <split:value>300000/100</split:value>
+      <split:quantity>300000/100</split:quantity>
+      <split:account type="guid">a0000000000000000000000000000002</split:account>
+    </trn:split>
+    <trn:split>
+      <split:id type="guid">s0000000000000000000000000000002</split:id>
+      <split:reconciled-state>n</split:reconciled-state>
+      <split:value>-300000/100</split:value>
+      <split:quantity>-300000/100</split:quantity>
+      <split:account type="guid">a0000000000000000000000000000004</split:account>
+    </trn:split>
+  </trn:splits>
+</gnc:transaction>
+<gnc:transaction version="2.0.0">
+  <trn:id type="guid">t0000000000000000000000000000002</trn:id>
+  <trn:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </trn:currency>
+  <trn:num>7</trn:num>
+  <trn:date-posted>
+    <ts:date>2024-01-10 10:59:00 +0000</ts:date>
+  </trn:date-posted>
+  <trn:date-entered>
+    <ts:date>2024-01-10 10:59:00 +0000</ts:date>
+  </trn:date-entered>
+  <trn:description>Buy AAPL</trn:description>
+  <trn:splits>
+    <trn:split>
+      <split:id type="guid">s0000000000000000000000000000003</split:id>
+      <split:action>Buy</split:action>
+      <split:reconciled-state>c</split:reconciled-state>
+      <split:value>40000/100</split:value>
+      <split:quantity>20000/10000</split:quantity>
+      <split:account type="guid">a0000000000000000000000000000003</split:account>
+      <split:lot type="guid">l0000000000000000000000000000001</split:lot>
+    </trn:split>
+    <trn:split>
+      <split:id type="guid">s0000000000000000000000000000004</split:id>
+      <split:reconciled-state>n</split:reconciled-state>
+      <split:value>-40000/100</split:value>
+      <split:quantity>-40000/100</split:quantity>
+      <split:account type="guid">a0000000000000000000000000000002</split:account>
+    </trn:split>
+  </trn:splits>
+</gnc:transaction>
+<gnc:template-transactions>
+  <gnc:account version="2.0.0">
+    <act:name>Template Root</act:name>
+    <act:id type="guid">r0000000000000000000000000000000</act:id>
+    <act:type>ROOT</act:type>
+  </gnc:account>
+  <gnc:account version="2.0.0">
+    <act:name>x0000000000000000000000000000001</act:name>
+    <act:id type="guid">r0000000000000000000000000000001</act:id>
+    <act:type>BANK</act:type>
+    <act:commodity>
+      <cmdty:space>template</cmdty:space>
+      <cmdty:id>template</cmdty:id>
+    </act:commodity>
+    <act:commodity-scu>1</act:commodity-scu>
+    <act:parent type="guid">r0000000000000000000000000000000</act:parent>
+  </gnc:account>
+  <gnc:transaction version="2.0.0">
+    <trn:id type="guid">t0000000000000000000000000000003</trn:id>
+    <trn:currency>
+      <cmdty:space>CURRENCY</cmdty:space>
+      <cmdty:id>EUR</cmdty:id>
+    </trn:currency>
+    <trn:date-posted>
+      <ts:date>2024-01-01 10:59:00 +0000</ts:date>
+    </trn:date-posted>
+    <trn:date-entered>
+      <ts:date>2024-01-01 10:59:00 +0000</ts:date>
+    </trn:date-entered>
+    <trn:description>Salary</trn:description>
+    <trn:splits>
+      <trn:split>
+        <split:id type="guid">s0000000000000000000000000000005</split:id>
+        <split:reconciled-state>n</split:reconciled-state>
+        <split:value>0/1</split:value>
+        <split:quantity>0/1</split:quantity>
+        <split:account type="guid">r0000000000000000000000000000001</split:account>
+        <split:slots>
+          <slot>
+            <slot:key>sched-xaction</slot:key>
+            <slot:value type="frame">
+              <slot>
+                <slot:key>account</slot:key>
+                <slot:value type="guid">a0000000000000000000000000000002</slot:value>
+              </slot>
+              <slot>
+                <slot:key>debit-formula</slot:key>
+                <slot:value type="string">3000</slot:value>
+              </slot>
+              <slot>
+                <slot:key>debit-numeric</slot:key>
+                <slot:value type="numeric">3000/1</slot:value>
+              </slot>
+            </slot:value>
+          </slot>
+        </split:slots>
+      </trn:split>
+      <trn:split>
+        <split:id type="guid">s0000000000000000000000000000006</split:id>
+        <split:reconciled-state>n</split:reconciled-state>
+        <split:value>0/1</split:value>
+        <split:quantity>0/1</split:quantity>
+        <split:account type="guid">r0000000000000000000000000000001</split:account>
+        <split:slots>
+          <slot>
+            <slot:key>sched-xaction</slot:key>
+            <slot:value type="frame">
+              <slot>
+                <slot:key>account</slot:key>
+                <slot:value type="guid">a0000000000000000000000000000004</slot:value>
+              </slot>
+              <slot>
+                <slot:key>credit-formula</slot:key>
+                <slot:value type="string">3000</slot:value>
+              </slot>
+              <slot>
+                <slot:key>credit-numeric</slot:key>
+                <slot:value type="numeric">3000/1</slot:value>
+              </slot>
+            </slot:value>
+          </slot>
+        </split:slots>
+      </trn:split>
+    </trn:splits>
+  </gnc:transaction>
+</gnc:template-transactions>
+<gnc:schedxaction version="2.0.0">
+  <sx:id type="guid">x0000000000000000000000000000001</sx:id>
+  <sx:name>Salary</sx:name>
+  <sx:enabled>y</sx:enabled>
+  <sx:autoCreate>n</sx:autoCreate>
+  <sx:autoCreateNotify>n</sx:autoCreateNotify>
+  <sx:advanceCreateDays>0</sx:advanceCreateDays>
+  <sx:advanceRemindDays>0</sx:advanceRemindDays>
+  <sx:instanceCount>1</sx:instanceCount>
+  <sx:start>
+    <gdate>2024-01-01</gdate>
+  </sx:start>
+  <sx:last>
+    <gdate>2024-01-01</gdate>
+  </sx:last>
+  <sx:templ-acct type="guid">r0000000000000000000000000000001</sx:templ-acct>
+  <sx:schedule>
+    <gnc:recurrence version="1.0.0">
+      <recurrence:mult>1</recurrence:mult>
+      <recurrence:period_type>month</recurrence:period_type>
+      <recurrence:start>
+        <gdate>2024-01-01</gdate>
+      </recurrence:start>
+      <recurrence:weekend_adj>back</recurrence:weekend_adj>
+    </gnc:recurrence>
+  </sx:schedule>
+</gnc:schedxaction>
+<gnc:budget version="2.0.0">
+  <bgt:id type="guid">g0000000000000000000000000000001</bgt:id>
+  <bgt:name>2024</bgt:name>
+  <bgt:description>Yearly budget</bgt:description>
+  <bgt:num-periods>12</bgt:num-periods>
+  <bgt:recurrence version="1.0.0">
+    <recurrence:mult>1</recurrence:mult>
+    <recurrence:period_type>month</recurrence:period_type>
+    <recurrence:start>
+      <gdate>2024-01-01</gdate>
+    </recurrence:start>
+    <recurrence:weekend_adj>none</recurrence:weekend_adj>
+  </bgt:recurrence>
+  <bgt:slots>
+    <slot>
+      <slot:key>a0000000000000000000000000000004</slot:key>
+      <slot:value type="frame">
+        <slot>
+          <slot:key>0</slot:key>
+          <slot:value type="numeric">-300000/100</slot:value>
+        </slot>
+        <slot>
+          <slot:key>1</slot:key>
+          <slot:value type="numeric">-310000/100</slot:value>
+        </slot>
+      </slot:value>
+    </slot>
+  </bgt:slots>
+</gnc:budget>
+<gnc:GncBillTerm version="2.0.0">
+  <billterm:guid type="guid">e0000000000000000000000000000001</billterm:guid>
+  <billterm:name>Net 30</billterm:name>
+  <billterm:desc>30 days</billterm:desc>
+  <billterm:refcount>1</billterm:refcount>
+  <billterm:invisible>0</billterm:invisible>
+  <billterm:days>
+    <bt-days:due-days>30</bt-days:due-days>
+  </billterm:days>
+</gnc:GncBillTerm>
+<gnc:GncCustomer version="2.0.0">
+  <cust:guid type="guid">e0000000000000000000000000000002</cust:guid>
+  <cust:name>Acme</cust:name>
+  <cust:id>000001</cust:id>
+  <cust:addr version="2.0.0">
+    <addr:name>Acme Corp</addr:name>
+    <addr:addr1>Street 1</addr:addr1>
+  </cust:addr>
+  <cust:terms type="guid">e0000000000000000000000000000001</cust:terms>
+  <cust:taxincluded>USEGLOBAL</cust:taxincluded>
+  <cust:active>1</cust:active>
+  <cust:discount>0/1</cust:discount>
+  <cust:credit>0/1</cust:credit>
+  <cust:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </cust:currency>
+  <cust:use-tt>0</cust:use-tt>
+</gnc:GncCustomer>
+<gnc:GncInvoice version="2.0.0">
+  <invoice:guid type="guid">e0000000000000000000000000000003</invoice:guid>
+  <invoice:id>000001</invoice:id>
+  <invoice:owner version="2.0.0">
+    <owner:type>gncCustomer</owner:type>
+    <owner:id type="guid">e0000000000000000000000000000002</owner:id>
+  </invoice:owner>
+  <invoice:opened>
+    <ts:date>2024-01-05 10:59:00 +0000</ts:date>
+  </invoice:opened>
+  <invoice:billing_id>PO-1</invoice:billing_id>
+  <invoice:active>1</invoice:active>
+  <invoice:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </invoice:currency>
+  <invoice:terms type="guid">e0000000000000000000000000000001</invoice:terms>
+</gnc:GncInvoice>
+</gnc:book>
+</gnc-v2>
+`
+
+func createSQLiteBook(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "book.gnucash")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, q := range strings.Split(sqliteSchema+sqliteBook, ";\n") {
+		if strings.TrimSpace(q) == "" {
+			continue
+		}
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("%s: %s", q, err)
+		}
+	}
+
+	return path
+}
+
+func TestSQLite(t *testing.T) {
+	path := createSQLiteBook(t)
+	x, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Backend != BackendSQLite {
+		t.Fatalf("expected the sqlite backend got %d", x.Backend)
+	}
+
+	exp, err := Read(strings.NewReader(sqliteXMLBook))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the books are compared in their encoded form, dates and the order of
+	// unmodeled nodes differ in memory.
+	var got, want bytes.Buffer
+	if err := Write(&got, x); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&want, exp); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		gl, wl := strings.Split(got.String(), "\n"), strings.Split(want.String(), "\n")
+		for i := 0; i < len(gl) && i < len(wl); i++ {
+			if gl[i] != wl[i] {
+				t.Fatalf("line %d: expected\n%s\ngot\n%s", i+1, wl[i], gl[i])
+			}
+		}
+		t.Fatalf("expected %d lines got %d", len(wl), len(gl))
+	}
+
+	b := x.Books[0]
+	broker, _ := b.AccountsLookup.ByGUID("a0000000000000000000000000000003")
+	if len(broker.Lots) != 1 || len(broker.Lots[0].Splits) != 1 {
+		t.Error("lot not linked to its split")
+	}
+	if len(b.Scheduled) != 1 || len(b.Scheduled[0].Templates) != 1 {
+		t.Error("schedule not linked to its template")
+	}
+	if v := b.Budgets[0].Amounts["a0000000000000000000000000000004"][1]; !v.Equal(NewValue(-3100, 1)) {
+		t.Errorf("expected budget amount -3100 got %s", v)
+	}
+
+	if err := WriteFile(path, x); !errors.Is(err, ErrReadOnlyBackend) {
+		t.Errorf("expected %v got %v", ErrReadOnlyBackend, err)
+	}
+}
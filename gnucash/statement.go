@@ -0,0 +1,210 @@
+package gnucash
+
+import (
+	"sort"
+	"strings"
+)
+
+// StatementLine is a line of a financial statement, Values holds the
+// amount of the account including its children per column.
+type StatementLine struct {
+	// Account is nil for calculated lines (e.g.: retained earnings).
+	Account     *Account
+	Name        string
+	Depth       int
+	Placeholder bool
+	Values      []Value
+}
+
+type StatementSection struct {
+	Name  string
+	Lines []StatementLine
+	Total []Value
+}
+
+// Statement is a financial statement: sections of hierarchical account
+// lines with a value per column.
+type Statement struct {
+	Columns  []string
+	Sections []StatementSection
+}
+
+// Section returns the section with the given name.
+func (s *Statement) Section(name string) (StatementSection, bool) {
+	for _, sec := range s.Sections {
+		if sec.Name == name {
+			return sec, true
+		}
+	}
+
+	return StatementSection{}, false
+}
+
+// add appends a calculated line at the top level of the section and adds it
+// to the total.
+func (sec *StatementSection) add(name string, values []Value) {
+	for i := range values {
+		sec.Total[i] = sec.Total[i].Add(values[i])
+	}
+	sec.Lines = append(sec.Lines, StatementLine{Name: name, Depth: 1, Values: values})
+}
+
+func addValues(a, b []Value) {
+	for i := range a {
+		a[i] = a[i].Add(b[i])
+	}
+}
+
+func zeroValues(vs []Value) bool {
+	for _, v := range vs {
+		if !v.IsZero() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// statementSection builds a section of all accounts below root that match,
+// nested following Account.Children and sorted by name. value returns the
+// account's own amount for a column, i.e.: excluding its children. Lines
+// deeper than depth (if > 0) are folded into their parent and lines that
+// are zero in all columns are left out.
+func statementSection(
+	name string,
+	root *Account,
+	match func(*Account) bool,
+	columns int,
+	depth int,
+	value func(a *Account, col int) (Value, error),
+) (StatementSection, error) {
+	sec := StatementSection{Name: name, Total: make([]Value, columns)}
+
+	var walk func(a *Account, d int) ([]Value, []StatementLine, error)
+	walk = func(a *Account, d int) ([]Value, []StatementLine, error) {
+		total := make([]Value, columns)
+		self := match(a)
+		if self {
+			for i := range total {
+				v, err := value(a, i)
+				if err != nil {
+					return nil, nil, err
+				}
+				total[i] = v
+			}
+		}
+
+		cd := d
+		if self {
+			cd++
+		}
+
+		children := make(Accounts, len(a.Children))
+		copy(children, a.Children)
+		sort.SliceStable(children, func(i, j int) bool {
+			return strings.ToLower(children[i].Name) < strings.ToLower(children[j].Name)
+		})
+
+		lines := make([]StatementLine, 0)
+		for _, c := range children {
+			ct, cl, err := walk(c, cd)
+			if err != nil {
+				return nil, nil, err
+			}
+			addValues(total, ct)
+			lines = append(lines, cl...)
+		}
+
+		if !self {
+			return total, lines, nil
+		}
+		if depth > 0 && d > depth {
+			return total, nil, nil
+		}
+		if zeroValues(total) && len(lines) == 0 {
+			return total, nil, nil
+		}
+
+		line := StatementLine{
+			Account:     a,
+			Name:        a.Name,
+			Depth:       d,
+			Placeholder: a.Placeholder(),
+			Values:      total,
+		}
+
+		return total, append([]StatementLine{line}, lines...), nil
+	}
+
+	if root == nil {
+		return sec, nil
+	}
+
+	total, lines, err := walk(root, 1)
+	if err != nil {
+		return sec, err
+	}
+	sec.Total, sec.Lines = total, lines
+
+	return sec, nil
+}
+
+// defaultCurrency returns the currency of the root account or, if it has
+// none, of the first top level account.
+func (b *Book) defaultCurrency() CommodityFQN {
+	if b.RootAccount == nil {
+		return ""
+	}
+	if b.RootAccount.Commodity.ID != "" {
+		return b.RootAccount.Commodity.FQN()
+	}
+	for _, a := range b.RootAccount.Children {
+		if a.Commodity.IsCurrency() {
+			return a.Commodity.FQN()
+		}
+	}
+
+	return ""
+}
+
+// Changes returns a copy of the statement with, after every column but the
+// first, a column holding the change compared to the previous column.
+func (s *Statement) Changes() *Statement {
+	if len(s.Columns) < 2 {
+		return s
+	}
+
+	changes := func(vs []Value) []Value {
+		l := make([]Value, 0, len(vs)*2-1)
+		for i, v := range vs {
+			if i != 0 {
+				l = append(l, v, v.Sub(vs[i-1]))
+				continue
+			}
+			l = append(l, v)
+		}
+		return l
+	}
+
+	st := &Statement{Columns: make([]string, 0, len(s.Columns)*2-1)}
+	for i, c := range s.Columns {
+		st.Columns = append(st.Columns, c)
+		if i != 0 {
+			st.Columns = append(st.Columns, "change")
+		}
+	}
+	for _, sec := range s.Sections {
+		n := StatementSection{
+			Name:  sec.Name,
+			Lines: make([]StatementLine, len(sec.Lines)),
+			Total: changes(sec.Total),
+		}
+		for i, l := range sec.Lines {
+			l.Values = changes(l.Values)
+			n.Lines[i] = l
+		}
+		st.Sections = append(st.Sections, n)
+	}
+
+	return st
+}
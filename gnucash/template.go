@@ -0,0 +1,94 @@
+package gnucash
+
+import "fmt"
+
+// Templates holds the accounts and transactions scheduled transactions are
+// created from (gnc:template-transactions). Each schedule has its own
+// template account, the splits of its template transactions reference it
+// and store the real account and amounts in a sched-xaction slot frame.
+type Templates struct {
+	Accounts       Accounts        `xml:"account"`
+	AccountsLookup *AccountsLookup `xml:"-"`
+	Transactions   Transactions    `xml:"transaction"`
+	Extra          Nodes           `xml:",any"`
+}
+
+func (t *Templates) Empty() bool {
+	return len(t.Accounts) == 0 && len(t.Transactions) == 0 && len(t.Extra) == 0
+}
+
+func (t *Templates) validate() error {
+	t.AccountsLookup = t.Accounts.lookup()
+	txLookup := t.Transactions.lookup()
+
+	if err := t.Accounts.validate(t.AccountsLookup, txLookup); err != nil {
+		return err
+	}
+
+	return t.Transactions.validate(t.AccountsLookup)
+}
+
+// SplitTemplate is the sched-xaction slot frame of a template split.
+type SplitTemplate struct {
+	AccountID     GUID
+	Credit        string
+	Debit         string
+	CreditNumeric Value
+	DebitNumeric  Value
+}
+
+// Value evaluates the debit and credit formulas, falling back to the
+// numeric values GnuCash stored for formulas that could not be evaluated.
+// Debits are positive, credits negative.
+func (st SplitTemplate) Value() (Value, error) {
+	eval := func(formula string, numeric Value) (Value, error) {
+		v, err := EvalFormula(formula)
+		if err != nil && !numeric.IsZero() {
+			return numeric, nil
+		}
+		return v, err
+	}
+
+	debit, err := eval(st.Debit, st.DebitNumeric)
+	if err != nil {
+		return Value{}, err
+	}
+
+	credit, err := eval(st.Credit, st.CreditNumeric)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return debit.Sub(credit), nil
+}
+
+// Template returns the sched-xaction data of a template split.
+func (s *Split) Template() (SplitTemplate, bool) {
+	frame, ok := s.Slots.KeyValue()["sched-xaction"]
+	if !ok {
+		return SplitTemplate{}, false
+	}
+
+	st := SplitTemplate{}
+	for _, slot := range frame.RawValue.Slots {
+		v := slot.RawValue.Value
+		switch slot.Key {
+		case "account":
+			st.AccountID = GUID(v)
+		case "credit-formula":
+			st.Credit = v
+		case "debit-formula":
+			st.Debit = v
+		case "credit-numeric":
+			st.CreditNumeric, _ = ParseValue(v)
+		case "debit-numeric":
+			st.DebitNumeric, _ = ParseValue(v)
+		}
+	}
+
+	return st, st.AccountID != ""
+}
+
+func (st SplitTemplate) String() string {
+	return fmt.Sprintf("%s debit: %s credit: %s", st.AccountID, st.Debit, st.Credit)
+}
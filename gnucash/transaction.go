@@ -1,18 +1,53 @@
 package gnucash
 
 import (
+	nxml "encoding/xml"
 	"errors"
 	"fmt"
 	"time"
 )
 
 type Transaction struct {
-	ID          GUID   `xml:"id"`
-	Num         string `xml:"num"`
-	DatePosted  Date   `xml:"date-posted>date"`
-	DateEntered Date   `xml:"date-entered>date"`
-	Description string `xml:"description"`
-	Splits      Splits `xml:"splits>split"`
+	ID          GUID         `xml:"id"`
+	Currency    CommodityRef `xml:"currency"`
+	Num         string       `xml:"num"`
+	DatePosted  Date         `xml:"date-posted>date"`
+	DateEntered Date         `xml:"date-entered>date"`
+	Description string       `xml:"description"`
+	Slots       Slots        `xml:"slots>slot"`
+	Splits      Splits       `xml:"splits>split"`
+	Extra       Nodes        `xml:",any"`
+
+	Invoice *Invoice `xml:"-"`
+}
+
+// NewTransaction creates an empty transaction posted on the given day,
+// add splits with AddSplit and append it to a Book with AddTransaction.
+func NewTransaction(currency CommodityRef, posted time.Time, num, description string) *Transaction {
+	y, m, d := posted.Date()
+	return &Transaction{
+		ID:          NewGUID(),
+		Currency:    currency,
+		Num:         num,
+		DatePosted:  NewDate(time.Date(y, m, d, 10, 59, 0, 0, time.UTC)),
+		DateEntered: NewDate(time.Now()),
+		Description: description,
+		Slots: Slots{
+			{
+				Key: "date-posted",
+				RawValue: SlotValue{
+					Type: "gdate",
+					Extra: Nodes{
+						{
+							Name: nxml.Name{Local: "gdate"},
+							Text: posted.Format("2006-01-02"),
+						},
+					},
+				},
+			},
+		},
+		Splits: make(Splits, 0, 2),
+	}
 }
 
 func (t *Transaction) String() string {
@@ -25,14 +60,67 @@ func (t *Transaction) String() string {
 	)
 }
 
-func (t *Transaction) validate(lookup *AccountsLookup, prices Prices) error {
+func (t *Transaction) validate(lookup *AccountsLookup) error {
 	if t.ID == "" {
 		return errors.New("Empty transaction id")
 	}
 
-	if err := t.Splits.validate(lookup, prices); err != nil {
+	if err := t.Splits.validate(lookup, t); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// AddSplit appends a split moving value into account. The value is rounded
+// to the account's smallest commodity unit and the account must be
+// denominated in the transaction's currency.
+func (t *Transaction) AddSplit(account *Account, value Value, memo string) (*Split, error) {
+	if account.Commodity != t.Currency {
+		return nil, fmt.Errorf(
+			"account '%s' is denominated in %s not %s",
+			account.FQN,
+			account.Commodity.FQN(),
+			t.Currency.FQN(),
+		)
+	}
+
+	scu := int64(account.SCU)
+	if scu == 0 {
+		scu = 100
+	}
+
+	value = value.Convert(scu, RoundBankers)
+	s := &Split{
+		ID:              NewGUID(),
+		ReconciledState: ReconciledStateNew,
+		Value:           value,
+		Quantity:        value,
+		AccountID:       account.ID,
+		Memo:            memo,
+		Account:         account,
+		Transaction:     t,
+	}
+	t.Splits = append(t.Splits, s)
+
+	return s, nil
+}
+
+// accountIDs returns the distinct accounts of the splits in order.
+func (t *Transaction) accountIDs() []GUID {
+	ids := make([]GUID, 0, len(t.Splits))
+	seen := make(map[GUID]struct{}, len(t.Splits))
+	for _, s := range t.Splits {
+		if _, ok := seen[s.AccountID]; ok {
+			continue
+		}
+		seen[s.AccountID] = struct{}{}
+		ids = append(ids, s.AccountID)
+	}
+
+	return ids
+}
+
+func (t *Transaction) Imbalance() Value {
+	return t.Splits.Sum()
+}
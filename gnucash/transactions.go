@@ -39,12 +39,33 @@ func (ts Transactions) ValueForAccount(
 ) Value {
 	var v Value
 	for _, t := range ts {
-		v += t.Splits.ValueForAccount(accountID, includeChildren)
+		v = v.Add(t.Splits.ValueForAccount(accountID, includeChildren))
 	}
 
 	return v
 }
 
+// ValuationForAccount is like ValueForAccount but values the quantities held
+// in each commodity at the given time, see Splits.ValuationForAccount.
+func (ts Transactions) ValuationForAccount(
+	accountID GUID,
+	includeChildren bool,
+	conv *Converter,
+	currency CommodityFQN,
+	at time.Time,
+) (Value, error) {
+	return ts.splits().ValuationForAccount(accountID, includeChildren, conv, currency, at)
+}
+
+func (ts Transactions) splits() Splits {
+	ss := make(Splits, 0, len(ts)*2)
+	for _, t := range ts {
+		ss = append(ss, t.Splits...)
+	}
+
+	return ss
+}
+
 func (ts Transactions) Filter(
 	accountFQN,
 	accountExcludeFQN *regexp.Regexp,
@@ -75,7 +96,21 @@ func (ts Transactions) FilterSplits(
 }
 
 func (ts Transactions) Simplified() FlatTransactions {
-	return ts.mapTransactions().flatten().flattxs()
+	m, _ := ts.mapTransactions(func(s *Split) (Value, error) { return s.Value, nil })
+	return m.flatten().flattxs()
+}
+
+// SimplifiedIn is like Simplified but with all values converted to currency
+// at each transaction's date posted.
+func (ts Transactions) SimplifiedIn(conv *Converter, currency CommodityFQN) (FlatTransactions, error) {
+	m, err := ts.mapTransactions(func(s *Split) (Value, error) {
+		return conv.SplitValue(s, currency, time.Time{})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m.flatten().flattxs(), nil
 }
 
 func (ts Transactions) SortDatePosted() Transactions {
@@ -89,20 +124,22 @@ func (ts Transactions) SortDatePosted() Transactions {
 	return ts
 }
 
+// lookup maps account ids to the transactions with splits in them, a
+// transaction with several splits in an account is listed once.
 func (ts Transactions) lookup() TransactionsLookup {
 	lookup := make(TransactionsLookup)
 	for _, t := range ts {
-		for _, s := range t.Splits {
-			lookup[s.AccountID] = append(lookup[s.AccountID], t)
+		for _, id := range t.accountIDs() {
+			lookup[id] = append(lookup[id], t)
 		}
 	}
 
 	return lookup
 }
 
-func (ts Transactions) validate(lookup *AccountsLookup, prices Prices) error {
+func (ts Transactions) validate(lookup *AccountsLookup) error {
 	for _, t := range ts {
-		if err := t.validate(lookup, prices); err != nil {
+		if err := t.validate(lookup); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,318 @@
+package gnucash
+
+import (
+	nxml "encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Rounding mirrors GnuCash's GNC_HOW_RND_* modes and determines how a Value
+// is rounded when it is converted to a different denominator.
+type Rounding int
+
+const (
+	RoundNever Rounding = iota
+	RoundFloor
+	RoundCeil
+	RoundTrunc
+	RoundPromote
+	RoundHalfDown
+	RoundHalfUp
+	RoundBankers
+)
+
+var ErrValueOverflow = errors.New("value overflow")
+var ErrValueRemainder = errors.New("value can't be converted without rounding")
+
+// Value is an exact rational amount stored the same way GnuCash stores it:
+// an int64 numerator over an int64 denominator. The original denominator is
+// preserved across additions and subtractions whenever possible.
+type Value struct {
+	num   int64
+	denom int64
+}
+
+func NewValue(num, denom int64) Value {
+	if denom < 0 {
+		num, denom = -num, -denom
+	}
+	return Value{num, denom}
+}
+
+func ParseValue(str string) (Value, error) {
+	p := strings.SplitN(str, "/", 2)
+	if len(p) != 2 {
+		return Value{}, errors.New("Unexpected Value: " + str)
+	}
+
+	num, err := strconv.ParseInt(p[0], 10, 64)
+	if err != nil {
+		return Value{}, err
+	}
+
+	denom, err := strconv.ParseInt(p[1], 10, 64)
+	if err != nil {
+		return Value{}, err
+	}
+
+	if denom <= 0 {
+		return Value{}, nil
+	}
+
+	return NewValue(num, denom), nil
+}
+
+func (v Value) Num() int64 { return v.num }
+
+func (v Value) Denom() int64 {
+	if v.denom == 0 {
+		return 1
+	}
+	return v.denom
+}
+
+func (v Value) IsZero() bool { return v.num == 0 }
+
+func (v Value) Sign() int {
+	switch {
+	case v.num < 0:
+		return -1
+	case v.num > 0:
+		return 1
+	}
+	return 0
+}
+
+func (v Value) Neg() Value { return Value{-v.num, v.Denom()} }
+
+func (v Value) Abs() Value {
+	if v.num < 0 {
+		return v.Neg()
+	}
+	return v
+}
+
+func (v Value) Add(o Value) Value {
+	if v.Denom() == o.Denom() {
+		s := v.num + o.num
+		if (s > v.num) == (o.num > 0) {
+			return Value{s, v.Denom()}
+		}
+	}
+
+	d := lcm(v.bigDenom(), o.bigDenom())
+	a := new(big.Int).Mul(v.bigNum(), new(big.Int).Quo(d, v.bigDenom()))
+	b := new(big.Int).Mul(o.bigNum(), new(big.Int).Quo(d, o.bigDenom()))
+
+	return fromBig(a.Add(a, b), d)
+}
+
+func (v Value) Sub(o Value) Value { return v.Add(o.Neg()) }
+
+func (v Value) Mul(o Value) Value {
+	return fromBig(
+		new(big.Int).Mul(v.bigNum(), o.bigNum()),
+		new(big.Int).Mul(v.bigDenom(), o.bigDenom()),
+	)
+}
+
+func (v Value) Div(o Value) Value {
+	if o.num == 0 {
+		panic("gnucash: value division by zero")
+	}
+
+	return fromBig(
+		new(big.Int).Mul(v.bigNum(), o.bigDenom()),
+		new(big.Int).Mul(v.bigDenom(), o.bigNum()),
+	)
+}
+
+// Convert returns v expressed with the given denominator (e.g. a commodity's
+// Fraction), rounded using r. RoundNever panics if rounding would be required.
+func (v Value) Convert(denom int64, r Rounding) Value {
+	if denom <= 0 {
+		denom = 1
+	}
+	if denom == v.Denom() {
+		return Value{v.num, denom}
+	}
+
+	c, err := round(v.bigNum(), v.bigDenom(), denom, r)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// round returns num/den expressed with the given denominator, rounded using
+// r.
+func round(num, den *big.Int, denom int64, r Rounding) (Value, error) {
+	if denom <= 0 {
+		denom = 1
+	}
+
+	n := new(big.Int).Mul(num, big.NewInt(denom))
+	q, rem := new(big.Int).QuoRem(n, den, new(big.Int))
+	if rem.Sign() != 0 {
+		if r == RoundNever {
+			return Value{}, ErrValueRemainder
+		}
+
+		neg := n.Sign() < 0
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		half := twice.Cmp(den)
+
+		away := false
+		switch r {
+		case RoundFloor:
+			away = neg
+		case RoundCeil:
+			away = !neg
+		case RoundTrunc:
+		case RoundPromote:
+			away = true
+		case RoundHalfDown:
+			away = half > 0
+		case RoundHalfUp:
+			away = half >= 0
+		case RoundBankers:
+			away = half > 0 || (half == 0 && q.Bit(0) == 1)
+		}
+
+		if away && neg {
+			q.Sub(q, big.NewInt(1))
+		} else if away {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	if !q.IsInt64() {
+		return Value{}, ErrValueOverflow
+	}
+
+	return Value{q.Int64(), denom}, nil
+}
+
+// Reduce returns v with numerator and denominator divided by their gcd.
+func (v Value) Reduce() Value {
+	g := new(big.Int).GCD(nil, nil, new(big.Int).Abs(v.bigNum()), v.bigDenom())
+	if g.Sign() == 0 {
+		return Value{0, 1}
+	}
+	d := g.Int64()
+	return Value{v.num / d, v.Denom() / d}
+}
+
+func (v Value) Cmp(o Value) int {
+	if v.Denom() == o.Denom() {
+		switch {
+		case v.num < o.num:
+			return -1
+		case v.num > o.num:
+			return 1
+		}
+		return 0
+	}
+
+	a := new(big.Int).Mul(v.bigNum(), o.bigDenom())
+	b := new(big.Int).Mul(o.bigNum(), v.bigDenom())
+	return a.Cmp(b)
+}
+
+func (v Value) Equal(o Value) bool { return v.Cmp(o) == 0 }
+
+// Float64 returns an approximation of v, only to be used for display.
+func (v Value) Float64() float64 {
+	return float64(v.num) / float64(v.Denom())
+}
+
+// String returns v in GnuCash's num/denom notation.
+func (v Value) String() string {
+	return fmt.Sprintf("%d/%d", v.num, v.Denom())
+}
+
+func (v *Value) UnmarshalXML(d *nxml.Decoder, start nxml.StartElement) error {
+	var content string
+	if err := d.DecodeElement(&content, &start); err != nil {
+		return err
+	}
+
+	val, err := ParseValue(content)
+	if err != nil {
+		return err
+	}
+
+	*v = val
+	return nil
+}
+
+func (v Value) bigNum() *big.Int   { return big.NewInt(v.num) }
+func (v Value) bigDenom() *big.Int { return big.NewInt(v.Denom()) }
+func (v Value) rat() *big.Rat      { return new(big.Rat).SetFrac(v.bigNum(), v.bigDenom()) }
+
+func fromBig(num, denom *big.Int) Value {
+	if denom.Sign() < 0 {
+		num.Neg(num)
+		denom.Neg(denom)
+	}
+
+	if !num.IsInt64() || !denom.IsInt64() {
+		g := new(big.Int).GCD(nil, nil, new(big.Int).Abs(num), denom)
+		if g.Sign() != 0 {
+			num.Quo(num, g)
+			denom.Quo(denom, g)
+		}
+	}
+
+	if !num.IsInt64() || !denom.IsInt64() {
+		panic(ErrValueOverflow)
+	}
+
+	return Value{num.Int64(), denom.Int64()}
+}
+
+func lcm(a, b *big.Int) *big.Int {
+	g := new(big.Int).GCD(nil, nil, a, b)
+	l := new(big.Int).Mul(a, b)
+	return l.Quo(l, g)
+}
+
+// ParseDecimal parses a plain decimal number (e.g. "-12.34") exactly, the
+// denominator being 10^(number of decimals).
+func ParseDecimal(str string) (Value, error) {
+	str = strings.TrimSpace(str)
+	neg := strings.HasPrefix(str, "-")
+	digits := strings.TrimLeft(str, "+-")
+	if len(str)-len(digits) > 1 {
+		return Value{}, fmt.Errorf("invalid decimal '%s'", str)
+	}
+
+	p := strings.SplitN(digits, ".", 2)
+	denom := int64(1)
+	if len(p) == 2 {
+		for range p[1] {
+			denom *= 10
+		}
+		digits = p[0] + p[1]
+	}
+
+	if digits == "" || strings.Trim(digits, "0123456789") != "" || len(digits) > 18 {
+		return Value{}, fmt.Errorf("invalid decimal '%s'", str)
+	}
+
+	num, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Value{}, err
+	}
+
+	if neg {
+		num = -num
+	}
+
+	return Value{num, denom}, nil
+}
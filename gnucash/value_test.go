@@ -0,0 +1,96 @@
+package gnucash
+
+import (
+	"testing"
+)
+
+func TestValueSum(t *testing.T) {
+	var v Value
+	for i := 0; i < 10000; i++ {
+		v = v.Add(NewValue(10, 100))
+	}
+	v = v.Sub(NewValue(1000, 1))
+	if !v.IsZero() {
+		t.Errorf("expected zero sum, got %s", v)
+	}
+	if v.Denom() != 100 {
+		t.Errorf("expected denominator to be preserved, got %s", v)
+	}
+
+	v = NewValue(1, 3).Add(NewValue(1, 6))
+	if !v.Equal(NewValue(1, 2)) {
+		t.Errorf("1/3 + 1/6 != %s", v)
+	}
+}
+
+func TestValueConvert(t *testing.T) {
+	tests := []struct {
+		v   Value
+		r   Rounding
+		exp int64
+	}{
+		{NewValue(125, 1000), RoundBankers, 12},
+		{NewValue(135, 1000), RoundBankers, 14},
+		{NewValue(-125, 1000), RoundBankers, -12},
+		{NewValue(125, 1000), RoundHalfUp, 13},
+		{NewValue(-125, 1000), RoundHalfUp, -13},
+		{NewValue(125, 1000), RoundHalfDown, 12},
+		{NewValue(121, 1000), RoundCeil, 13},
+		{NewValue(-121, 1000), RoundCeil, -12},
+		{NewValue(-121, 1000), RoundFloor, -13},
+		{NewValue(-129, 1000), RoundTrunc, -12},
+		{NewValue(121, 1000), RoundPromote, 13},
+	}
+
+	for _, test := range tests {
+		c := test.v.Convert(100, test.r)
+		if c.Num() != test.exp || c.Denom() != 100 {
+			t.Errorf("%s rounded with %d: expected %d/100 got %s", test.v, test.r, test.exp, c)
+		}
+	}
+
+	price := NewValue(3333, 100)
+	qty := NewValue(3, 1)
+	if v := qty.Mul(price).Convert(100, RoundBankers); v.Num() != 9999 {
+		t.Errorf("3 * 33.33 != %s", v)
+	}
+}
+
+func TestValueParse(t *testing.T) {
+	v, err := ParseValue("-12345/100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != "-12345/100" {
+		t.Errorf("round trip failed: %s", v)
+	}
+	if _, err := ParseValue("12.34"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]Value{
+		"12.34":  NewValue(1234, 100),
+		"-0.5":   NewValue(-5, 10),
+		"+7":     NewValue(7, 1),
+		".25":    NewValue(25, 100),
+		"100.00": NewValue(10000, 100),
+	}
+	for str, exp := range tests {
+		v, err := ParseDecimal(str)
+		if err != nil {
+			t.Errorf("%s: %s", str, err)
+			continue
+		}
+		if v.Num() != exp.Num() || v.Denom() != exp.Denom() {
+			t.Errorf("%s: expected %s got %s", str, exp, v)
+		}
+	}
+
+	for _, str := range []string{"", "-", "1,5", "1.2.3", "--1", "1e3"} {
+		if _, err := ParseDecimal(str); err == nil {
+			t.Errorf("%s: expected error", str)
+		}
+	}
+}
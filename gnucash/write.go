@@ -0,0 +1,623 @@
+package gnucash
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	nxml "encoding/xml"
+)
+
+var namespaces = [][2]string{
+	{"gnc", "http://www.gnucash.org/XML/gnc"},
+	{"act", "http://www.gnucash.org/XML/act"},
+	{"book", "http://www.gnucash.org/XML/book"},
+	{"cd", "http://www.gnucash.org/XML/cd"},
+	{"cmdty", "http://www.gnucash.org/XML/cmdty"},
+	{"price", "http://www.gnucash.org/XML/price"},
+	{"slot", "http://www.gnucash.org/XML/slot"},
+	{"split", "http://www.gnucash.org/XML/split"},
+	{"sx", "http://www.gnucash.org/XML/sx"},
+	{"trn", "http://www.gnucash.org/XML/trn"},
+	{"ts", "http://www.gnucash.org/XML/ts"},
+	{"fs", "http://www.gnucash.org/XML/fs"},
+	{"bgt", "http://www.gnucash.org/XML/bgt"},
+	{"recurrence", "http://www.gnucash.org/XML/recurrence"},
+	{"lot", "http://www.gnucash.org/XML/lot"},
+	{"addr", "http://www.gnucash.org/XML/addr"},
+	{"billterm", "http://www.gnucash.org/XML/billterm"},
+	{"bt-days", "http://www.gnucash.org/XML/bt-days"},
+	{"bt-prox", "http://www.gnucash.org/XML/bt-prox"},
+	{"cust", "http://www.gnucash.org/XML/cust"},
+	{"employee", "http://www.gnucash.org/XML/employee"},
+	{"entry", "http://www.gnucash.org/XML/entry"},
+	{"invoice", "http://www.gnucash.org/XML/invoice"},
+	{"job", "http://www.gnucash.org/XML/job"},
+	{"order", "http://www.gnucash.org/XML/order"},
+	{"owner", "http://www.gnucash.org/XML/owner"},
+	{"taxtable", "http://www.gnucash.org/XML/taxtable"},
+	{"tte", "http://www.gnucash.org/XML/tte"},
+	{"vendor", "http://www.gnucash.org/XML/vendor"},
+}
+
+const dateFormat = "2006-01-02 15:04:05 -0700"
+
+type attr struct {
+	k, v string
+}
+
+type encoder struct {
+	w     *bufio.Writer
+	depth int
+	err   error
+}
+
+func (e *encoder) indent() {
+	if e.depth > 2 {
+		e.w.WriteString(strings.Repeat("  ", e.depth-2))
+	}
+}
+
+func (e *encoder) tag(name string, attrs []attr) {
+	e.w.WriteByte('<')
+	e.w.WriteString(name)
+	for _, a := range attrs {
+		fmt.Fprintf(e.w, " %s=\"", a.k)
+		e.escape(a.v)
+		e.w.WriteByte('"')
+	}
+}
+
+func (e *encoder) escape(s string) {
+	if err := nxml.EscapeText(e.w, []byte(s)); err != nil && e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *encoder) open(name string, attrs ...attr) {
+	e.indent()
+	e.tag(name, attrs)
+	e.w.WriteString(">\n")
+	e.depth++
+}
+
+func (e *encoder) close(name string) {
+	e.depth--
+	e.indent()
+	fmt.Fprintf(e.w, "</%s>\n", name)
+}
+
+func (e *encoder) text(name, value string, attrs ...attr) {
+	e.indent()
+	e.tag(name, attrs)
+	if value == "" {
+		e.w.WriteString("/>\n")
+		return
+	}
+	e.w.WriteByte('>')
+	e.escape(value)
+	fmt.Fprintf(e.w, "</%s>\n", name)
+}
+
+func (e *encoder) optional(name, value string) {
+	if value != "" {
+		e.text(name, value)
+	}
+}
+
+func (e *encoder) guid(name string, id GUID) {
+	e.text(name, string(id), attr{"type", "guid"})
+}
+
+func (e *encoder) date(name string, d Date) {
+	e.open(name)
+	e.text("ts:date", d.Get().Format(dateFormat))
+	e.close(name)
+}
+
+func (e *encoder) gdate(name string, d Date) {
+	e.open(name)
+	e.text("gdate", d.Get().Format("2006-01-02"))
+	e.close(name)
+}
+
+func (e *encoder) flag(name string, v Enabled) {
+	if v {
+		e.text(name, "y")
+		return
+	}
+	e.text(name, "n")
+}
+
+func (e *encoder) bit(name string, v Flag) {
+	if v {
+		e.text(name, "1")
+		return
+	}
+	e.text(name, "0")
+}
+
+func (e *encoder) optionalGUID(name string, id GUID) {
+	if id != "" {
+		e.guid(name, id)
+	}
+}
+
+func (e *encoder) commodity(name string, c CommodityRef) {
+	e.open(name)
+	e.text("cmdty:space", string(c.NS))
+	e.text("cmdty:id", string(c.ID))
+	e.close(name)
+}
+
+func (e *encoder) slots(name string, slots Slots) {
+	if len(slots) == 0 {
+		return
+	}
+
+	e.open(name)
+	for _, s := range slots {
+		e.slot(s)
+	}
+	e.close(name)
+}
+
+func (e *encoder) slot(s Slot) {
+	e.open("slot")
+	e.text("slot:key", s.Key)
+	v := s.RawValue
+	a := attr{"type", v.Type}
+	switch {
+	case v.Type == "frame" || len(v.Slots) != 0:
+		e.open("slot:value", a)
+		for _, c := range v.Slots {
+			e.slot(c)
+		}
+		e.nodes(v.Extra)
+		e.close("slot:value")
+	case len(v.Extra) != 0:
+		e.open("slot:value", a)
+		e.nodes(v.Extra)
+		e.close("slot:value")
+	default:
+		e.text("slot:value", v.Value, a)
+	}
+	e.close("slot")
+}
+
+func (e *encoder) nodes(ns Nodes) {
+	for _, n := range ns {
+		e.node(n)
+	}
+}
+
+func (e *encoder) node(n Node) {
+	name := qualify(n.Name)
+	attrs := make([]attr, 0, len(n.Attrs))
+	for _, a := range n.Attrs {
+		attrs = append(attrs, attr{qualify(a.Name), a.Value})
+	}
+
+	if len(n.Children) == 0 {
+		e.text(name, n.Text, attrs...)
+		return
+	}
+
+	e.open(name, attrs...)
+	e.nodes(n.Children)
+	e.close(name)
+}
+
+func (e *encoder) count(typ string, n int) {
+	if n != 0 {
+		e.text("gnc:count-data", strconv.Itoa(n), attr{"cd:type", typ})
+	}
+}
+
+func qualify(n nxml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	for _, ns := range namespaces {
+		if ns[1] == n.Space {
+			return ns[0] + ":" + n.Local
+		}
+	}
+
+	return n.Space + ":" + n.Local
+}
+
+func countType(n Node) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == "type" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (x *XML) encode(e *encoder) {
+	e.w.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\" ?>\n")
+	e.w.WriteString("<gnc-v2")
+	for _, ns := range namespaces {
+		fmt.Fprintf(e.w, "\n     xmlns:%s=\"%s\"", ns[0], ns[1])
+	}
+	e.w.WriteString(">\n")
+	e.depth++
+
+	e.count("book", len(x.Books))
+	e.nodes(x.Extra.Exclude("count-data"))
+	for _, b := range x.Books {
+		b.encode(e)
+	}
+
+	e.close("gnc-v2")
+}
+
+func (b *Book) encode(e *encoder) {
+	version := b.Version
+	if version == "" {
+		version = "2.0.0"
+	}
+	e.open("gnc:book", attr{"version", version})
+	e.guid("book:id", b.ID)
+	e.slots("book:slots", b.Slots)
+
+	known := map[string]int{
+		"commodity":    len(b.Commodities),
+		"account":      len(b.Accounts),
+		"transaction":  len(b.Transactions),
+		"schedxaction": len(b.Scheduled),
+		"budget":       len(b.Budgets),
+
+		"gnc:GncBillTerm": len(b.BillTerms),
+		"gnc:GncCustomer": len(b.Customers),
+		"gnc:GncEntry":    len(b.Entries),
+		"gnc:GncInvoice":  len(b.Invoices),
+		"gnc:GncJob":      len(b.Jobs),
+		"gnc:GncTaxTable": len(b.TaxTables),
+		"gnc:GncVendor":   len(b.Vendors),
+		"price":           len(b.Prices),
+	}
+	for _, t := range []string{
+		"commodity",
+		"account",
+		"transaction",
+		"schedxaction",
+		"budget",
+	} {
+		e.count(t, known[t])
+	}
+
+	// business counts are sorted by type like GnuCash does, including the
+	// ones gocash does not model (e.g.: gnc:GncOrder).
+	business := []string{
+		"gnc:GncBillTerm",
+		"gnc:GncCustomer",
+		"gnc:GncEntry",
+		"gnc:GncInvoice",
+		"gnc:GncJob",
+		"gnc:GncTaxTable",
+		"gnc:GncVendor",
+	}
+	unknown := make(map[string]Nodes)
+	for _, n := range b.Extra.Filter("count-data") {
+		t := countType(n)
+		if _, ok := known[t]; ok {
+			continue
+		}
+		if _, ok := unknown[t]; !ok {
+			business = append(business, t)
+		}
+		unknown[t] = append(unknown[t], n)
+	}
+	sort.Strings(business)
+	for _, t := range business {
+		if ns, ok := unknown[t]; ok {
+			e.nodes(ns)
+			continue
+		}
+		e.count(t, known[t])
+	}
+	e.count("price", known["price"])
+
+	for _, c := range b.Commodities {
+		c.encode(e)
+	}
+
+	if len(b.Prices) != 0 {
+		e.open("gnc:pricedb", attr{"version", "1"})
+		for _, p := range b.Prices {
+			p.encode(e)
+		}
+		e.close("gnc:pricedb")
+	}
+
+	for _, a := range b.Accounts {
+		a.encode(e)
+	}
+
+	for _, t := range b.Transactions {
+		t.encode(e)
+	}
+
+	if !b.Templates.Empty() {
+		e.open("gnc:template-transactions")
+		for _, a := range b.Templates.Accounts {
+			a.encode(e)
+		}
+		for _, t := range b.Templates.Transactions {
+			t.encode(e)
+		}
+		e.nodes(b.Templates.Extra)
+		e.close("gnc:template-transactions")
+	}
+
+	for _, s := range b.Scheduled {
+		s.encode(e)
+	}
+
+	for _, bg := range b.Budgets {
+		bg.encode(e)
+	}
+
+	b.encodeBusiness(e)
+
+	e.nodes(b.Extra.Exclude("count-data"))
+
+	e.close("gnc:book")
+}
+
+func (c Commodity) encode(e *encoder) {
+	e.open("gnc:commodity", attr{"version", "2.0.0"})
+	e.text("cmdty:space", string(c.NS))
+	e.text("cmdty:id", string(c.ID))
+	if c.Fraction != 0 {
+		e.text("cmdty:fraction", strconv.Itoa(c.Fraction))
+	}
+	e.nodes(c.Extra)
+	e.slots("cmdty:slots", c.Slots)
+	e.close("gnc:commodity")
+}
+
+func (p Price) encode(e *encoder) {
+	e.open("price")
+	e.guid("price:id", p.ID)
+	e.commodity("price:commodity", p.Comodity)
+	e.commodity("price:currency", p.Currency)
+	e.date("price:time", p.Time)
+	e.optional("price:source", p.Source)
+	e.optional("price:type", p.Type)
+	e.text("price:value", p.Value.String())
+	e.nodes(p.Extra)
+	e.close("price")
+}
+
+func (a *Account) encode(e *encoder) {
+	e.open("gnc:account", attr{"version", "2.0.0"})
+	e.text("act:name", a.Name)
+	e.guid("act:id", a.ID)
+	e.text("act:type", string(a.Type))
+	if a.Commodity.ID != "" {
+		e.commodity("act:commodity", a.Commodity)
+	}
+	if a.SCU != 0 {
+		e.text("act:commodity-scu", strconv.Itoa(a.SCU))
+	}
+	e.optional("act:description", a.Description)
+	e.slots("act:slots", a.Slots)
+	if a.ParentID != "" {
+		e.guid("act:parent", a.ParentID)
+	}
+	if len(a.Lots) != 0 {
+		e.open("act:lots")
+		for _, l := range a.Lots {
+			e.open("gnc:lot", attr{"version", "2.0.0"})
+			e.guid("lot:id", l.ID)
+			e.slots("lot:slots", l.Slots)
+			e.nodes(l.Extra)
+			e.close("gnc:lot")
+		}
+		e.close("act:lots")
+	}
+	e.nodes(a.Extra)
+	e.close("gnc:account")
+}
+
+func (t *Transaction) encode(e *encoder) {
+	e.open("gnc:transaction", attr{"version", "2.0.0"})
+	e.guid("trn:id", t.ID)
+	e.commodity("trn:currency", t.Currency)
+	e.optional("trn:num", t.Num)
+	e.date("trn:date-posted", t.DatePosted)
+	e.date("trn:date-entered", t.DateEntered)
+	e.text("trn:description", t.Description)
+	e.slots("trn:slots", t.Slots)
+	e.open("trn:splits")
+	for _, s := range t.Splits {
+		s.encode(e)
+	}
+	e.close("trn:splits")
+	e.nodes(t.Extra)
+	e.close("gnc:transaction")
+}
+
+func (s *Split) encode(e *encoder) {
+	e.open("trn:split")
+	e.guid("split:id", s.ID)
+	e.optional("split:memo", s.Memo)
+	e.optional("split:action", s.Action)
+	e.text("split:reconciled-state", s.ReconciledState.String())
+	if !s.ReconcileDate.Empty() {
+		e.date("split:reconcile-date", s.ReconcileDate)
+	}
+	e.text("split:value", s.Value.String())
+	e.text("split:quantity", s.Quantity.String())
+	e.guid("split:account", s.AccountID)
+	if s.LotID != "" {
+		e.guid("split:lot", s.LotID)
+	}
+	e.slots("split:slots", s.Slots)
+	e.nodes(s.Extra)
+	e.close("trn:split")
+}
+
+func (s *Scheduled) encode(e *encoder) {
+	e.open("gnc:schedxaction", attr{"version", "2.0.0"})
+	e.guid("sx:id", s.ID)
+	e.text("sx:name", s.Name)
+	e.flag("sx:enabled", s.Enabled)
+	e.flag("sx:autoCreate", s.AutoCreate)
+	e.flag("sx:autoCreateNotify", s.AutoCreateNotify)
+	e.text("sx:advanceCreateDays", strconv.Itoa(s.AdvanceCreateDays))
+	e.text("sx:advanceRemindDays", strconv.Itoa(s.AdvanceRemindDays))
+	e.text("sx:instanceCount", strconv.Itoa(s.InstanceCount))
+	e.gdate("sx:start", s.Start)
+	if !s.Last.Empty() {
+		e.gdate("sx:last", s.Last)
+	}
+	if s.NumOccur != 0 {
+		e.text("sx:num-occur", strconv.Itoa(s.NumOccur))
+		e.text("sx:rem-occur", strconv.Itoa(s.RemOccur))
+	}
+	if !s.End.Empty() {
+		e.gdate("sx:end", s.End)
+	}
+	if s.TemplateAccountID != "" {
+		e.guid("sx:templ-acct", s.TemplateAccountID)
+	}
+	if len(s.Schedule) != 0 {
+		e.open("sx:schedule")
+		for _, r := range s.Schedule {
+			r.encode(e)
+		}
+		e.close("sx:schedule")
+	}
+	e.nodes(s.Extra)
+	e.slots("sx:slots", s.Slots)
+	e.close("gnc:schedxaction")
+}
+
+func (bg *Budget) encode(e *encoder) {
+	e.open("gnc:budget", attr{"version", "2.0.0"})
+	e.guid("bgt:id", bg.ID)
+	e.text("bgt:name", bg.Name)
+	e.text("bgt:description", bg.Description)
+	e.text("bgt:num-periods", strconv.Itoa(bg.NumPeriods))
+	bg.Recurrence.encodeAs(e, "bgt:recurrence")
+	e.slots("bgt:slots", bg.Slots)
+	e.nodes(bg.Extra)
+	e.close("gnc:budget")
+}
+
+func (r Recurrence) encode(e *encoder) {
+	r.encodeAs(e, "gnc:recurrence")
+}
+
+func (r Recurrence) encodeAs(e *encoder, name string) {
+	e.open(name, attr{"version", "1.0.0"})
+	e.text("recurrence:mult", strconv.Itoa(r.mult()))
+	e.text("recurrence:period_type", string(r.PeriodType))
+	e.gdate("recurrence:start", r.Start)
+	if r.WeekendAdj != "" && r.WeekendAdj != WeekendAdjustNone {
+		e.text("recurrence:weekend_adj", string(r.WeekendAdj))
+	}
+	e.close(name)
+}
+
+// Write encodes x as a gnc-v2 document, gzip compressed if x was read from
+// a compressed file or x.Compressed was set.
+func Write(w io.Writer, x *XML) error {
+	var gz *gzip.Writer
+	if x.Compressed {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	e := &encoder{w: bufio.NewWriter(w)}
+	x.encode(e)
+	if e.err != nil {
+		return e.err
+	}
+
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+
+	if gz != nil {
+		return gz.Close()
+	}
+
+	return nil
+}
+
+// WriteFile atomically replaces path with the encoded x.
+func WriteFile(path string, x *XML) error {
+	if x.Backend != BackendXML {
+		return ErrReadOnlyBackend
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		if err := Write(tmp, x); err != nil {
+			return err
+		}
+		if err := tmp.Sync(); err != nil {
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		if fi, err := os.Stat(path); err == nil {
+			if err := os.Chmod(tmp.Name(), fi.Mode()); err != nil {
+				return err
+			}
+		}
+		return os.Rename(tmp.Name(), path)
+	}()
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// Backup copies path to a timestamped sibling the same way GnuCash names
+// its backups (e.g.: book.gnucash.20060102150405.gnucash) and returns the
+// name of the copy.
+func Backup(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	ext := filepath.Ext(path)
+	if ext == "" {
+		ext = ".gnucash"
+	}
+	dst := fmt.Sprintf("%s.%s%s", path, time.Now().Format("20060102150405"), ext)
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	return dst, f.Close()
+}
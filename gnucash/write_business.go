@@ -0,0 +1,209 @@
+package gnucash
+
+import "strconv"
+
+func (b *Book) encodeBusiness(e *encoder) {
+	for _, bt := range b.BillTerms {
+		bt.encode(e)
+	}
+	for _, c := range b.Customers {
+		c.encode(e)
+	}
+	for _, en := range b.Entries {
+		en.encode(e)
+	}
+	for _, inv := range b.Invoices {
+		inv.encode(e)
+	}
+	for _, j := range b.Jobs {
+		j.encode(e)
+	}
+	for _, tt := range b.TaxTables {
+		tt.encode(e)
+	}
+	for _, v := range b.Vendors {
+		v.encode(e)
+	}
+}
+
+func (e *encoder) owner(name string, o Owner) {
+	e.open(name, attr{"version", "2.0.0"})
+	e.text("owner:type", string(o.Type))
+	e.guid("owner:id", o.ID)
+	e.close(name)
+}
+
+func (e *encoder) address(name string, a Address) {
+	e.open(name, attr{"version", "2.0.0"})
+	e.optional("addr:name", a.Name)
+	e.optional("addr:addr1", a.Addr1)
+	e.optional("addr:addr2", a.Addr2)
+	e.optional("addr:addr3", a.Addr3)
+	e.optional("addr:addr4", a.Addr4)
+	e.optional("addr:phone", a.Phone)
+	e.optional("addr:fax", a.Fax)
+	e.optional("addr:email", a.Email)
+	e.nodes(a.Extra)
+	e.close(name)
+}
+
+func (c *Customer) encode(e *encoder) {
+	e.open("gnc:GncCustomer", attr{"version", "2.0.0"})
+	e.guid("cust:guid", c.GUID)
+	e.text("cust:name", c.Name)
+	e.text("cust:id", c.ID)
+	e.address("cust:addr", c.Addr)
+	e.address("cust:shipaddr", c.ShipAddr)
+	e.optional("cust:notes", c.Notes)
+	e.optionalGUID("cust:terms", c.TermsID)
+	e.text("cust:taxincluded", c.TaxIncluded)
+	e.bit("cust:active", c.Active)
+	e.text("cust:discount", c.Discount.String())
+	e.text("cust:credit", c.Credit.String())
+	if c.Currency.ID != "" {
+		e.commodity("cust:currency", c.Currency)
+	}
+	e.bit("cust:use-tt", c.UseTaxTable)
+	e.optionalGUID("cust:taxtable", c.TaxTableID)
+	e.slots("cust:slots", c.Slots)
+	e.nodes(c.Extra)
+	e.close("gnc:GncCustomer")
+}
+
+func (v *Vendor) encode(e *encoder) {
+	e.open("gnc:GncVendor", attr{"version", "2.0.0"})
+	e.guid("vendor:guid", v.GUID)
+	e.text("vendor:name", v.Name)
+	e.text("vendor:id", v.ID)
+	e.address("vendor:addr", v.Addr)
+	e.optional("vendor:notes", v.Notes)
+	e.optionalGUID("vendor:terms", v.TermsID)
+	e.text("vendor:taxincluded", v.TaxIncluded)
+	e.bit("vendor:active", v.Active)
+	if v.Currency.ID != "" {
+		e.commodity("vendor:currency", v.Currency)
+	}
+	e.bit("vendor:use-tt", v.UseTaxTable)
+	e.optionalGUID("vendor:taxtable", v.TaxTableID)
+	e.slots("vendor:slots", v.Slots)
+	e.nodes(v.Extra)
+	e.close("gnc:GncVendor")
+}
+
+func (j *Job) encode(e *encoder) {
+	e.open("gnc:GncJob", attr{"version", "2.0.0"})
+	e.guid("job:guid", j.GUID)
+	e.text("job:id", j.ID)
+	e.text("job:name", j.Name)
+	e.optional("job:reference", j.Reference)
+	e.owner("job:owner", j.Owner)
+	e.bit("job:active", j.Active)
+	e.slots("job:slots", j.Slots)
+	e.nodes(j.Extra)
+	e.close("gnc:GncJob")
+}
+
+func (inv *Invoice) encode(e *encoder) {
+	e.open("gnc:GncInvoice", attr{"version", "2.0.0"})
+	e.guid("invoice:guid", inv.GUID)
+	e.text("invoice:id", inv.ID)
+	e.owner("invoice:owner", inv.Owner)
+	e.date("invoice:opened", inv.Opened)
+	if !inv.Posted.Empty() {
+		e.date("invoice:posted", inv.Posted)
+	}
+	e.optional("invoice:billing_id", inv.BillingID)
+	e.optional("invoice:notes", inv.Notes)
+	e.bit("invoice:active", inv.Active)
+	e.optionalGUID("invoice:posttxn", inv.PostTransactionID)
+	e.optionalGUID("invoice:postlot", inv.PostLotID)
+	e.optionalGUID("invoice:postacc", inv.PostAccountID)
+	e.commodity("invoice:currency", inv.Currency)
+	e.optionalGUID("invoice:terms", inv.TermsID)
+	if inv.BillTo.Type != "" {
+		e.owner("invoice:billto", inv.BillTo)
+	}
+	e.slots("invoice:slots", inv.Slots)
+	e.nodes(inv.Extra)
+	e.close("gnc:GncInvoice")
+}
+
+func (en *Entry) encode(e *encoder) {
+	e.open("gnc:GncEntry", attr{"version", "2.0.0"})
+	e.guid("entry:guid", en.GUID)
+	e.date("entry:date", en.Date)
+	e.date("entry:entered", en.Entered)
+	e.optional("entry:description", en.Description)
+	e.optional("entry:action", en.Action)
+	e.optional("entry:notes", en.Notes)
+	e.text("entry:qty", en.Quantity.String())
+	e.optionalGUID("entry:i-acct", en.InvAccountID)
+	if en.InvAccountID != "" {
+		e.text("entry:i-price", en.InvPrice.String())
+	}
+	e.optionalGUID("entry:invoice", en.InvoiceID)
+	e.optionalGUID("entry:b-acct", en.BillAccountID)
+	if en.BillAccountID != "" {
+		e.text("entry:b-price", en.BillPrice.String())
+	}
+	e.optionalGUID("entry:bill", en.BillID)
+	e.nodes(en.Extra)
+	e.slots("entry:slots", en.Slots)
+	e.close("gnc:GncEntry")
+}
+
+func (tt *TaxTable) encode(e *encoder) {
+	e.open("gnc:GncTaxTable", attr{"version", "2.0.0"})
+	e.guid("taxtable:guid", tt.GUID)
+	e.text("taxtable:name", tt.Name)
+	e.text("taxtable:refcount", strconv.Itoa(tt.RefCount))
+	e.bit("taxtable:invisible", tt.Invisible)
+	e.nodes(tt.Extra)
+	e.open("taxtable:entries")
+	for _, en := range tt.Entries {
+		e.open("gnc:GncTaxTableEntry")
+		e.guid("tte:acct", en.AccountID)
+		e.text("tte:amount", en.Amount.String())
+		e.text("tte:type", en.Type)
+		e.nodes(en.Extra)
+		e.close("gnc:GncTaxTableEntry")
+	}
+	e.close("taxtable:entries")
+	e.close("gnc:GncTaxTable")
+}
+
+func (bt *BillTerm) encode(e *encoder) {
+	e.open("gnc:GncBillTerm", attr{"version", "2.0.0"})
+	e.guid("billterm:guid", bt.GUID)
+	e.text("billterm:name", bt.Name)
+	e.text("billterm:desc", bt.Description)
+	e.text("billterm:refcount", strconv.Itoa(bt.RefCount))
+	e.bit("billterm:invisible", bt.Invisible)
+	e.nodes(bt.Extra)
+	if d := bt.Days; d != nil {
+		e.open("billterm:days")
+		e.text("bt-days:due-days", strconv.Itoa(d.DueDays))
+		if d.DiscountDays != 0 {
+			e.text("bt-days:disc-days", strconv.Itoa(d.DiscountDays))
+		}
+		if !d.Discount.IsZero() {
+			e.text("bt-days:discount", d.Discount.String())
+		}
+		e.close("billterm:days")
+	}
+	if p := bt.Proximo; p != nil {
+		e.open("billterm:proximo")
+		e.text("bt-prox:due-day", strconv.Itoa(p.DueDay))
+		if p.DiscountDay != 0 {
+			e.text("bt-prox:disc-day", strconv.Itoa(p.DiscountDay))
+		}
+		if !p.Discount.IsZero() {
+			e.text("bt-prox:discount", p.Discount.String())
+		}
+		if p.CutoffDay != 0 {
+			e.text("bt-prox:cutoff-day", strconv.Itoa(p.CutoffDay))
+		}
+		e.close("billterm:proximo")
+	}
+	e.close("gnc:GncBillTerm")
+}
@@ -0,0 +1,623 @@
+package gnucash
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const roundTripBook = `<?xml version="1.0" encoding="utf-8" ?>
+<gnc-v2
+     xmlns:gnc="http://www.gnucash.org/XML/gnc"
+     xmlns:act="http://www.gnucash.org/XML/act"
+     xmlns:book="http://www.gnucash.org/XML/book"
+     xmlns:cd="http://www.gnucash.org/XML/cd"
+     xmlns:cmdty="http://www.gnucash.org/XML/cmdty"
+     xmlns:price="http://www.gnucash.org/XML/price"
+     xmlns:slot="http://www.gnucash.org/XML/slot"
+     xmlns:split="http://www.gnucash.org/XML/split"
+     xmlns:sx="http://www.gnucash.org/XML/sx"
+     xmlns:trn="http://www.gnucash.org/XML/trn"
+     xmlns:ts="http://www.gnucash.org/XML/ts"
+     xmlns:fs="http://www.gnucash.org/XML/fs"
+     xmlns:bgt="http://www.gnucash.org/XML/bgt"
+     xmlns:recurrence="http://www.gnucash.org/XML/recurrence"
+     xmlns:lot="http://www.gnucash.org/XML/lot"
+     xmlns:addr="http://www.gnucash.org/XML/addr"
+     xmlns:billterm="http://www.gnucash.org/XML/billterm"
+     xmlns:bt-days="http://www.gnucash.org/XML/bt-days"
+     xmlns:bt-prox="http://www.gnucash.org/XML/bt-prox"
+     xmlns:cust="http://www.gnucash.org/XML/cust"
+     xmlns:employee="http://www.gnucash.org/XML/employee"
+     xmlns:entry="http://www.gnucash.org/XML/entry"
+     xmlns:invoice="http://www.gnucash.org/XML/invoice"
+     xmlns:job="http://www.gnucash.org/XML/job"
+     xmlns:order="http://www.gnucash.org/XML/order"
+     xmlns:owner="http://www.gnucash.org/XML/owner"
+     xmlns:taxtable="http://www.gnucash.org/XML/taxtable"
+     xmlns:tte="http://www.gnucash.org/XML/tte"
+     xmlns:vendor="http://www.gnucash.org/XML/vendor">
+<gnc:count-data cd:type="book">1</gnc:count-data>
+<gnc:book version="2.0.0">
+<book:id type="guid">b0000000000000000000000000000001</book:id>
+<book:slots>
+  <slot>
+    <slot:key>features</slot:key>
+    <slot:value type="frame">
+      <slot>
+        <slot:key>Register sort and filter settings stored in .gcm file</slot:key>
+        <slot:value type="string">Store the register sort and filter settings in .gcm metadata file</slot:value>
+      </slot>
+    </slot:value>
+  </slot>
+</book:slots>
+<gnc:count-data cd:type="commodity">2</gnc:count-data>
+<gnc:count-data cd:type="account">5</gnc:count-data>
+<gnc:count-data cd:type="transaction">1</gnc:count-data>
+<gnc:count-data cd:type="schedxaction">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncBillTerm">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncCustomer">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncEntry">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncInvoice">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncJob">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncOrder">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncTaxTable">1</gnc:count-data>
+<gnc:count-data cd:type="gnc:GncVendor">1</gnc:count-data>
+<gnc:count-data cd:type="price">1</gnc:count-data>
+<gnc:commodity version="2.0.0">
+  <cmdty:space>CURRENCY</cmdty:space>
+  <cmdty:id>EUR</cmdty:id>
+  <cmdty:get_quotes/>
+  <cmdty:quote_source>currency</cmdty:quote_source>
+  <cmdty:quote_tz/>
+</gnc:commodity>
+<gnc:commodity version="2.0.0">
+  <cmdty:space>NASDAQ</cmdty:space>
+  <cmdty:id>AAPL</cmdty:id>
+  <cmdty:name>Apple</cmdty:name>
+  <cmdty:xcode/>
+  <cmdty:fraction>10000</cmdty:fraction>
+</gnc:commodity>
+<gnc:pricedb version="1">
+  <price>
+    <price:id type="guid">p0000000000000000000000000000001</price:id>
+    <price:commodity>
+      <cmdty:space>NASDAQ</cmdty:space>
+      <cmdty:id>AAPL</cmdty:id>
+    </price:commodity>
+    <price:currency>
+      <cmdty:space>CURRENCY</cmdty:space>
+      <cmdty:id>EUR</cmdty:id>
+    </price:currency>
+    <price:time>
+      <ts:date>2024-01-10 10:59:00 +0000</ts:date>
+    </price:time>
+    <price:source>user:price-editor</price:source>
+    <price:type>last</price:type>
+    <price:value>20000/100</price:value>
+  </price>
+</gnc:pricedb>
+<gnc:account version="2.0.0">
+  <act:name>Root Account</act:name>
+  <act:id type="guid">a0000000000000000000000000000000</act:id>
+  <act:type>ROOT</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>Bank</act:name>
+  <act:id type="guid">a0000000000000000000000000000001</act:id>
+  <act:type>BANK</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+  <act:code>1000</act:code>
+  <act:description>Checking &amp; savings</act:description>
+  <act:slots>
+    <slot>
+      <slot:key>reconcile-info</slot:key>
+      <slot:value type="frame">
+        <slot>
+          <slot:key>last-date</slot:key>
+          <slot:value type="integer">1706698800</slot:value>
+        </slot>
+      </slot:value>
+    </slot>
+  </act:slots>
+  <act:parent type="guid">a0000000000000000000000000000000</act:parent>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>Income</act:name>
+  <act:id type="guid">a0000000000000000000000000000002</act:id>
+  <act:type>INCOME</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+  <act:parent type="guid">a0000000000000000000000000000000</act:parent>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>VAT</act:name>
+  <act:id type="guid">a0000000000000000000000000000003</act:id>
+  <act:type>LIABILITY</act:type>
+  <act:commodity>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>100</act:commodity-scu>
+  <act:parent type="guid">a0000000000000000000000000000000</act:parent>
+</gnc:account>
+<gnc:account version="2.0.0">
+  <act:name>Broker</act:name>
+  <act:id type="guid">a0000000000000000000000000000004</act:id>
+  <act:type>STOCK</act:type>
+  <act:commodity>
+    <cmdty:space>NASDAQ</cmdty:space>
+    <cmdty:id>AAPL</cmdty:id>
+  </act:commodity>
+  <act:commodity-scu>10000</act:commodity-scu>
+  <act:parent type="guid">a0000000000000000000000000000000</act:parent>
+</gnc:account>
+<gnc:transaction version="2.0.0">
+  <trn:id type="guid">t0000000000000000000000000000001</trn:id>
+  <trn:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </trn:currency>
+  <trn:num>42</trn:num>
+  <trn:date-posted>
+    <ts:date>2024-01-01 10:59:00 +0000</ts:date>
+  </trn:date-posted>
+  <trn:date-entered>
+    <ts:date>2024-01-01 12:00:00 +0000</ts:date>
+  </trn:date-entered>
+  <trn:description>Salary &lt;january&gt;</trn:description>
+  <trn:slots>
+    <slot>
+      <slot:key>date-posted</slot:key>
+      <slot:value type="gdate">
+        <gdate>2024-01-01</gdate>
+      </slot:value>
+    </slot>
+    <slot>
+      <slot:key>notes</slot:key>
+      <slot:value type="string">paid late</slot:value>
+    </slot>
+  </trn:slots>
+  <trn:splits>
+    <trn:split>
+      <split:id type="guid">s0000000000000000000000000000001</split:id>
+      <split:memo>net</split:memo>
+      <split:reconciled-state>y</split:reconciled-state>
+      <split:reconcile-date>
+        <ts:date>2024-01-31 10:59:00 +0000</ts:date>
+      </split:reconcile-date>
+      <split:value>300000/100</split:value>
+      <split:quantity>300000/100</split:quantity>
+      <split:account type="guid">a0000000000000000000000000000001</split:account>
+    </trn:split>
+    <trn:split>
+      <split:id type="guid">s0000000000000000000000000000002</split:id>
+      <split:action>Pay</split:action>
+      <split:reconciled-state>n</split:reconciled-state>
+      <split:value>-300000/100</split:value>
+      <split:quantity>-300000/100</split:quantity>
+      <split:account type="guid">a0000000000000000000000000000002</split:account>
+      <split:slots>
+        <slot>
+          <slot:key>online_id</slot:key>
+          <slot:value type="string">ABC123</slot:value>
+        </slot>
+      </split:slots>
+    </trn:split>
+  </trn:splits>
+</gnc:transaction>
+<gnc:template-transactions>
+  <gnc:account version="2.0.0">
+    <act:name>Template Root</act:name>
+    <act:id type="guid">r0000000000000000000000000000000</act:id>
+    <act:type>ROOT</act:type>
+  </gnc:account>
+  <gnc:account version="2.0.0">
+    <act:name>x0000000000000000000000000000001</act:name>
+    <act:id type="guid">r0000000000000000000000000000001</act:id>
+    <act:type>BANK</act:type>
+    <act:commodity>
+      <cmdty:space>template</cmdty:space>
+      <cmdty:id>template</cmdty:id>
+    </act:commodity>
+    <act:commodity-scu>1</act:commodity-scu>
+    <act:parent type="guid">r0000000000000000000000000000000</act:parent>
+  </gnc:account>
+  <gnc:transaction version="2.0.0">
+    <trn:id type="guid">t0000000000000000000000000000002</trn:id>
+    <trn:currency>
+      <cmdty:space>CURRENCY</cmdty:space>
+      <cmdty:id>EUR</cmdty:id>
+    </trn:currency>
+    <trn:date-posted>
+      <ts:date>2024-01-01 10:59:00 +0000</ts:date>
+    </trn:date-posted>
+    <trn:date-entered>
+      <ts:date>2024-01-01 10:59:00 +0000</ts:date>
+    </trn:date-entered>
+    <trn:description>Salary</trn:description>
+    <trn:splits>
+      <trn:split>
+        <split:id type="guid">s0000000000000000000000000000003</split:id>
+        <split:reconciled-state>n</split:reconciled-state>
+        <split:value>0/1</split:value>
+        <split:quantity>0/1</split:quantity>
+        <split:account type="guid">r0000000000000000000000000000001</split:account>
+        <split:slots>
+          <slot>
+            <slot:key>sched-xaction</slot:key>
+            <slot:value type="frame">
+              <slot>
+                <slot:key>account</slot:key>
+                <slot:value type="guid">a0000000000000000000000000000001</slot:value>
+              </slot>
+              <slot>
+                <slot:key>credit-formula</slot:key>
+                <slot:value type="string"/>
+              </slot>
+              <slot>
+                <slot:key>debit-formula</slot:key>
+                <slot:value type="string">3000</slot:value>
+              </slot>
+              <slot>
+                <slot:key>debit-numeric</slot:key>
+                <slot:value type="numeric">3000/1</slot:value>
+              </slot>
+            </slot:value>
+          </slot>
+        </split:slots>
+      </trn:split>
+      <trn:split>
+        <split:id type="guid">s0000000000000000000000000000004</split:id>
+        <split:reconciled-state>n</split:reconciled-state>
+        <split:value>0/1</split:value>
+        <split:quantity>0/1</split:quantity>
+        <split:account type="guid">r0000000000000000000000000000001</split:account>
+        <split:slots>
+          <slot>
+            <slot:key>sched-xaction</slot:key>
+            <slot:value type="frame">
+              <slot>
+                <slot:key>account</slot:key>
+                <slot:value type="guid">a0000000000000000000000000000002</slot:value>
+              </slot>
+              <slot>
+                <slot:key>credit-formula</slot:key>
+                <slot:value type="string">3000</slot:value>
+              </slot>
+              <slot>
+                <slot:key>credit-numeric</slot:key>
+                <slot:value type="numeric">3000/1</slot:value>
+              </slot>
+            </slot:value>
+          </slot>
+        </split:slots>
+      </trn:split>
+    </trn:splits>
+  </gnc:transaction>
+</gnc:template-transactions>
+<gnc:schedxaction version="2.0.0">
+  <sx:id type="guid">x0000000000000000000000000000001</sx:id>
+  <sx:name>Salary</sx:name>
+  <sx:enabled>y</sx:enabled>
+  <sx:autoCreate>n</sx:autoCreate>
+  <sx:autoCreateNotify>n</sx:autoCreateNotify>
+  <sx:advanceCreateDays>0</sx:advanceCreateDays>
+  <sx:advanceRemindDays>0</sx:advanceRemindDays>
+  <sx:instanceCount>1</sx:instanceCount>
+  <sx:start>
+    <gdate>2024-01-01</gdate>
+  </sx:start>
+  <sx:last>
+    <gdate>2024-01-01</gdate>
+  </sx:last>
+  <sx:templ-acct type="guid">r0000000000000000000000000000001</sx:templ-acct>
+  <sx:schedule>
+    <gnc:recurrence version="1.0.0">
+      <recurrence:mult>1</recurrence:mult>
+      <recurrence:period_type>month</recurrence:period_type>
+      <recurrence:start>
+        <gdate>2024-01-01</gdate>
+      </recurrence:start>
+    </gnc:recurrence>
+  </sx:schedule>
+</gnc:schedxaction>
+<gnc:GncBillTerm version="2.0.0">
+  <billterm:guid type="guid">c0000000000000000000000000000001</billterm:guid>
+  <billterm:name>Net 30</billterm:name>
+  <billterm:desc>30 days</billterm:desc>
+  <billterm:refcount>1</billterm:refcount>
+  <billterm:invisible>0</billterm:invisible>
+  <billterm:days>
+    <bt-days:due-days>30</bt-days:due-days>
+  </billterm:days>
+</gnc:GncBillTerm>
+<gnc:GncCustomer version="2.0.0">
+  <cust:guid type="guid">c0000000000000000000000000000002</cust:guid>
+  <cust:name>Acme</cust:name>
+  <cust:id>000001</cust:id>
+  <cust:addr version="2.0.0">
+    <addr:name>Acme Corp</addr:name>
+    <addr:addr1>Street 1</addr:addr1>
+  </cust:addr>
+  <cust:terms type="guid">c0000000000000000000000000000001</cust:terms>
+  <cust:taxincluded>USEGLOBAL</cust:taxincluded>
+  <cust:active>1</cust:active>
+  <cust:discount>0/1</cust:discount>
+  <cust:credit>0/1</cust:credit>
+  <cust:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </cust:currency>
+  <cust:use-tt>0</cust:use-tt>
+</gnc:GncCustomer>
+<gnc:GncEntry version="2.0.0">
+  <entry:guid type="guid">c0000000000000000000000000000003</entry:guid>
+  <entry:date>
+    <ts:date>2024-01-05 10:59:00 +0000</ts:date>
+  </entry:date>
+  <entry:entered>
+    <ts:date>2024-01-05 10:59:00 +0000</ts:date>
+  </entry:entered>
+  <entry:description>Consulting</entry:description>
+  <entry:qty>10/1</entry:qty>
+  <entry:i-acct type="guid">a0000000000000000000000000000002</entry:i-acct>
+  <entry:i-price>100/1</entry:i-price>
+  <entry:invoice type="guid">c0000000000000000000000000000004</entry:invoice>
+  <entry:i-disc-type>PERCENT</entry:i-disc-type>
+  <entry:i-disc-how>PRETAX</entry:i-disc-how>
+  <entry:i-taxable>1</entry:i-taxable>
+  <entry:i-taxincluded>0</entry:i-taxincluded>
+  <entry:i-taxtable type="guid">c0000000000000000000000000000006</entry:i-taxtable>
+</gnc:GncEntry>
+<gnc:GncInvoice version="2.0.0">
+  <invoice:guid type="guid">c0000000000000000000000000000004</invoice:guid>
+  <invoice:id>000001</invoice:id>
+  <invoice:owner version="2.0.0">
+    <owner:type>gncJob</owner:type>
+    <owner:id type="guid">c0000000000000000000000000000005</owner:id>
+  </invoice:owner>
+  <invoice:opened>
+    <ts:date>2024-01-05 10:59:00 +0000</ts:date>
+  </invoice:opened>
+  <invoice:active>1</invoice:active>
+  <invoice:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </invoice:currency>
+  <invoice:terms type="guid">c0000000000000000000000000000001</invoice:terms>
+</gnc:GncInvoice>
+<gnc:GncJob version="2.0.0">
+  <job:guid type="guid">c0000000000000000000000000000005</job:guid>
+  <job:id>000001</job:id>
+  <job:name>Website</job:name>
+  <job:owner version="2.0.0">
+    <owner:type>gncCustomer</owner:type>
+    <owner:id type="guid">c0000000000000000000000000000002</owner:id>
+  </job:owner>
+  <job:active>1</job:active>
+</gnc:GncJob>
+<gnc:GncTaxTable version="2.0.0">
+  <taxtable:guid type="guid">c0000000000000000000000000000006</taxtable:guid>
+  <taxtable:name>VAT</taxtable:name>
+  <taxtable:refcount>1</taxtable:refcount>
+  <taxtable:invisible>0</taxtable:invisible>
+  <taxtable:entries>
+    <gnc:GncTaxTableEntry>
+      <tte:acct type="guid">a0000000000000000000000000000003</tte:acct>
+      <tte:amount>21/1</tte:amount>
+      <tte:type>PERCENT</tte:type>
+    </gnc:GncTaxTableEntry>
+  </taxtable:entries>
+</gnc:GncTaxTable>
+<gnc:GncVendor version="2.0.0">
+  <vendor:guid type="guid">c0000000000000000000000000000007</vendor:guid>
+  <vendor:name>Office Supplies Inc</vendor:name>
+  <vendor:id>000001</vendor:id>
+  <vendor:addr version="2.0.0">
+    <addr:name>OSI</addr:name>
+  </vendor:addr>
+  <vendor:taxincluded>USEGLOBAL</vendor:taxincluded>
+  <vendor:active>1</vendor:active>
+  <vendor:currency>
+    <cmdty:space>CURRENCY</cmdty:space>
+    <cmdty:id>EUR</cmdty:id>
+  </vendor:currency>
+  <vendor:use-tt>0</vendor:use-tt>
+</gnc:GncVendor>
+<gnc:GncOrder version="2.0.0">
+  <order:guid type="guid">c0000000000000000000000000000008</order:guid>
+  <order:id>000001</order:id>
+  <order:owner version="2.0.0">
+    <owner:type>gncVendor</owner:type>
+    <owner:id type="guid">c0000000000000000000000000000007</owner:id>
+  </order:owner>
+  <order:opened>
+    <ts:date>2024-01-05 10:59:00 +0000</ts:date>
+  </order:opened>
+  <order:active>1</order:active>
+</gnc:GncOrder>
+</gnc:book>
+</gnc-v2>
+`
+
+func TestWriteRoundTrip(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write([]byte(roundTripBook)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := map[string][]byte{
+		"plain": []byte(roundTripBook),
+		"gzip":  gz.Bytes(),
+	}
+	for name, input := range inputs {
+		x, err := Read(bytes.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if x.Compressed != (name == "gzip") {
+			t.Errorf("%s: compressed %t", name, x.Compressed)
+		}
+
+		var out bytes.Buffer
+		if err := Write(&out, x); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if x.Compressed && !bytes.HasPrefix(out.Bytes(), gzipMagic) {
+			t.Errorf("%s: output is not compressed", name)
+		}
+
+		y, err := Read(bytes.NewReader(out.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: reading written book: %s", name, err)
+		}
+		if !reflect.DeepEqual(x, y) {
+			t.Errorf("%s: book changed by a round-trip", name)
+		}
+
+		var again bytes.Buffer
+		if err := Write(&again, y); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !bytes.Equal(out.Bytes(), again.Bytes()) {
+			t.Errorf("%s: writing is not stable", name)
+		}
+	}
+
+	x, err := Read(strings.NewReader(roundTripBook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Write(&out, x); err != nil {
+		t.Fatal(err)
+	}
+	y, err := Read(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := y.Books[0]
+	counts := map[string]int{
+		"accounts":          len(b.Accounts),
+		"transactions":      len(b.Transactions),
+		"templates":         len(b.Templates.Transactions),
+		"template accounts": len(b.Templates.Accounts),
+		"schedules":         len(b.Scheduled),
+		"bill terms":        len(b.BillTerms),
+		"customers":         len(b.Customers),
+		"entries":           len(b.Entries),
+		"invoices":          len(b.Invoices),
+		"jobs":              len(b.Jobs),
+		"tax tables":        len(b.TaxTables),
+		"vendors":           len(b.Vendors),
+		"orders":            len(b.Extra.Filter("GncOrder")),
+		"book count-data":   len(y.Extra.Filter("count-data")),
+		"count-data":        len(b.Extra.Filter("count-data")),
+	}
+	exp := map[string]int{
+		"accounts":          5,
+		"transactions":      1,
+		"templates":         1,
+		"template accounts": 2,
+		"schedules":         1,
+		"bill terms":        1,
+		"customers":         1,
+		"entries":           1,
+		"invoices":          1,
+		"jobs":              1,
+		"tax tables":        1,
+		"vendors":           1,
+		"orders":            1,
+		"book count-data":   1,
+		"count-data":        13,
+	}
+	for k, v := range exp {
+		if counts[k] != v {
+			t.Errorf("expected %d %s got %d", v, k, counts[k])
+		}
+	}
+
+	for _, n := range b.Extra.Filter("count-data") {
+		if countType(n) == "gnc:GncOrder" && n.Text != "1" {
+			t.Errorf("expected order count 1 got '%s'", n.Text)
+		}
+	}
+
+	bank, _ := b.AccountsLookup.ByGUID("a0000000000000000000000000000001")
+	if bank.Description != "Checking & savings" {
+		t.Errorf("description not unescaped: '%s'", bank.Description)
+	}
+	if v := bank.Slots.KeyValue()["reconcile-info"].RawValue.Slots; len(v) != 1 || v[0].RawValue.Value != "1706698800" {
+		t.Errorf("nested slots lost: %v", v)
+	}
+	tx := b.Transactions[0]
+	if tx.Description != "Salary <january>" || tx.Splits[1].Slots.KeyValue()["online_id"].RawValue.Value != "ABC123" {
+		t.Errorf("transaction changed: %s", tx)
+	}
+	if st, ok := b.Templates.Transactions[0].Splits[0].Template(); !ok || st.Debit != "3000" {
+		t.Errorf("template split changed: %v", st)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "book.gnucash")
+	if err := os.WriteFile(path, []byte(roundTripBook), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Books[0].Transactions[0].Description = "Changed"
+	if err := WriteFile(path, x); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0640 {
+		t.Errorf("expected mode 0640 got %o", fi.Mode().Perm())
+	}
+	if l, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*.tmp")); len(l) != 0 {
+		t.Errorf("temporary files left behind: %v", l)
+	}
+
+	y, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := y.Books[0].Transactions[0].Description; d != "Changed" {
+		t.Errorf("expected the changed description got '%s'", d)
+	}
+
+	y.Backend = BackendSQLite
+	if err := WriteFile(path, y); !errors.Is(err, ErrReadOnlyBackend) {
+		t.Errorf("expected %v got %v", ErrReadOnlyBackend, err)
+	}
+}
@@ -1,13 +1,22 @@
 package gnucash
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"io"
+	"os"
 
 	nxml "encoding/xml"
 )
 
+var gzipMagic = []byte{0x1f, 0x8b}
+
 type XML struct {
-	Books Books `xml:"book"`
+	Books      Books   `xml:"book"`
+	Backend    Backend `xml:"-"`
+	Compressed bool    `xml:"-"`
+	Extra      Nodes   `xml:",any"`
 }
 
 func (x *XML) String() string {
@@ -22,9 +31,29 @@ func (x *XML) validate() error {
 	return nil
 }
 
+func decompress(r io.Reader) (io.Reader, bool, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+
+	if !bytes.Equal(magic, gzipMagic) {
+		return br, false, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	return gz, true, err
+}
+
 func Read(r io.Reader) (*XML, error) {
+	r, compressed, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+
 	dec := nxml.NewDecoder(r)
-	xml := &XML{}
+	xml := &XML{Compressed: compressed}
 	if err := dec.Decode(xml); err != nil {
 		return nil, err
 	}
@@ -41,6 +70,11 @@ func (a *AccountsXML) validate() error {
 }
 
 func ReadAccounts(r io.Reader) (*AccountsXML, error) {
+	r, _, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+
 	dec := nxml.NewDecoder(r)
 	xml := &AccountsXML{}
 	if err := dec.Decode(xml); err != nil {
@@ -49,3 +83,56 @@ func ReadAccounts(r io.Reader) (*AccountsXML, error) {
 
 	return xml, xml.validate()
 }
+
+func isSQLite(f *os.File) (bool, error) {
+	magic := make([]byte, len(sqliteMagic))
+	n, err := io.ReadFull(f, magic)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(magic[:n], sqliteMagic), nil
+}
+
+// Open reads the xml (optionally gzip compressed) or sqlite book at path.
+func Open(path string) (*XML, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if sqlite, err := isSQLite(f); err != nil {
+		return nil, err
+	} else if sqlite {
+		return ReadSQLite(path)
+	}
+
+	return Read(f)
+}
+
+func OpenAccounts(path string) (*AccountsXML, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if sqlite, err := isSQLite(f); err != nil {
+		return nil, err
+	} else if sqlite {
+		xml, err := ReadSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(xml.Books) == 0 {
+			return &AccountsXML{}, nil
+		}
+		return &AccountsXML{Accounts: xml.Books[0].Accounts}, nil
+	}
+
+	return ReadAccounts(f)
+}